@@ -2,7 +2,11 @@ module github.com/yossefsabry/gotype
 
 go 1.24.6
 
-require github.com/gdamore/tcell/v2 v2.13.8
+require (
+	github.com/BurntSushi/toml v1.5.0
+	github.com/gdamore/tcell/v2 v2.13.8
+	go.etcd.io/bbolt v1.4.3
+)
 
 require (
 	github.com/gdamore/encoding v1.0.1 // indirect
@@ -0,0 +1,111 @@
+package analytics
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// Source is the history the dashboard reads from
+type Source interface {
+	Runs() []storage.Summary
+	KeyStats() (map[string]storage.KeyStat, error)
+	Fingerprint() string
+}
+
+// cacheVersion changes when the reports get new fields, an older cache
+// file is computed again
+const cacheVersion = 1
+
+// Dashboard hands out reports and only recomputes them when the history
+// changed. Reports are kept in memory and in a cache file next to the
+// history so the stats open instantly on the next start too.
+type Dashboard struct {
+	mu          sync.Mutex
+	source      Source
+	path        string
+	loaded      bool
+	fingerprint string
+	reports     map[string]Report
+}
+
+// content of the cache file
+type cacheFile struct {
+	Version     int               `json:"version"`
+	Fingerprint string            `json:"fingerprint"`
+	Reports     map[string]Report `json:"reports"`
+}
+
+// CachePath returns the cache file in the gotype config directory
+func CachePath(dir string) string {
+	return filepath.Join(dir, "analytics.json")
+}
+
+// NewDashboard creates a dashboard, path can be empty to skip the cache file
+func NewDashboard(source Source, path string) *Dashboard {
+	return &Dashboard{source: source, path: path, reports: map[string]Report{}}
+}
+
+// Report returns the report for a formula, from the cache when the history
+// did not change since it was computed
+func (d *Dashboard) Report(formula string) Report {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fingerprint := d.source.Fingerprint()
+	if !d.loaded {
+		d.loaded = true
+		d.readCache()
+	}
+	if fingerprint != d.fingerprint {
+		d.fingerprint = fingerprint
+		d.reports = map[string]Report{}
+	}
+	if report, ok := d.reports[formula]; ok {
+		return report
+	}
+	report := Compute(d.source.Runs(), formula, time.Local)
+	// the letters are only missing from the report when they can't be read
+	if stats, err := d.source.KeyStats(); err == nil {
+		report.Letters = Letters(stats)
+	}
+	d.reports[formula] = report
+	d.writeCache()
+	return report
+}
+
+// load the reports from the cache file, a broken file is just ignored
+func (d *Dashboard) readCache() {
+	if d.path == "" {
+		return
+	}
+	raw, err := os.ReadFile(d.path)
+	if err != nil {
+		return
+	}
+	var cache cacheFile
+	if err := json.Unmarshal(raw, &cache); err != nil || cache.Version != cacheVersion || cache.Reports == nil {
+		return
+	}
+	d.fingerprint = cache.Fingerprint
+	d.reports = cache.Reports
+}
+
+// save the reports, the cache is only an optimisation so errors are dropped
+func (d *Dashboard) writeCache() {
+	if d.path == "" {
+		return
+	}
+	raw, err := json.Marshal(cacheFile{Version: cacheVersion, Fingerprint: d.fingerprint, Reports: d.reports})
+	if err != nil {
+		return
+	}
+	tmp := d.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, d.path)
+}
@@ -0,0 +1,43 @@
+package analytics
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+func TestDashboardLetters(t *testing.T) {
+	backend := storage.NewMemory()
+	for _, run := range []storage.Run{
+		{Summary: storage.Summary{ID: "1", Options: storage.RunOptions{Formula: "standard"}},
+			Keys: map[string]storage.KeyStat{"e": {Hits: 9, Misses: 1}, "t": {Hits: 5}}},
+		{Summary: storage.Summary{ID: "2", Options: storage.RunOptions{Formula: "plain"}},
+			Keys: map[string]storage.KeyStat{"e": {Hits: 1, Misses: 1}}},
+	} {
+		if _, err := backend.Append(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "analytics.json")
+
+	// the letters count the keys of every formula
+	report := NewDashboard(backend, path).Report("standard")
+	if report.Runs != 1 || len(report.Letters) != 2 || report.Letters[0].Key != "e" || report.Letters[0].Hits != 10 || report.Letters[0].Misses != 2 {
+		t.Fatalf("letters = %+v", report.Letters)
+	}
+
+	// a cache file of an older version is computed again
+	raw, err := json.Marshal(cacheFile{Fingerprint: backend.Fingerprint(), Reports: map[string]Report{"standard": {Runs: 42}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if report := NewDashboard(backend, path).Report("standard"); report.Runs != 1 || len(report.Letters) != 2 {
+		t.Fatalf("old cache used: %+v", report)
+	}
+}
@@ -0,0 +1,2 @@
+// Package analytics computes long-term progress numbers from the run history.
+package analytics
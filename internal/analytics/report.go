@@ -0,0 +1,252 @@
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// Report holds the long-term numbers for all runs of one formula, speeds
+// are in wpm and accuracy in percent
+type Report struct {
+	Formula      string     `json:"formula"`
+	Runs         int        `json:"runs"`
+	TotalSeconds int64      `json:"total_seconds"`
+	Rolling10    float64    `json:"rolling_10"`
+	Rolling100   float64    `json:"rolling_100"`
+	Accuracy10   float64    `json:"accuracy_10"`
+	Accuracy100  float64    `json:"accuracy_100"`
+	WeeklyGain   float64    `json:"weekly_gain"`
+	Keys         []KeyStats `json:"keys"`
+	Weeks        []Week     `json:"weeks"`
+	Days         []Day      `json:"days"`
+	// the typed keys of every run, not only of this formula
+	Letters []Letter `json:"letters"`
+}
+
+// KeyStats are the numbers for one score key
+type KeyStats struct {
+	Key    string  `json:"key"`
+	Runs   int     `json:"runs"`
+	Best   int     `json:"best"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+}
+
+// Letter is how often one key of the keyboard was typed right and wrong
+type Letter struct {
+	Key      string  `json:"key"`
+	Hits     int     `json:"hits"`
+	Misses   int     `json:"misses"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// Label is the key as it is shown, a space can't be seen
+func (l Letter) Label() string {
+	if l.Key == " " {
+		return "space"
+	}
+	return l.Key
+}
+
+// Week is the average of all runs started in one week (monday to sunday)
+type Week struct {
+	Start    int64   `json:"start"`
+	Runs     int     `json:"runs"`
+	WPM      float64 `json:"wpm"`
+	Accuracy float64 `json:"accuracy"`
+}
+
+// Day is the time spent typing on one day
+type Day struct {
+	Date    string `json:"date"`
+	Runs    int    `json:"runs"`
+	Seconds int64  `json:"seconds"`
+}
+
+// Compute builds the report for the runs of one formula, an empty formula
+// uses every run. Runs can be in any order, imported runs are appended to
+// the history after newer ones so they are sorted by start time here.
+func Compute(runs []storage.Summary, formula string, loc *time.Location) Report {
+	report := Report{Formula: formula}
+	selected := make([]storage.Summary, 0, len(runs))
+	for _, run := range runs {
+		if formula == "" || run.Options.Formula == formula {
+			selected = append(selected, run)
+		}
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return selected[i].StartedAt < selected[j].StartedAt
+	})
+	report.Runs = len(selected)
+	if len(selected) == 0 {
+		return report
+	}
+	report.Rolling10, report.Accuracy10 = rolling(selected, 10)
+	report.Rolling100, report.Accuracy100 = rolling(selected, 100)
+
+	byKey := map[string][]int{}
+	days := map[string]*Day{}
+	weeks := map[int64]*Week{}
+	for _, run := range selected {
+		byKey[run.Key] = append(byKey[run.Key], run.Metrics.WPM)
+		seconds := runSeconds(run)
+		report.TotalSeconds += seconds
+
+		started := time.UnixMilli(run.StartedAt).In(loc)
+		date := started.Format("2006-01-02")
+		day, ok := days[date]
+		if !ok {
+			day = &Day{Date: date}
+			days[date] = day
+		}
+		day.Runs++
+		day.Seconds += seconds
+
+		weekStart := startOfWeek(started).UnixMilli()
+		week, ok := weeks[weekStart]
+		if !ok {
+			week = &Week{Start: weekStart}
+			weeks[weekStart] = week
+		}
+		// running sums, turned into averages below
+		week.Runs++
+		week.WPM += float64(run.Metrics.WPM)
+		week.Accuracy += float64(run.Metrics.Accuracy)
+	}
+
+	for key, values := range byKey {
+		sort.Ints(values)
+		sum := 0
+		for _, value := range values {
+			sum += value
+		}
+		report.Keys = append(report.Keys, KeyStats{
+			Key:    key,
+			Runs:   len(values),
+			Best:   values[len(values)-1],
+			Mean:   float64(sum) / float64(len(values)),
+			Median: Percentile(values, 50),
+			P90:    Percentile(values, 90),
+		})
+	}
+	sort.Slice(report.Keys, func(i, j int) bool {
+		if report.Keys[i].Runs != report.Keys[j].Runs {
+			return report.Keys[i].Runs > report.Keys[j].Runs
+		}
+		return report.Keys[i].Key < report.Keys[j].Key
+	})
+
+	for _, day := range days {
+		report.Days = append(report.Days, *day)
+	}
+	sort.Slice(report.Days, func(i, j int) bool { return report.Days[i].Date < report.Days[j].Date })
+
+	for _, week := range weeks {
+		week.WPM /= float64(week.Runs)
+		week.Accuracy /= float64(week.Runs)
+		report.Weeks = append(report.Weeks, *week)
+	}
+	sort.Slice(report.Weeks, func(i, j int) bool { return report.Weeks[i].Start < report.Weeks[j].Start })
+	report.WeeklyGain = weeklyGain(report.Weeks)
+	return report
+}
+
+// Letters turns the key totals of the storage into letters, the least
+// accurate first and the most missed first between equals
+func Letters(stats map[string]storage.KeyStat) []Letter {
+	letters := make([]Letter, 0, len(stats))
+	for key, stat := range stats {
+		total := stat.Hits + stat.Misses
+		if total <= 0 {
+			continue
+		}
+		letters = append(letters, Letter{
+			Key:      key,
+			Hits:     stat.Hits,
+			Misses:   stat.Misses,
+			Accuracy: float64(stat.Hits) / float64(total) * 100,
+		})
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		if letters[i].Accuracy != letters[j].Accuracy {
+			return letters[i].Accuracy < letters[j].Accuracy
+		}
+		if letters[i].Misses != letters[j].Misses {
+			return letters[i].Misses > letters[j].Misses
+		}
+		return letters[i].Key < letters[j].Key
+	})
+	return letters
+}
+
+// Percentile returns the p-th percentile of sorted values with linear
+// interpolation between the closest ranks
+func Percentile(sorted []int, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return float64(sorted[lower])
+	}
+	weight := rank - float64(lower)
+	return float64(sorted[lower])*(1-weight) + float64(sorted[upper])*weight
+}
+
+// average wpm and accuracy of the last n runs
+func rolling(runs []storage.Summary, n int) (float64, float64) {
+	if len(runs) < n {
+		n = len(runs)
+	}
+	wpm, accuracy := 0.0, 0.0
+	for _, run := range runs[len(runs)-n:] {
+		wpm += float64(run.Metrics.WPM)
+		accuracy += float64(run.Metrics.Accuracy)
+	}
+	return wpm / float64(n), accuracy / float64(n)
+}
+
+// weeklyGain is the slope of the weekly averages in wpm per week, using a
+// least squares fit so one bad week doesn't flip the trend
+func weeklyGain(weeks []Week) float64 {
+	if len(weeks) < 2 {
+		return 0
+	}
+	const weekMillis = float64(7 * 24 * time.Hour / time.Millisecond)
+	first := weeks[0].Start
+	n := float64(len(weeks))
+	sumX, sumY, sumXY, sumXX := 0.0, 0.0, 0.0, 0.0
+	for _, week := range weeks {
+		x := float64(week.Start-first) / weekMillis
+		sumX += x
+		sumY += week.WPM
+		sumXY += x * week.WPM
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// seconds spent on one run
+func runSeconds(run storage.Summary) int64 {
+	if run.EndedAt <= run.StartedAt {
+		return 0
+	}
+	return (run.EndedAt - run.StartedAt) / 1000
+}
+
+// midnight of the monday of the week t is in
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -offset)
+}
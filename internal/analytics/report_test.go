@@ -0,0 +1,75 @@
+package analytics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+func TestPercentile(t *testing.T) {
+	values := []int{10, 20, 30, 40}
+	if got := Percentile(values, 50); got != 25 {
+		t.Fatalf("median = %v, want 25", got)
+	}
+	if got := Percentile(values, 90); got != 37 {
+		t.Fatalf("p90 = %v, want 37", got)
+	}
+	if got := Percentile([]int{7}, 90); got != 7 {
+		t.Fatalf("p90 of one value = %v, want 7", got)
+	}
+}
+
+func TestCompute(t *testing.T) {
+	start := time.Date(2026, 10, 5, 12, 0, 0, 0, time.UTC) // a monday
+	runs := []storage.Summary{}
+	for i := 0; i < 3; i++ {
+		// one run per week, getting 10 wpm faster every week
+		at := start.AddDate(0, 0, 7*i)
+		runs = append(runs, storage.Summary{
+			Key:       "time:60s",
+			StartedAt: at.UnixMilli(),
+			EndedAt:   at.Add(time.Minute).UnixMilli(),
+			Options:   storage.RunOptions{Formula: "standard"},
+			Metrics:   storage.Metrics{WPM: 50 + 10*i, Accuracy: 90},
+		})
+	}
+	runs = append(runs, storage.Summary{Options: storage.RunOptions{Formula: "plain"}})
+	report := Compute(runs, "standard", time.UTC)
+	if report.Runs != 3 {
+		t.Fatalf("runs = %d, want 3", report.Runs)
+	}
+	if report.Rolling10 != 60 {
+		t.Fatalf("rolling 10 = %v, want 60", report.Rolling10)
+	}
+	if report.WeeklyGain < 9.99 || report.WeeklyGain > 10.01 {
+		t.Fatalf("weekly gain = %v, want 10", report.WeeklyGain)
+	}
+	if report.TotalSeconds != 180 || len(report.Days) != 3 || len(report.Weeks) != 3 {
+		t.Fatalf("unexpected totals %+v", report)
+	}
+	if len(report.Keys) != 1 || report.Keys[0].Median != 60 || report.Keys[0].Best != 70 {
+		t.Fatalf("unexpected key stats %+v", report.Keys)
+	}
+}
+
+func TestLetters(t *testing.T) {
+	letters := Letters(map[string]storage.KeyStat{
+		"a": {Hits: 9, Misses: 1},
+		"b": {Hits: 18, Misses: 2},
+		" ": {Hits: 1, Misses: 1},
+		"c": {Hits: 5},
+		"d": {},
+	})
+	got := ""
+	for _, letter := range letters {
+		got += letter.Label() + ","
+	}
+	// same accuracy, more misses first, and keys never typed are left out
+	if got != "space,b,a,c," {
+		t.Fatalf("letters = %s", got)
+	}
+	if letters[1].Accuracy != 90 {
+		t.Fatalf("accuracy of b = %v", letters[1].Accuracy)
+	}
+}
@@ -0,0 +1,55 @@
+package analytics
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// WriteText prints a report in a plain format for the terminal
+func WriteText(w io.Writer, report Report) error {
+	formula := report.Formula
+	if formula == "" {
+		formula = "all"
+	}
+	if report.Runs == 0 {
+		_, err := fmt.Fprintf(w, "no runs for formula %s yet\n", formula)
+		return err
+	}
+	fmt.Fprintf(w, "formula:      %s\n", formula)
+	fmt.Fprintf(w, "tests:        %d\n", report.Runs)
+	fmt.Fprintf(w, "time typed:   %s\n", FormatSeconds(report.TotalSeconds))
+	fmt.Fprintf(w, "last 10:      %.1f wpm  %.1f%% acc\n", report.Rolling10, report.Accuracy10)
+	fmt.Fprintf(w, "last 100:     %.1f wpm  %.1f%% acc\n", report.Rolling100, report.Accuracy100)
+	fmt.Fprintf(w, "per week:     %+.2f wpm\n", report.WeeklyGain)
+
+	fmt.Fprintf(w, "\n%-50s  %5s  %5s  %6s  %6s\n", "score key", "runs", "best", "median", "p90")
+	for _, key := range report.Keys {
+		fmt.Fprintf(w, "%-50s  %5d  %5d  %6.1f  %6.1f\n", key.Key, key.Runs, key.Best, key.Median, key.P90)
+	}
+
+	fmt.Fprintf(w, "\n%-5s  %6s  %6s  %7s\n", "key", "hits", "misses", "acc")
+	for _, letter := range report.Letters {
+		fmt.Fprintf(w, "%-5s  %6d  %6d  %6.1f%%\n", letter.Label(), letter.Hits, letter.Misses, letter.Accuracy)
+	}
+
+	fmt.Fprintf(w, "\n%-10s  %5s  %7s  %7s\n", "week", "runs", "wpm", "acc")
+	for _, week := range report.Weeks {
+		start := time.UnixMilli(week.Start).Format("2006-01-02")
+		fmt.Fprintf(w, "%-10s  %5d  %7.1f  %6.1f%%\n", start, week.Runs, week.WPM, week.Accuracy)
+	}
+
+	fmt.Fprintf(w, "\n%-10s  %5s  %8s\n", "day", "runs", "time")
+	for _, day := range report.Days {
+		fmt.Fprintf(w, "%-10s  %5d  %8s\n", day.Date, day.Runs, FormatSeconds(day.Seconds))
+	}
+	return nil
+}
+
+// FormatSeconds prints a duration like "1h 05m" or "4m 10s"
+func FormatSeconds(seconds int64) string {
+	if seconds >= 3600 {
+		return fmt.Sprintf("%dh %02dm", seconds/3600, seconds%3600/60)
+	}
+	return fmt.Sprintf("%dm %02ds", seconds/60, seconds%60)
+}
@@ -0,0 +1,326 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// Action is one thing the user can do. Clicks on the top bar and its menu
+// row, the focused top bar and the command palette all run actions by id,
+// the id of a top bar action is the id of its region.
+type Action struct {
+	ID string
+	// the name keys are bound to, see the keymap package
+	Name string
+	// what the command palette and the help show
+	Title string
+	Run   func(m *Model, now time.Time) bool
+	// only keys run it, the palette leaves it out
+	keyOnly bool
+}
+
+// actions with an argument in the id, like theme:forest. list gives the
+// ones the palette offers right now.
+type actionFamily struct {
+	prefix string
+	list   func(m *Model) []Action
+	run    func(m *Model, arg string, now time.Time) bool
+}
+
+// the fixed actions in palette order, the ids that aren't regions start
+// with act:. They are set in init because the palette action lists them.
+var actionList []Action
+
+func init() {
+	actionList = fixedActions()
+}
+
+func fixedActions() []Action {
+	return []Action{
+		{ID: "act:restart", Name: "restart", Title: "new test", Run: func(m *Model, now time.Time) bool {
+			m.Reset()
+			return true
+		}},
+		{ID: "act:repeat", Name: "repeat", Title: "repeat test with the same text", Run: func(m *Model, now time.Time) bool {
+			m.ResetWithSeed(m.Seed)
+			return true
+		}},
+		{ID: "opt:punct", Name: "toggle-punctuation", Title: "toggle punctuation", Run: func(m *Model, now time.Time) bool {
+			m.Options.Punctuation = !m.Options.Punctuation
+			m.Reset()
+			return true
+		}},
+		{ID: "opt:numbers", Name: "toggle-numbers", Title: "toggle numbers", Run: func(m *Model, now time.Time) bool {
+			m.Options.Numbers = !m.Options.Numbers
+			m.Reset()
+			return true
+		}},
+		{ID: "mode:time", Name: "time-mode", Title: "time mode", Run: func(m *Model, now time.Time) bool {
+			return m.setMode(ModeTime)
+		}},
+		{ID: "mode:words", Name: "words-mode", Title: "words mode", Run: func(m *Model, now time.Time) bool {
+			return m.setMode(ModeWords)
+		}},
+		{ID: customSelectorRegion, Name: "custom-length", Title: "custom length", Run: func(m *Model, now time.Time) bool {
+			return m.toggleCustomInput()
+		}},
+		// the formula changes how the test is scored so start a new one
+		{ID: "opt:formula", Name: "next-formula", Title: "next speed formula", Run: func(m *Model, now time.Time) bool {
+			m.Options.Formula = nextFormula(m.Options.Formula)
+			m.Reset()
+			m.SetMessage("formula: "+formulaToString(m.Options.Formula), now, m.Tuning.MessageTime)
+			return true
+		}},
+		// the unit is only for display
+		{ID: "opt:unit", Name: "switch-unit", Title: "switch wpm and cpm", Run: func(m *Model, now time.Time) bool {
+			m.Unit = nextUnit(m.Unit)
+			m.SetMessage("unit: "+unitToString(m.Unit), now, m.Tuning.MessageTime)
+			return true
+		}},
+		{ID: "btn:presets", Name: "presets", Title: "presets menu", Run: func(m *Model, now time.Time) bool {
+			return m.togglePresetMenu()
+		}},
+		{ID: savePresetRegion, Name: "save-preset", Title: "save preset", Run: func(m *Model, now time.Time) bool {
+			if !m.PresetMenu {
+				m.togglePresetMenu()
+			}
+			return m.startNamingPreset()
+		}},
+		{ID: "btn:themes", Name: "themes", Title: "themes menu", Run: func(m *Model, now time.Time) bool {
+			return m.toggleThemeMenu()
+		}},
+		{ID: "act:next-theme", Name: "next-theme", Title: "next theme", Run: func(m *Model, now time.Time) bool {
+			return m.cycleTheme(1)
+		}},
+		{ID: "act:previous-theme", Name: "previous-theme", Title: "previous theme", Run: func(m *Model, now time.Time) bool {
+			return m.cycleTheme(-1)
+		}},
+		{ID: editThemeRegion, Name: "theme-editor", Title: "edit the theme", Run: func(m *Model, now time.Time) bool {
+			if m.View == ViewThemeEditor {
+				return m.CloseThemeEditor()
+			}
+			return m.OpenThemeEditor()
+		}},
+		{ID: "btn:history", Name: "history", Title: "history", Run: func(m *Model, now time.Time) bool {
+			if m.View == ViewHistory {
+				return m.CloseHistory()
+			}
+			return m.OpenHistory(now)
+		}},
+		{ID: "btn:stats", Name: "stats", Title: "stats", Run: func(m *Model, now time.Time) bool {
+			if m.View == ViewStats {
+				return m.CloseStats()
+			}
+			return m.OpenStats(now)
+		}},
+		{ID: "act:settings", Name: "settings", Title: "settings", Run: func(m *Model, now time.Time) bool {
+			if m.View == ViewSettings {
+				return m.CloseSettings()
+			}
+			return m.OpenSettings()
+		}},
+		{ID: "btn:profile", Name: "profiles", Title: "profiles menu", Run: func(m *Model, now time.Time) bool {
+			return m.toggleProfileMenu()
+		}},
+		{ID: "act:top-bar", Name: "top-bar", Title: "focus the top bar", Run: func(m *Model, now time.Time) bool {
+			return m.focusTopBar()
+		}},
+		{ID: "act:help", Name: "help", Title: "key bindings", Run: func(m *Model, now time.Time) bool {
+			m.Help = !m.Help
+			return true
+		}},
+		{ID: "act:quit", Name: "quit", Title: "quit", Run: func(m *Model, now time.Time) bool {
+			m.quit = true
+			return false
+		}},
+		{ID: "act:palette", Name: "palette", Title: "command palette", keyOnly: true, Run: func(m *Model, now time.Time) bool {
+			return m.openPalette()
+		}},
+		{ID: "act:delete-word", Name: "delete-word", Title: "delete the previous word", keyOnly: true, Run: func(m *Model, now time.Time) bool {
+			return !m.Timer.Finished && m.BackspaceWord(now)
+		}},
+		{ID: "act:review-up", Name: "review-up", Title: "scroll the results up", keyOnly: true, Run: func(m *Model, now time.Time) bool {
+			return m.ScrollReview(-1)
+		}},
+		{ID: "act:review-down", Name: "review-down", Title: "scroll the results down", keyOnly: true, Run: func(m *Model, now time.Time) bool {
+			return m.ScrollReview(1)
+		}},
+		{ID: "act:review-page-up", Name: "review-page-up", Title: "page the results up", keyOnly: true, Run: func(m *Model, now time.Time) bool {
+			return m.ScrollReview(-m.Tuning.VisibleLines)
+		}},
+		{ID: "act:review-page-down", Name: "review-page-down", Title: "page the results down", keyOnly: true, Run: func(m *Model, now time.Time) bool {
+			return m.ScrollReview(m.Tuning.VisibleLines)
+		}},
+		{ID: "act:review-top", Name: "review-top", Title: "top of the results", keyOnly: true, Run: func(m *Model, now time.Time) bool {
+			return m.ReviewTop()
+		}},
+		{ID: "act:review-bottom", Name: "review-bottom", Title: "bottom of the results", keyOnly: true, Run: func(m *Model, now time.Time) bool {
+			return m.ReviewBottom()
+		}},
+	}
+}
+
+var actionFamilies = []actionFamily{
+	// change the length for words or time and reset the test
+	{prefix: "sel:", list: func(m *Model) []Action {
+		actions := make([]Action, 0, len(m.Tuning.Selectors))
+		for _, option := range m.Tuning.Selectors {
+			label := option.LabelTime
+			if m.Options.Mode == ModeWords {
+				label = option.LabelWord + " words"
+			}
+			actions = append(actions, Action{ID: option.ID, Title: "length " + label})
+		}
+		return actions
+	}, run: func(m *Model, arg string, now time.Time) bool {
+		return m.selectLength("sel:" + arg)
+	}},
+	{prefix: "theme:", list: func(m *Model) []Action {
+		themes := ThemeOptions()
+		actions := make([]Action, 0, len(themes))
+		for _, theme := range themes {
+			actions = append(actions, Action{ID: ThemeRegionID(theme.ID), Title: "theme " + theme.Label})
+		}
+		return actions
+	}, run: func(m *Model, arg string, now time.Time) bool {
+		return m.selectTheme(ThemeRegionID(arg))
+	}},
+	{prefix: "preset:", list: func(m *Model) []Action {
+		actions := make([]Action, 0, len(m.Presets))
+		for _, preset := range m.Presets {
+			actions = append(actions, Action{ID: presetRegionID(preset.Name), Title: "preset " + preset.Name})
+		}
+		return actions
+	}, run: func(m *Model, arg string, now time.Time) bool {
+		return m.applyPreset(arg, now)
+	}},
+	{prefix: "profile:", list: func(m *Model) []Action {
+		actions := make([]Action, 0, len(m.Profiles))
+		for _, name := range m.Profiles {
+			actions = append(actions, Action{ID: profileRegionID(name), Title: "profile " + name})
+		}
+		return actions
+	}, run: func(m *Model, arg string, now time.Time) bool {
+		return m.selectProfile(arg, now)
+	}},
+}
+
+// find the action of an id, the fixed ones first so preset:+ isn't taken
+// for a preset
+func findAction(id string) (Action, bool) {
+	if index := slices.IndexFunc(actionList, func(a Action) bool { return a.ID == id }); index >= 0 {
+		return actionList[index], true
+	}
+	for _, family := range actionFamilies {
+		if arg, ok := strings.CutPrefix(id, family.prefix); ok {
+			run := family.run
+			return Action{ID: id, Run: func(m *Model, now time.Time) bool { return run(m, arg, now) }}, true
+		}
+	}
+	return Action{}, false
+}
+
+// every action the palette offers right now, with its title
+func (m *Model) actions() []Action {
+	var actions []Action
+	for _, action := range actionList {
+		if !action.keyOnly {
+			actions = append(actions, action)
+		}
+	}
+	for _, family := range actionFamilies {
+		actions = append(actions, family.list(m)...)
+	}
+	return actions
+}
+
+// the action keys bind to a name
+func actionByName(name string) (Action, bool) {
+	index := slices.IndexFunc(actionList, func(a Action) bool { return a.Name == name })
+	if index < 0 {
+		return Action{}, false
+	}
+	return actionList[index], true
+}
+
+// run the action a key is bound to
+func (m *Model) runNamedAction(name string, now time.Time) bool {
+	action, ok := actionByName(name)
+	if !ok {
+		return false
+	}
+	return action.Run(m, now)
+}
+
+// tells if an action asked to quit, once
+func (m *Model) takeQuit() bool {
+	quit := m.quit
+	m.quit = false
+	return quit
+}
+
+// run the action of an id, it reports if anything changed
+func (m *Model) runAction(id string, now time.Time) bool {
+	action, ok := findAction(id)
+	if !ok {
+		return false
+	}
+	return action.Run(m, now)
+}
+
+func (m *Model) setMode(mode Mode) bool {
+	m.Options.Mode = mode
+	m.Reset()
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	return true
+}
+
+func (m *Model) selectLength(id string) bool {
+	option, ok := selectorByID(m.Tuning.Selectors, id)
+	if !ok {
+		return false
+	}
+	if m.Options.Mode == ModeWords {
+		m.Options.WordCount = option.WordCount
+	} else {
+		m.Options.Duration = option.Duration
+	}
+	m.Reset()
+	return true
+}
+
+// themes and shit
+func (m *Model) toggleThemeMenu() bool {
+	open := !m.ThemeMenu
+	m.closeMenus()
+	m.ThemeMenu = open
+	m.Layout.MenuOpen = open
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	return true
+}
+
+// so the theme is just value for the theme that we want to change to it,
+// the region id of a theme gives the theme id and it becomes the current
+// theme
+func (m *Model) selectTheme(id string) bool {
+	themeID, ok := ThemeIDFromRegion(id)
+	if !ok {
+		return false
+	}
+	_ = m.SetTheme(themeID)
+	m.closeMenus()
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	return true
+}
+
+// step through the themes in menu order
+func (m *Model) cycleTheme(step int) bool {
+	themes := ThemeOptions()
+	if len(themes) == 0 {
+		return false
+	}
+	index := slices.IndexFunc(themes, func(t Theme) bool { return t.ID == m.ThemeID })
+	index = (index + step + len(themes)) % len(themes)
+	return m.SetTheme(themes[index].ID)
+}
@@ -1,10 +1,19 @@
 package app
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/analytics"
+	"github.com/yossefsabry/gotype/internal/config"
 	"github.com/yossefsabry/gotype/internal/storage"
+	"github.com/yossefsabry/gotype/internal/themes"
 )
 
 type App struct {
@@ -12,68 +21,280 @@ type App struct {
 	model    *Model
 	renderer *Renderer
 	store    *Persister
+	// the tty that says what the terminal background is, nil when it
+	// can't be asked like on the simulated screens of the tests
+	terminal *terminalTty
 	data     storage.Data
 	prefs    storage.Preferences
 	finished bool
+	// another window saved newer preferences, they are applied when no
+	// test is being typed
+	prefsBehind bool
+
+	// the config in use and the loader that reloads it when the file
+	// changes, nil without one
+	cfg             config.Config
+	config          *config.Loader
+	lastConfigCheck time.Time
+	// where the theme editor saves, empty without a config path
+	themesDir string
+}
+
+// RunConfig is everything Run needs, main fills it from the config and
+// the command line
+type RunConfig struct {
+	Config config.Config
+	// reloads the config file while running, nil to not watch it
+	Loader *config.Loader
+	// the user themes directory and what was wrong with its files when
+	// main loaded them, the problems are shown in the footer
+	ThemesDir string
+	ThemesErr error
+
+	// test options for this session only, the zero values keep the saved
+	// preferences. A preset is applied first and the other options on top.
+	Preset      string
+	Mode        *Mode
+	Duration    time.Duration
+	WordCount   int
+	ThemeID     string
+	Punctuation *bool
+	Numbers     *bool
+	// seed of the first test, random when nil
+	Seed *int64
+	// words to type instead of random ones, from --text
+	Text []string
+
+	// the terminal, nil opens the real one and tests pass a simulation
+	Screen tcell.Screen
 }
 
 // first initialization of the application
-func Run() error {
-	// creating new window for application
-	screen, err := tcell.NewScreen()
+func Run(cfg RunConfig) error {
+	// load the preferences and best scores first, a state file we can't
+	// read safely stops here before the terminal is taken over
+	stored, err := openStorage(cfg.Config.Profile, cfg.Config.Backend)
 	if err != nil {
 		return err
 	}
-	// initialize the screen
-	if err := screen.Init(); err != nil {
+	if _, ok := findPreset(stored.data.Presets, cfg.Preset); cfg.Preset != "" && !ok {
+		if stored.backend != nil {
+			stored.backend.Close()
+		}
+		return fmt.Errorf("no preset %q in profile %s, see gotype presets", cfg.Preset, cfg.Config.Profile)
+	}
+
+	// creating new window for application
+	screen := cfg.Screen
+	var terminal *terminalTty
+	if screen == nil {
+		screen, terminal, err = openScreen()
+	}
+	if err == nil {
+		// initialize the screen
+		err = screen.Init()
+	}
+	if err != nil {
+		if stored.backend != nil {
+			stored.backend.Close()
+		}
 		return err
 	}
 	screen.EnableMouse()
+	// coming back to the window asks for the background again
+	screen.EnableFocus()
 	// ensure the screen is finalized when the application exits 
 	// (freeing resources, restoring terminal state, etc.)
 	defer screen.Fini()
 
-	// from here get the data and initialize the model, renderer, and app
-	model := NewModel()
-	// load default preferences and best scores, and apply them to the model
-	path, data := loadPersistedData()
-	if applyPreferences(model, data.Preferences) {
-		model.Reset()
+	// there is were too start the main loop
+	app := &App{
+		screen:   screen,
+		model:    NewModel(),
+		renderer: NewRenderer(screen),
+		terminal: terminal,
+		cfg:       cfg.Config,
+		config:    cfg.Loader,
+		themesDir: cfg.ThemesDir,
 	}
-	if data.BestScores == nil {
-		data.BestScores = map[string]storage.BestScore{}
+	app.model.SetTuning(newTuning(cfg.Config))
+	// the auto theme goes by the background, the terminal's answer wins
+	// over COLORFGBG
+	app.model.Background = backgroundFromColorFGBG(os.Getenv("COLORFGBG"))
+	if terminal != nil {
+		if background, ok := terminal.start(); ok {
+			app.model.Background = background
+		}
+		defer terminal.stop()
+	}
+	// apply the loaded preferences to the model and start saving
+	app.attach(stored)
+	app.override(cfg)
+	if stored.warning != "" {
+		app.model.SetMessage(stored.warning, time.Now(), app.model.Tuning.WarningTime)
+	} else if cfg.ThemesErr != nil {
+		message, _, _ := strings.Cut(cfg.ThemesErr.Error(), "\n")
+		app.model.SetMessage("themes: "+message, time.Now(), app.model.Tuning.WarningTime)
 	}
-	data.Preferences = preferencesFromModel(model)
 	// auto calculate resize the layout based on the current screen size 
 	// and model options
 	width, height := screen.Size()
-	model.Layout.Recalculate(width, height, model.Options.Mode, model.focusActive())
+	app.model.Layout.Recalculate(width, height, app.model.Options.Mode, app.model.focusActive())
 
-	// persister is responsible for saving the preferences and best scores to disk,
-	// it runs in a separate goroutine and listens for changes to the data and 
-	// saves it asynchronously to avoid blocking the main UI thread
-	var persister *Persister
-	if path != "" {
-		persister = NewPersister(path)
+	err = app.loop()
+	// wait for the last write when the application exits, its error is
+	// the last chance to tell the user something wasn't saved
+	if app.store != nil {
+		if closeErr := app.store.Close(); err == nil {
+			err = closeErr
+		}
 	}
+	return err
+}
 
-	// there is were too start the main loop
-	app := &App{
-		screen:   screen,
-		model:    model,
-		renderer: NewRenderer(screen),
-		store:    persister,
-		data:     data,
-		prefs:    data.Preferences,
+// attach points the app and the model at the storage of a profile. The
+// persister is responsible for saving the preferences and best scores to
+// disk, it runs in a separate goroutine and listens for changes to the data
+// and saves it asynchronously to avoid blocking the main UI thread
+func (a *App) attach(stored persisted) {
+	data := stored.data
+	if data.BestScores == nil {
+		data.BestScores = map[string]storage.BestScore{}
 	}
-	// ensure the persister is closed when the application exits to clean up resources
-	if persister != nil {
-		defer persister.Close()
+	if applyPreferences(a.model, data.Preferences) {
+		a.model.Reset()
+	}
+	data.Preferences = preferencesFromModel(a.model)
+	a.data = data
+	a.prefs = data.Preferences
+	a.store = nil
+	a.model.Presets = data.Presets
+	a.model.Preset = ""
+	a.model.Profile = stored.profile
+	a.model.Profiles = listProfiles(stored.profile)
+	a.model.Layout.Profiles = a.model.Profiles
+	a.model.HistorySource = nil
+	a.model.StatsSource = nil
+	if stored.backend != nil {
+		a.store = NewPersister(stored.backend)
+		a.store.Watch(a.model.Tuning.Watch)
+		a.model.HistorySource = stored.backend
+		a.model.StatsSource = analytics.NewDashboard(stored.backend, analytics.CachePath(stored.dir))
 	}
-	return app.loop()
 }
 
-// loop main event that is run each 80 milliseconds to check for user input
+// override applies the test options from the command line, they only
+// become preferences when the user changes something in the app
+func (a *App) override(cfg RunConfig) {
+	if preset, ok := findPreset(a.model.Presets, cfg.Preset); ok {
+		a.model.Options = optionsFromRun(preset.Options)
+		if preset.ThemeID != "" {
+			a.model.SetTheme(ThemeByID(preset.ThemeID).ID)
+		}
+		a.model.Preset = preset.Name
+	}
+	options := &a.model.Options
+	if cfg.Mode != nil {
+		options.Mode = *cfg.Mode
+	}
+	if cfg.Duration > 0 {
+		options.Duration = cfg.Duration
+	}
+	if cfg.WordCount > 0 {
+		options.WordCount = cfg.WordCount
+	}
+	if cfg.Punctuation != nil {
+		options.Punctuation = *cfg.Punctuation
+	}
+	if cfg.Numbers != nil {
+		options.Numbers = *cfg.Numbers
+	}
+	if cfg.ThemeID != "" {
+		_ = a.model.SetTheme(cfg.ThemeID)
+	}
+	if len(cfg.Text) > 0 {
+		a.model.Generator.UseText(cfg.Text)
+		// the whole text once unless a word count was asked for
+		if cfg.WordCount <= 0 {
+			options.WordCount = len(cfg.Text)
+		}
+	}
+	if cfg.Seed != nil {
+		a.model.ResetWithSeed(*cfg.Seed)
+	} else {
+		a.model.Reset()
+	}
+	a.prefs = preferencesFromModel(a.model)
+}
+
+// switchProfile writes out everything of the current profile and moves
+// the app to another one, a profile that can't be opened leaves the
+// current one active
+func (a *App) switchProfile(name string, now time.Time) {
+	stored, err := openStorage(name, a.cfg.Backend)
+	if err != nil {
+		a.model.SetMessage("profile "+name+": "+err.Error(), now, a.model.Tuning.WarningTime)
+		return
+	}
+	message := "profile: " + name
+	if a.store != nil {
+		if err := a.store.Close(); err != nil {
+			message = "profile: " + name + ", last save failed: " + err.Error()
+		}
+	}
+	a.model.CloseHistory()
+	a.model.CloseStats()
+	a.attach(stored)
+	a.model.Reset()
+	a.finished = false
+	a.model.Layout.Recalculate(a.model.Layout.Width, a.model.Layout.Height,
+		a.model.Options.Mode, a.model.focusActive())
+	if stored.warning != "" {
+		message = stored.warning
+	}
+	a.model.SetMessage(message, now, a.model.Tuning.WarningTime)
+}
+
+// write the theme of the editor into the themes directory and switch to
+// it, on a failure the editor stays open with the edits
+func (a *App) saveTheme(file themes.File, now time.Time) {
+	target := filepath.Join(a.themesDir, file.ID+themes.Ext)
+	err := errors.New("no themes directory without a config path")
+	if a.themesDir != "" {
+		err = os.MkdirAll(a.themesDir, 0o755)
+	}
+	if err == nil {
+		err = writeThemeFile(target, file, "made in the gotype theme editor")
+	}
+	if err != nil {
+		a.model.SetMessage("theme "+file.ID+": "+err.Error(), now, a.model.Tuning.WarningTime)
+		return
+	}
+	message := "saved theme " + file.ID
+	if err := LoadThemes(a.themesDir); err != nil {
+		message += ", " + err.Error()
+	}
+	a.model.CloseThemeEditor()
+	a.model.SetTheme(file.ID)
+	a.model.Layout.Recalculate(a.model.Layout.Width, a.model.Layout.Height,
+		a.model.Options.Mode, a.model.focusActive())
+	a.model.SetMessage(message, now, a.model.Tuning.MessageTime)
+}
+
+// the saved profiles plus the active one, which has no directory yet when
+// nothing was saved in it
+func listProfiles(active string) []string {
+	profiles, err := storage.Profiles()
+	if err != nil {
+		return []string{active}
+	}
+	if !slices.Contains(profiles, active) {
+		profiles = append(profiles, active)
+	}
+	return profiles
+}
+
+// loop main event that is run each tick (80ms by default) to check for user input
 // and update the UI accordingly
 func (a *App) loop() error {
 	// for handle any interaction with the window
@@ -99,8 +320,9 @@ func (a *App) loop() error {
 		}
 	}()
 
-	// update UI each 80ms
-	ticker := time.NewTicker(80 * time.Millisecond)
+	// update UI each tick, 80ms unless the config says otherwise
+	tick := a.model.Tuning.Tick
+	ticker := time.NewTicker(tick)
 	// ensure stop when exit the function to clean up
 	defer ticker.Stop()
 
@@ -142,7 +364,18 @@ func (a *App) loop() error {
 				}
 				// handle new data and save it to disk if needed
 				a.syncPersistence(now)
+			case *tcell.EventFocus:
+				// the terminal may have changed its colours meanwhile
+				if ev.Focused && a.terminal != nil {
+					a.terminal.query()
+				}
 			case *tcell.EventMouse:
+				if ev.Buttons() == tcell.ButtonNone {
+					x, y := ev.Position()
+					if a.model.HandleHover(x, y) {
+						needsRender = true
+					}
+				}
 				if ev.Buttons()&tcell.Button1 != 0 {
 					now := time.Now()
 					x, y := ev.Position()
@@ -155,12 +388,38 @@ func (a *App) loop() error {
 				}
 			}
 
+		// the terminal background changed, the auto theme follows it
+		case background := <-a.backgrounds():
+			if a.model.SetBackground(background) {
+				needsRender = true
+			}
+
+		// a write failed or works again, shown in the footer
+		case err := <-a.saveErrors():
+			if err != nil {
+				a.model.SetMessage(err.Error()+", retrying", time.Now(), a.model.Tuning.WarningTime)
+			} else {
+				a.model.SetMessage("saved", time.Now(), a.model.Tuning.MessageTime)
+			}
+			needsRender = true
+
+		// another gotype window saved, its best scores are merged in
+		case disk := <-a.diskStates():
+			a.mergeState(disk)
+
 		// update the timer for application 
 		case <-ticker.C:
 			now := time.Now()
 			if a.model.Update(now) {
 				needsRender = true
 			}
+			if a.watchConfig(now) {
+				needsRender = true
+			}
+			if tick != a.model.Tuning.Tick {
+				tick = a.model.Tuning.Tick
+				ticker.Reset(tick)
+			}
 			a.syncPersistence(now)
 		}
 	}
@@ -169,25 +428,53 @@ func (a *App) loop() error {
 // saving new preferences and best scores to disk when they change,
 // and calculating the final results
 func (a *App) syncPersistence(now time.Time) {
+	// the user picked another profile in the top bar
+	if name := a.model.TakeProfileSwitch(); name != "" {
+		a.switchProfile(name, now)
+		return
+	}
+	if file, ok := a.model.TakeThemeFile(); ok {
+		a.saveTheme(file, now)
+	}
+	// preferences another window saved during the last test
+	a.applyDiskPreferences()
 	// if there is a store, check if the preferences have changed and save them
 	// make sure to only save when there is a change to avoid 
 	// unnecessary writes to disk
 	if a.store != nil {
+		for _, id := range a.model.TakeDeletes() {
+			a.store.DeleteRun(id)
+		}
+		if presets, ok := a.model.TakePresets(); ok {
+			a.data.Presets = presets
+			a.data.PreferencesAt = now.UnixMilli()
+			a.store.Save(a.data)
+		}
 		current := preferencesFromModel(a.model)
 		if current != a.prefs {
 			a.prefs = current
 			a.data.Preferences = current
+			a.data.PreferencesAt = now.UnixMilli()
 			a.store.Save(a.data)
 		}
 	}
 	// when the timer finishes for the first time,
 	// calculate the final results and update the best score if needed
 	if a.model.Timer.Finished && !a.finished {
-		key := scoreKey(a.model.Options)
+		key := a.model.ScoreKey()
 		previous, ok := a.data.BestScores[key]
 		a.model.FinalizeResults(previous, ok)
 		a.model.InitReviewStart()
-		if updateBestScore(&a.data, a.model.Options, a.model.Stats, now) && a.store != nil {
+		// replays only show the results, they are already in the history,
+		// and a text of the user's can't be replayed or compared
+		if a.model.Replaying() || a.model.Generator.CustomText() {
+			a.finished = true
+			return
+		}
+		if a.store != nil {
+			a.store.AppendRun(runFromModel(a.model, now))
+		}
+		if updateBestScore(&a.data, key, a.model.Options, a.model.Stats, now) && a.store != nil {
 			a.store.Save(a.data)
 		}
 	}
@@ -195,3 +482,60 @@ func (a *App) syncPersistence(now time.Time) {
 	// and updating the best score
 	a.finished = a.model.Timer.Finished
 }
+
+// mergeState takes in a state another gotype window saved and the persister
+// found: its best scores are merged in so "new best" stays right, its runs
+// are already in the history of the backend. Preferences and presets saved
+// after ours replace them, like Merge does on disk.
+func (a *App) mergeState(disk storage.Data) {
+	newer := disk.PreferencesAt > a.data.PreferencesAt
+	a.data = storage.Merge(disk, a.data)
+	if newer {
+		a.prefsBehind = true
+		a.applyDiskPreferences()
+	}
+}
+
+// apply the preferences and presets another window saved, a started test
+// keeps its options and they are applied before the next one
+func (a *App) applyDiskPreferences() {
+	if !a.prefsBehind || a.model.Timer.Started {
+		return
+	}
+	a.prefsBehind = false
+	if applyPreferences(a.model, a.data.Preferences) {
+		a.model.Reset()
+		a.model.Layout.Recalculate(a.model.Layout.Width, a.model.Layout.Height,
+			a.model.Options.Mode, a.model.focusActive())
+	}
+	a.prefs = preferencesFromModel(a.model)
+	a.data.Preferences = a.prefs
+	a.model.Presets = a.data.Presets
+}
+
+// the backgrounds the terminal reports, nil without a terminal so the loop
+// never receives from it
+func (a *App) backgrounds() <-chan Background {
+	if a.terminal == nil {
+		return nil
+	}
+	return a.terminal.backgrounds
+}
+
+// the states the persister found on disk, nil when there is no persister
+// so the loop never receives from it
+func (a *App) diskStates() <-chan storage.Data {
+	if a.store == nil {
+		return nil
+	}
+	return a.store.States()
+}
+
+// write failures from the persister, nil when there is no persister so
+// the loop never receives from it
+func (a *App) saveErrors() <-chan error {
+	if a.store == nil {
+		return nil
+	}
+	return a.store.Errors()
+}
@@ -0,0 +1,252 @@
+package app
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/themes"
+)
+
+// Background is what the terminal says about its background colour
+type Background int
+
+const (
+	BackgroundUnknown Background = iota
+	BackgroundDark
+	BackgroundLight
+)
+
+// how long the start waits for the terminal to answer, a terminal that
+// doesn't know the questions answers the last one right away
+const backgroundWait = 200 * time.Millisecond
+
+// the theme drawn for the auto theme, dark until the terminal says
+// otherwise
+func (t Tuning) autoThemeID(background Background) string {
+	if background == BackgroundLight {
+		return t.LightTheme
+	}
+	return t.DarkTheme
+}
+
+// light or dark by the colour, whichever of black and white text stands
+// out more on it
+func backgroundOf(color themes.RGB) Background {
+	black, white := themes.RGB{}, themes.RGB{R: 255, G: 255, B: 255}
+	if themes.Contrast(color, black) > themes.Contrast(color, white) {
+		return BackgroundLight
+	}
+	return BackgroundDark
+}
+
+// COLORFGBG is set by rxvt, konsole and a few others as "fg;bg" with the
+// colours of the 16 colour palette, the last field is the background
+func backgroundFromColorFGBG(value string) Background {
+	fields := strings.Split(value, ";")
+	index, err := strconv.Atoi(fields[len(fields)-1])
+	switch {
+	case err != nil || index < 0 || index > 15:
+		return BackgroundUnknown
+	case index == 7 || index >= 9:
+		return BackgroundLight
+	}
+	return BackgroundDark
+}
+
+// the colour of an OSC 11 answer, rgb:RRRR/GGGG/BBBB with one to four hex
+// digits per channel
+func parseBackgroundColor(text string) (themes.RGB, bool) {
+	_, channels, ok := strings.Cut(text, ":")
+	if !ok {
+		return themes.RGB{}, false
+	}
+	parts := strings.Split(channels, "/")
+	if len(parts) < 3 {
+		return themes.RGB{}, false
+	}
+	var rgb [3]uint8
+	for i := range rgb {
+		part := parts[i]
+		value, err := strconv.ParseUint(part, 16, 16)
+		if err != nil || len(part) == 0 || len(part) > 4 {
+			return themes.RGB{}, false
+		}
+		top := uint64(1)<<(4*len(part)) - 1
+		rgb[i] = uint8(value * 255 / top)
+	}
+	return themes.RGB{R: rgb[0], G: rgb[1], B: rgb[2]}, true
+}
+
+// the questions for the terminal: the background colour (OSC 11), reports
+// of its changes (mode 2031, contour, ghostty, kitty) and the device
+// attributes, which every terminal answers, so the answers end
+const (
+	queryBackground    = "\x1b]11;?\x1b\\"
+	reportBackground   = "\x1b[?2031h"
+	unreportBackground = "\x1b[?2031l"
+	queryAttributes    = "\x1b[c"
+)
+
+// the starts of the answers, one cut after these is held until the rest
+// comes. An OSC 11 answer is ended by ST or BEL.
+var (
+	backgroundAnswer = []byte("\x1b]11;")
+	privateAnswer    = []byte("\x1b[?")
+)
+
+// the longest answer held back, anything longer is no answer of ours
+const maxAnswer = 64
+
+// terminalTty is the tty of the screen with the answers about the
+// background taken out of the input, tcell would drop them. The latest
+// background is in backgrounds and answered is closed once the terminal
+// answered the device attributes.
+type terminalTty struct {
+	tcell.Tty
+	// the filtered input not read yet and the start of an answer cut in two
+	ready   []byte
+	pending []byte
+
+	backgrounds chan Background
+	answered    chan struct{}
+	answerOnce  sync.Once
+}
+
+func newTerminalTty(tty tcell.Tty) *terminalTty {
+	return &terminalTty{
+		Tty:         tty,
+		backgrounds: make(chan Background, 1),
+		answered:    make(chan struct{}),
+	}
+}
+
+func (t *terminalTty) Read(b []byte) (int, error) {
+	if len(t.ready) == 0 {
+		buf := make([]byte, len(b))
+		n, err := t.Tty.Read(buf)
+		t.ready = t.filter(append(t.pending, buf[:n]...))
+		if err != nil {
+			return copy(b, t.ready), err
+		}
+	}
+	n := copy(b, t.ready)
+	t.ready = t.ready[n:]
+	return n, nil
+}
+
+// filter takes the answers out of data, the start of one at the end is
+// kept in pending
+func (t *terminalTty) filter(data []byte) []byte {
+	t.pending = nil
+	var out []byte
+	for len(data) > 0 {
+		i := bytes.IndexByte(data, '\x1b')
+		if i < 0 {
+			return append(out, data...)
+		}
+		out = append(out, data[:i]...)
+		data = data[i:]
+		n, complete := t.answer(data)
+		if !complete && len(data) < maxAnswer {
+			t.pending = bytes.Clone(data)
+			return out
+		}
+		if n == 0 {
+			out = append(out, data[0])
+			n = 1
+		}
+		data = data[n:]
+	}
+	return out
+}
+
+// answer reads the answer at the start of data and returns its length, 0
+// when it is something else. complete is false while data could still
+// become one.
+func (t *terminalTty) answer(data []byte) (int, bool) {
+	switch {
+	case bytes.HasPrefix(data, backgroundAnswer):
+		body := data[len(backgroundAnswer):]
+		end, size := bytes.IndexByte(body, '\a'), 1
+		if st := bytes.Index(body, []byte("\x1b\\")); st >= 0 && (end < 0 || st < end) {
+			end, size = st, 2
+		}
+		if end < 0 {
+			return 0, false
+		}
+		if color, ok := parseBackgroundColor(string(body[:end])); ok {
+			t.report(backgroundOf(color))
+		}
+		return len(backgroundAnswer) + end + size, true
+	case bytes.HasPrefix(data, privateAnswer):
+		body := data[len(privateAnswer):]
+		end := bytes.IndexFunc(body, func(r rune) bool { return (r < '0' || r > '9') && r != ';' })
+		if end < 0 {
+			return 0, false
+		}
+		params := string(body[:end])
+		switch body[end] {
+		case 'n':
+			// 997;1 is dark and 997;2 light
+			switch params {
+			case "997;1":
+				t.report(BackgroundDark)
+			case "997;2":
+				t.report(BackgroundLight)
+			default:
+				return 0, true
+			}
+		case 'c':
+			t.answerOnce.Do(func() { close(t.answered) })
+		default:
+			return 0, true
+		}
+		return len(privateAnswer) + end + 1, true
+	}
+	// the keys start with ESC too, a lone one can't wait for more
+	complete := len(data) < 3 ||
+		!bytes.HasPrefix(backgroundAnswer, data) && !bytes.HasPrefix(privateAnswer, data)
+	return 0, complete
+}
+
+// report keeps only the latest background for the app
+func (t *terminalTty) report(background Background) {
+	select {
+	case <-t.backgrounds:
+	default:
+	}
+	t.backgrounds <- background
+}
+
+// start asks for the background and its changes and waits a moment for
+// the answer
+func (t *terminalTty) start() (Background, bool) {
+	_, _ = t.Write([]byte(queryBackground + reportBackground + queryAttributes))
+	select {
+	case background := <-t.backgrounds:
+		return background, true
+	case <-t.answered:
+	case <-time.After(backgroundWait):
+	}
+	// both answers can come in one read
+	select {
+	case background := <-t.backgrounds:
+		return background, true
+	default:
+	}
+	return BackgroundUnknown, false
+}
+
+// query asks for the background again, the answer comes in backgrounds
+func (t *terminalTty) query() {
+	_, _ = t.Write([]byte(queryBackground))
+}
+
+// stop turns the change reports off again
+func (t *terminalTty) stop() {
+	_, _ = t.Write([]byte(unreportBackground))
+}
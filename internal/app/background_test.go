@@ -0,0 +1,97 @@
+package app
+
+import (
+	"io"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/themes"
+)
+
+// a tty that reads the chunks one by one, a chunk never shares a read
+type chunkTty struct {
+	tcell.Tty
+	chunks []string
+}
+
+func (t *chunkTty) Read(b []byte) (int, error) {
+	if len(t.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, t.chunks[0])
+	if t.chunks[0] = t.chunks[0][n:]; t.chunks[0] == "" {
+		t.chunks = t.chunks[1:]
+	}
+	return n, nil
+}
+
+func TestTerminalTtyTakesOutTheAnswers(t *testing.T) {
+	tty := newTerminalTty(&chunkTty{chunks: []string{
+		"ab\x1b]11;rgb:ffff/ffff/f0f0\x1b\\c",
+		// an answer cut in two and the keys around it
+		"\x1b[A\x1b]11;rgb:00",
+		"/00/00\ad\x1b",
+		"\x1b[?62;22c\x1b[?997;2n",
+	}})
+	var input []byte
+	buf := make([]byte, 64)
+	for {
+		n, err := tty.Read(buf)
+		input = append(input, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	if got, want := string(input), "abc\x1b[Ad\x1b"; got != want {
+		t.Fatalf("input = %q, want %q", got, want)
+	}
+	// only the latest background is kept
+	if background := <-tty.backgrounds; background != BackgroundLight {
+		t.Fatalf("background = %v, want light from the change report", background)
+	}
+	select {
+	case <-tty.answered:
+	default:
+		t.Fatal("the device attributes were answered")
+	}
+}
+
+func TestAutoTheme(t *testing.T) {
+	for value, want := range map[string]Background{
+		"15;0":        BackgroundDark,
+		"0;15":        BackgroundLight,
+		"0;default;7": BackgroundLight,
+		"7;8":         BackgroundDark,
+		"":            BackgroundUnknown,
+		"15;default":  BackgroundUnknown,
+	} {
+		if got := backgroundFromColorFGBG(value); got != want {
+			t.Errorf("COLORFGBG %q = %v, want %v", value, got, want)
+		}
+	}
+	for text, want := range map[string]themes.RGB{
+		"rgb:ffff/8080/0000":       {R: 255, G: 128, B: 0},
+		"rgb:f/8/0":                {R: 255, G: 136, B: 0},
+		"rgba:1919/1717/2424/ffff": {R: 25, G: 23, B: 36},
+	} {
+		if got, ok := parseBackgroundColor(text); !ok || got != want {
+			t.Errorf("%q = %v %v, want %v", text, got, ok, want)
+		}
+	}
+	if _, ok := parseBackgroundColor("rgb:zz/00/00"); ok {
+		t.Error("a broken colour was read")
+	}
+
+	m := NewModel()
+	m.SetTheme(themes.AutoID)
+	if got := m.themeInUse(); got != m.Tuning.DarkTheme {
+		t.Fatalf("unknown background draws %q, want the dark theme", got)
+	}
+	if !m.SetBackground(backgroundOf(themes.RGB{R: 0xf5, G: 0xf5, B: 0xf5})) || m.themeInUse() != m.Tuning.LightTheme {
+		t.Fatalf("light background draws %q, want the light theme", m.themeInUse())
+	}
+	m.SetTheme("forest")
+	if got := m.themeInUse(); got != "forest" {
+		t.Fatalf("a picked theme draws %q", got)
+	}
+}
@@ -0,0 +1,275 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/config"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// exit codes of gotype, scripts can rely on them
+const (
+	ExitOK = 0
+	// the command failed, the error is on stderr
+	ExitError = 1
+	// unknown command, bad flag or bad flag value
+	ExitUsage = 2
+)
+
+// Version is set at build time with
+// -ldflags "-X github.com/yossefsabry/gotype/internal/app.Version=v1.2.3"
+var Version = ""
+
+// a bad command line, exits with ExitUsage. shown is set when the flag
+// package printed it already.
+type usageError struct {
+	err   error
+	shown bool
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// parse the flags of a command, -h is not an error and the rest are usage
+// errors
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return usageError{err: err, shown: true}
+}
+
+// the commands, in the order of the help text
+var commands = []struct {
+	name, usage string
+}{
+	{"stats", "print the progress report"},
+	{"history", "list the latest runs"},
+	{"export", "write the run history as jsonl, json or csv"},
+	{"import", "add results from another typing test"},
+	{"presets", "list the presets with their best scores, \"presets delete NAME\" removes one"},
+	{"themes", "list the themes, \"themes import FILE\" converts a monkeytype or base16 scheme"},
+	{"config", "print the config in use, \"config path\" prints the file"},
+	{"doctor", "check and repair the saved files"},
+	{"storage", "copy the data to another storage backend"},
+	{"version", "print the version"},
+	{"help", "print this help"},
+}
+
+// flags of the test itself, only used without a command
+type testFlags struct {
+	preset            string
+	mode, theme, text string
+	duration          time.Duration
+	words             int
+	punctuation       *bool
+	numbers           *bool
+	seed              *int64
+}
+
+func (t *testFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&t.preset, "preset", "", "start with a saved preset, the other test flags change it")
+	flags.StringVar(&t.mode, "mode", "", "test mode: time or words")
+	flags.DurationVar(&t.duration, "duration", 0, "length of a time test, like 30s or 2m")
+	flags.IntVar(&t.words, "words", 0, "number of words of a words test")
+	flags.StringVar(&t.theme, "theme", "", "theme id, see gotype themes")
+	flags.BoolFunc("punctuation", "add punctuation (--punctuation=false to leave it out)", boolFlag(&t.punctuation))
+	flags.BoolFunc("numbers", "add numbers (--numbers=false to leave them out)", boolFlag(&t.numbers))
+	flags.Func("seed", "seed of the first test, the same seed gives the same text", func(value string) error {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid seed %q", value)
+		}
+		t.seed = &seed
+		return nil
+	})
+	flags.StringVar(&t.text, "text", "", "type the words of this file, these runs are not saved")
+}
+
+func boolFlag(target **bool) func(string) error {
+	return func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		*target = &b
+		return nil
+	}
+}
+
+// given reports if any test flag was used
+func (t *testFlags) given() bool {
+	return t.preset != "" || t.mode != "" || t.duration != 0 || t.words != 0 || t.theme != "" || t.text != "" ||
+		t.punctuation != nil || t.numbers != nil || t.seed != nil
+}
+
+// check the test flags and turn them into the options of Run
+func (t *testFlags) apply(cfg *RunConfig) error {
+	switch t.mode {
+	case "":
+	case "time", "words":
+		mode := modeFromString(t.mode)
+		cfg.Mode = &mode
+	default:
+		return usageError{err: fmt.Errorf("invalid --mode %q (want time or words)", t.mode)}
+	}
+	if t.duration < 0 || t.duration%time.Second != 0 {
+		return usageError{err: fmt.Errorf("invalid --duration %s (want whole seconds)", t.duration)}
+	}
+	// the same limits as a custom length picked in the top bar
+	prefs := storage.Preferences{DurationSeconds: int(t.duration / time.Second), WordCount: t.words}
+	if err := prefs.Validate(); err != nil {
+		return usageError{err: fmt.Errorf("invalid test length: %w", err)}
+	}
+	if t.theme != "" && ThemeByID(t.theme).ID != t.theme {
+		return usageError{err: fmt.Errorf("unknown theme %q, see gotype themes", t.theme)}
+	}
+	if t.preset != "" {
+		if err := storage.ValidPresetName(t.preset); err != nil {
+			return usageError{err: err}
+		}
+	}
+	cfg.Preset = t.preset
+	cfg.Duration, cfg.WordCount, cfg.ThemeID = t.duration, t.words, t.theme
+	cfg.Punctuation, cfg.Numbers, cfg.Seed = t.punctuation, t.numbers, t.seed
+	if t.text != "" {
+		raw, err := os.ReadFile(t.text)
+		if err != nil {
+			return err
+		}
+		cfg.Text = strings.Fields(string(raw))
+		if len(cfg.Text) == 0 {
+			return fmt.Errorf("%s has no words to type", t.text)
+		}
+	}
+	return nil
+}
+
+// Main runs gotype with the command line args (without the program name)
+// and returns the exit code
+func Main(args []string, stdout, stderr io.Writer) int {
+	loader := config.NewLoader()
+	var test testFlags
+	flags := flag.NewFlagSet("gotype", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { printUsage(stderr, loader) }
+	test.register(flags)
+	loader.RegisterFlags(flags)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		// a bad flag value is a bad command line, a broken file is not
+		if invalid := (*config.InvalidError)(nil); errors.As(err, &invalid) {
+			return ExitUsage
+		}
+		return ExitError
+	}
+	// broken theme files are left out, the app and `gotype themes` say why
+	themesDir := loader.ThemesDir()
+	themesErr := LoadThemes(themesDir)
+
+	rest := flags.Args()
+	command := ""
+	if len(rest) > 0 {
+		command, rest = rest[0], rest[1:]
+	}
+	if command != "" && test.given() {
+		fmt.Fprintf(stderr, "the test flags only apply without a command, not with %q\n", command)
+		return ExitUsage
+	}
+	switch command {
+	case "":
+		run := RunConfig{Config: cfg, Loader: loader, ThemesDir: themesDir, ThemesErr: themesErr}
+		if err = test.apply(&run); err == nil {
+			err = Run(run)
+		}
+	case "stats":
+		err = PrintStats(stdout, stderr, cfg, rest)
+	case "history":
+		err = History(stdout, stderr, cfg, rest)
+	case "export":
+		err = Export(stdout, stderr, cfg, rest)
+	case "import":
+		err = Import(stdout, cfg, rest)
+	case "presets":
+		err = Presets(stdout, stderr, cfg, rest)
+	case "themes":
+		err = Themes(stdout, stderr, cfg, themesDir, themesErr, rest)
+	case "config":
+		err = printConfig(stdout, stderr, rest, loader, cfg)
+	case "doctor":
+		err = Doctor(stdout, stderr, cfg, rest)
+	case "storage":
+		err = Storage(stdout, stderr, cfg, rest)
+	case "version":
+		fmt.Fprintln(stdout, "gotype", version())
+	case "help":
+		printUsage(stdout, loader)
+	default:
+		err = usageError{err: fmt.Errorf("unknown command %q, see gotype help", command)}
+	}
+	var usage usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.As(err, &usage):
+		if !usage.shown {
+			fmt.Fprintln(stderr, err)
+		}
+		return ExitUsage
+	}
+	fmt.Fprintln(stderr, err)
+	return ExitError
+}
+
+// the version from the build flags, or from the module when installed with
+// go install
+func version() string {
+	if Version != "" {
+		return Version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		return info.Main.Version
+	}
+	return "dev"
+}
+
+func printUsage(w io.Writer, loader *config.Loader) {
+	fmt.Fprint(w, `usage: gotype [flags] [command] [command flags]
+
+Without a command gotype starts a typing test.
+
+Commands:
+`)
+	for _, command := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", command.name, command.usage)
+	}
+	fmt.Fprintln(w, "\nTest flags, for this session only:")
+	test := flag.NewFlagSet("test", flag.ContinueOnError)
+	test.SetOutput(w)
+	(&testFlags{}).register(test)
+	test.PrintDefaults()
+
+	fmt.Fprintln(w, "\nConfig flags, they override the config file and GOTYPE_<NAME>:")
+	settings := flag.NewFlagSet("config", flag.ContinueOnError)
+	settings.SetOutput(w)
+	(&config.Loader{Path: loader.Path}).RegisterFlags(settings)
+	settings.PrintDefaults()
+
+	fmt.Fprintf(w, "\nExit codes: %d success, %d error, %d bad command line.\n", ExitOK, ExitError, ExitUsage)
+}
@@ -0,0 +1,33 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yossefsabry/gotype/internal/config"
+)
+
+// printConfig writes the config in use for `gotype config`, with the file,
+// the environment and the flags applied, or the path of the file for
+// `gotype config path`
+func printConfig(w, stderr io.Writer, args []string, loader *config.Loader, cfg config.Config) error {
+	flags := flag.NewFlagSet("config", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	switch flags.Arg(0) {
+	case "path":
+		fmt.Fprintln(w, loader.Path)
+		return nil
+	case "":
+	default:
+		return usageError{err: fmt.Errorf("unknown config command %q (want path)", flags.Arg(0))}
+	}
+	fmt.Fprintf(w, "# %s\n", loader.Path)
+	encoder := toml.NewEncoder(w)
+	encoder.Indent = ""
+	return encoder.Encode(cfg)
+}
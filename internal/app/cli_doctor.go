@@ -0,0 +1,129 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/config"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// Doctor checks the state file and the history for `gotype doctor`, with
+// --repair a corrupted state file is quarantined and rebuilt from what
+// still parses and the newest backup. Other backends are only opened and
+// counted, they have no files of their own to repair.
+func Doctor(w, stderr io.Writer, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	repair := flags.Bool("repair", false, "repair a corrupted state file")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	dir, err := storage.ProfileDir(cfg.Profile)
+	if err != nil {
+		return err
+	}
+	path := storage.StatePath(dir)
+	fmt.Fprintf(w, "profile:  %s\n", cfg.Profile)
+	if name := cfg.Backend; name != "json" {
+		return doctorBackend(w, name, dir)
+	}
+	fmt.Fprintf(w, "backend:  json\n")
+	fmt.Fprintf(w, "state:    %s\n", path)
+
+	problem, corrupt := false, false
+	data, err := storage.Load(path)
+	switch {
+	case err == nil:
+		if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+			fmt.Fprintln(w, "status:   missing, a new one is created on the next start")
+		} else {
+			fmt.Fprintf(w, "status:   ok, version %d, %d best scores\n", data.Version, len(data.BestScores))
+		}
+	case errors.Is(err, storage.ErrCorrupt) && *repair:
+		_, recovery, err := storage.LoadOrRecover(path, time.Now())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "status:   repaired, %s\n", recovery.Summary())
+		for _, lost := range recovery.Lost {
+			fmt.Fprintf(w, "          lost %s\n", lost)
+		}
+	case errors.Is(err, storage.ErrCorrupt):
+		fmt.Fprintf(w, "status:   %v\n", err)
+		problem, corrupt = true, true
+	default:
+		// newer version or not readable, nothing doctor can fix
+		fmt.Fprintf(w, "status:   %v\n", err)
+		problem = true
+	}
+
+	backups, err := storage.Backups(path)
+	if err != nil {
+		return err
+	}
+	if len(backups) == 0 {
+		fmt.Fprintln(w, "backups:  none")
+	} else {
+		fmt.Fprintf(w, "backups:  %d in %s, newest %s\n", len(backups),
+			storage.BackupDir(path), filepath.Base(backups[0]))
+	}
+	for _, name := range storage.Quarantined(path) {
+		fmt.Fprintf(w, "broken:   %s\n", name)
+	}
+
+	historyPath := storage.HistoryPath(path)
+	if _, err := os.Stat(historyPath); err == nil {
+		// opening the history drops a half written last line and rebuilds
+		// a stale index, closing it writes the index back
+		history, err := storage.OpenHistory(historyPath)
+		if err != nil {
+			fmt.Fprintf(w, "history:  %v\n", err)
+			problem = true
+		} else {
+			fmt.Fprintf(w, "history:  ok, %d runs\n", history.Len())
+			if err := history.Close(); err != nil {
+				return err
+			}
+		}
+	} else {
+		fmt.Fprintln(w, "history:  none")
+	}
+
+	if problem {
+		if corrupt {
+			return errors.New("state file is corrupted, run `gotype doctor --repair` to fix it")
+		}
+		return errors.New("problems found")
+	}
+	return nil
+}
+
+// check a backend by loading everything from it
+func doctorBackend(w io.Writer, name, dir string) error {
+	fmt.Fprintf(w, "backend:  %s in %s\n", name, dir)
+	backend, err := storage.Open(name, dir)
+	if err != nil {
+		fmt.Fprintf(w, "status:   %v\n", err)
+		return errors.New("problems found")
+	}
+	defer backend.Close()
+	data, err := backend.Load()
+	if err != nil {
+		fmt.Fprintf(w, "status:   %v\n", err)
+		return errors.New("problems found")
+	}
+	fmt.Fprintf(w, "status:   ok, version %d, %d best scores\n", data.Version, len(data.BestScores))
+	runs := 0
+	if err := backend.Each(nil, func(storage.Run) error { runs++; return nil }); err != nil {
+		fmt.Fprintf(w, "history:  %v\n", err)
+		return errors.New("problems found")
+	}
+	fmt.Fprintf(w, "history:  ok, %d runs\n", runs)
+	return nil
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/yossefsabry/gotype/internal/config"
+	"github.com/yossefsabry/gotype/internal/export"
+)
+
+// Export writes the run history for `gotype export`, to stdout unless an
+// output file is given
+func Export(w, stderr io.Writer, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "jsonl", "output format: "+strings.Join(export.Formats, ", "))
+	since := flags.String("since", "", "only runs started on or after this date (YYYY-MM-DD or RFC 3339)")
+	key := flags.String("key", "", "only runs with this score key")
+	output := flags.String("output", "", "write to this file instead of stdout")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if !slices.Contains(export.Formats, *format) {
+		return usageError{err: fmt.Errorf("unknown export format %q (want %s)", *format, strings.Join(export.Formats, ", "))}
+	}
+	filter := export.Filter{Key: *key}
+	if *since != "" {
+		t, err := export.ParseSince(*since)
+		if err != nil {
+			return usageError{err: err}
+		}
+		filter.Since = t
+	}
+	stored, err := openCommandStorage(cfg)
+	if err != nil {
+		return err
+	}
+	history := stored.backend
+	defer history.Close()
+
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		writer := bufio.NewWriter(file)
+		if err := export.Write(writer, *format, history, filter); err != nil {
+			file.Close()
+			return err
+		}
+		if err := writer.Flush(); err != nil {
+			file.Close()
+			return err
+		}
+		return file.Close()
+	}
+	writer := bufio.NewWriter(w)
+	if err := export.Write(writer, *format, history, filter); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,59 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/yossefsabry/gotype/internal/config"
+)
+
+// History lists the latest runs for `gotype history`, newest first, with
+// the same columns as the history screen
+func History(w, stderr io.Writer, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("history", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	limit := flags.Int("limit", 20, "number of runs to list, 0 for all")
+	mode := flags.String("mode", "", "only runs of this mode: time or words")
+	key := flags.String("key", "", "only runs with this score key")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *limit < 0 {
+		return usageError{err: fmt.Errorf("invalid --limit %d", *limit)}
+	}
+	stored, err := openCommandStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer stored.backend.Close()
+
+	unit := unitFromString(stored.data.Preferences.Unit)
+	rateLabel, rawLabel := rateLabels(FormulaStandard, unit)
+	runs := stored.backend.Runs()
+	listed := 0
+	for i := len(runs) - 1; i >= 0; i-- {
+		run := runs[i]
+		if (*mode != "" && run.Options.Mode != *mode) || (*key != "" && run.Key != *key) {
+			continue
+		}
+		if listed == 0 {
+			fmt.Fprintf(w, "%-16s  %-5s  %6s  %4s  %4s  %4s  %4s  %s\n",
+				"date", "mode", "amount", rateLabel, rawLabel, "acc", "cons", "formula")
+		}
+		metrics := run.Metrics
+		fmt.Fprintf(w, "%-16s  %-5s  %6s  %4d  %4d  %3d%%  %4d  %s\n",
+			formatRunTime(run.StartedAt), run.Options.Mode, runAmountLabel(run),
+			unitValue(unit, metrics.WPM, metrics.CPM),
+			unitValue(unit, metrics.RawWPM, metrics.RawCPM),
+			metrics.Accuracy, metrics.Consistency, run.Options.Formula)
+		listed++
+		if listed == *limit {
+			break
+		}
+	}
+	if listed == 0 {
+		fmt.Fprintln(w, "no runs yet, finish a test to see it here")
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/config"
+	"github.com/yossefsabry/gotype/internal/importer"
+)
+
+// Import adds results from another typing test to the history for
+// `gotype import <source> <file>`, runs already in the history are skipped
+// and best scores are raised when an imported run beats them
+func Import(w io.Writer, cfg config.Config, args []string) error {
+	if len(args) != 2 {
+		return usageError{err: errors.New("usage: gotype import monkeytype <results.csv>")}
+	}
+	if args[0] != "monkeytype" {
+		return usageError{err: fmt.Errorf("unknown import source %q (supported: monkeytype)", args[0])}
+	}
+	file, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	result, err := importer.ParseMonkeytype(file)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	stored, err := openCommandStorage(cfg)
+	if err != nil {
+		return err
+	}
+	backend, data := stored.backend, stored.data
+	imported, duplicates, best := 0, 0, 0
+	for _, run := range result.Runs {
+		if backend.Has(run.ID) {
+			duplicates++
+			continue
+		}
+		options := optionsFromRun(run.Options)
+		run.Key = scoreKey(options)
+		if _, err := backend.Append(run); err != nil {
+			backend.Close()
+			return err
+		}
+		imported++
+		stats := Stats{WPM: run.Metrics.WPM, CPM: run.Metrics.CPM, Accuracy: run.Metrics.Accuracy}
+		if updateBestScore(&data, run.Key, options, stats, time.UnixMilli(run.EndedAt)) {
+			best++
+		}
+	}
+	if best > 0 {
+		if _, err := backend.Save(data); err != nil {
+			backend.Close()
+			return err
+		}
+	}
+	if err := backend.Close(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "imported %d runs, skipped %d duplicates, %d new best scores\n", imported, duplicates, best)
+	if len(result.Unsupported) > 0 {
+		fmt.Fprintf(w, "not imported (unsupported): %s\n", strings.Join(result.UnsupportedList(), ", "))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/config"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// Presets lists the presets of the profile with their best scores for
+// `gotype presets`, `gotype presets delete NAME` removes one
+func Presets(w, stderr io.Writer, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("presets", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	stored, err := openCommandStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer stored.backend.Close()
+	data := stored.data
+
+	switch flags.Arg(0) {
+	case "":
+	case "delete":
+		name := flags.Arg(1)
+		index := slices.IndexFunc(data.Presets, func(p storage.Preset) bool { return p.Name == name })
+		if index < 0 {
+			return fmt.Errorf("no preset %q", name)
+		}
+		data.Presets = slices.Delete(slices.Clone(data.Presets), index, index+1)
+		data.PreferencesAt = time.Now().UnixMilli()
+		if _, err := stored.backend.Save(data); err != nil {
+			return err
+		}
+		// the best scores stay, saving the preset again brings them back
+		fmt.Fprintf(w, "deleted preset %s\n", name)
+		return nil
+	default:
+		return usageError{err: fmt.Errorf("unknown presets command %q (want delete)", flags.Arg(0))}
+	}
+
+	if len(data.Presets) == 0 {
+		fmt.Fprintln(w, "no presets yet, save one from the presets menu")
+		return nil
+	}
+	unit := unitFromString(data.Preferences.Unit)
+	rateLabel, _ := rateLabels(FormulaStandard, unit)
+	for _, preset := range data.Presets {
+		best := "no runs yet"
+		key := presetScoreKey(preset.Name, optionsFromRun(preset.Options))
+		if score, ok := data.BestScores[key]; ok {
+			best = fmt.Sprintf("best %d %s, %d%% acc", unitValue(unit, score.WPM, score.CPM), rateLabel, score.Accuracy)
+		}
+		fmt.Fprintf(w, "%-16s %-40s %s\n", preset.Name, presetSummary(preset), best)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/yossefsabry/gotype/internal/analytics"
+	"github.com/yossefsabry/gotype/internal/config"
+)
+
+// PrintStats writes the progress report for `gotype stats`, the formula
+// defaults to the one saved in the preferences
+func PrintStats(w, stderr io.Writer, cfg config.Config, args []string) error {
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	formula := flags.String("formula", "", "formula to report on: standard, monkeytype, plain or all")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	stored, err := openCommandStorage(cfg)
+	if err != nil {
+		return err
+	}
+	defer stored.backend.Close()
+	name := *formula
+	switch name {
+	case "":
+		name = formulaToString(formulaFromString(stored.data.Preferences.Formula))
+	case "all":
+		name = ""
+	default:
+		if formulaToString(formulaFromString(name)) != name {
+			return usageError{err: fmt.Errorf("unknown formula %q", name)}
+		}
+	}
+	dashboard := analytics.NewDashboard(stored.backend, analytics.CachePath(stored.dir))
+	return analytics.WriteText(w, dashboard.Report(name))
+}
@@ -0,0 +1,65 @@
+package app
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/yossefsabry/gotype/internal/config"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// Storage handles `gotype storage migrate`, copying the preferences, best
+// scores and every run from one backend to another. The copy is merged
+// into the target and runs it already has are skipped, so it is safe to
+// run twice.
+func Storage(w, stderr io.Writer, cfg config.Config, args []string) error {
+	if len(args) == 0 || args[0] != "migrate" {
+		return usageError{err: errors.New("usage: gotype storage migrate --to <backend> [--from <backend>]")}
+	}
+	flags := flag.NewFlagSet("storage migrate", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	names := strings.Join(storage.Backends, ", ")
+	from := flags.String("from", cfg.Backend, "backend to copy from: "+names)
+	to := flags.String("to", "", "backend to copy to: "+names)
+	if err := parseFlags(flags, args[1:]); err != nil {
+		return err
+	}
+	for _, name := range []string{*from, *to} {
+		if !slices.Contains(storage.Backends, name) {
+			return usageError{err: fmt.Errorf("unknown storage backend %q (want %s)", name, names)}
+		}
+	}
+	if *from == *to {
+		return usageError{err: errors.New("--from and --to are the same backend")}
+	}
+	dir, err := storage.ProfileDir(cfg.Profile)
+	if err != nil {
+		return err
+	}
+
+	src, err := storage.Open(*from, dir)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := storage.Open(*to, dir)
+	if err != nil {
+		return err
+	}
+	copied, err := storage.Copy(dst, src)
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "copied %d runs from %s to %s (%d runs there now)\n", copied, *from, *to, dst.Len())
+	if *to != cfg.Backend {
+		fmt.Fprintf(w, "set backend = %q in the config file to use it\n", *to)
+	}
+	return nil
+}
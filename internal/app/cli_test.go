@@ -0,0 +1,144 @@
+package app
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/config"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// keep the commands away from the real config directory
+func testConfigDir(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv(config.PathEnv, "")
+	t.Cleanup(func() { LoadThemes("") })
+}
+
+func TestMainExitCodes(t *testing.T) {
+	testConfigDir(t)
+	for _, tc := range []struct {
+		args   []string
+		code   int
+		output string
+	}{
+		{[]string{"version"}, ExitOK, "gotype "},
+		{[]string{"help"}, ExitOK, "Exit codes: 0 success, 1 error, 2 bad command line."},
+		{[]string{"themes"}, ExitOK, "* rose-pine"},
+		{[]string{"history"}, ExitOK, "no runs yet"},
+		{[]string{"--tick", "50ms", "config"}, ExitOK, `tick = "50ms"`},
+		{[]string{"bogus"}, ExitUsage, `unknown command "bogus"`},
+		{[]string{"--bogus"}, ExitUsage, "flag provided but not defined"},
+		{[]string{"--mode", "fast"}, ExitUsage, `invalid --mode "fast"`},
+		{[]string{"--theme", "nope"}, ExitUsage, `unknown theme "nope"`},
+		{[]string{"--words", "5", "stats"}, ExitUsage, "only apply without a command"},
+		{[]string{"export", "--format", "xml"}, ExitUsage, `unknown export format "xml"`},
+		{[]string{"--tick", "1ms", "version"}, ExitUsage, "timing.tick"},
+		{[]string{"--tick", "soon", "version"}, ExitUsage, "flag -tick"},
+		{[]string{"--text", "/does/not/exist"}, ExitError, "no such file"},
+	} {
+		var stdout, stderr bytes.Buffer
+		code := Main(tc.args, &stdout, &stderr)
+		output := stdout.String() + stderr.String()
+		if code != tc.code || !strings.Contains(output, tc.output) {
+			t.Errorf("gotype %v = %d %q, want %d %q", tc.args, code, output, tc.code, tc.output)
+		}
+	}
+}
+
+func TestCommandFlagsToStderr(t *testing.T) {
+	testConfigDir(t)
+	for _, command := range []string{"stats", "history", "export", "presets", "themes", "config", "doctor"} {
+		var stdout, stderr bytes.Buffer
+		if code := Main([]string{command, "--bogus"}, &stdout, &stderr); code != ExitUsage || stdout.Len() > 0 ||
+			!strings.Contains(stderr.String(), "flag provided but not defined") {
+			t.Errorf("gotype %s --bogus = %d, stdout %q, stderr %q", command, code, stdout.String(), stderr.String())
+		}
+	}
+}
+
+func TestRunWithSimulationScreen(t *testing.T) {
+	testConfigDir(t)
+	mode, seed, punctuation := ModeWords, int64(42), false
+	cfg := RunConfig{
+		Config:      config.Default(),
+		Mode:        &mode,
+		WordCount:   3,
+		Punctuation: &punctuation,
+		Seed:        &seed,
+		ThemeID:     "forest",
+		Screen:      tcell.NewSimulationScreen(""),
+	}
+	// the text the seed gives, the same way the model builds it
+	generator := NewGenerator(rand.NewSource(0))
+	generator.Reseed(seed)
+	text := generator.Build(3, Options{Mode: ModeWords, WordCount: 3})
+
+	screen := cfg.Screen.(tcell.SimulationScreen)
+	go func() {
+		for {
+			if width, _ := screen.Size(); width > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		for _, r := range text {
+			screen.InjectKey(tcell.KeyRune, r, tcell.ModNone)
+		}
+		screen.InjectKey(tcell.KeyEsc, 0, tcell.ModNone)
+	}()
+	if err := Run(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, err := openStorage(storage.DefaultProfile, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stored.backend.Close()
+	runs := stored.backend.Runs()
+	if len(runs) != 1 || runs[0].Seed != seed || runs[0].Options.WordCount != 3 {
+		t.Fatalf("runs = %+v", runs)
+	}
+	// the flags were for this session, they are not the saved preferences
+	if prefs := stored.data.Preferences; prefs.Mode == "words" || prefs.ThemeID == "forest" {
+		t.Fatalf("flags saved as preferences: %+v", prefs)
+	}
+}
+
+func TestCommandsLeaveBrokenStateAlone(t *testing.T) {
+	testConfigDir(t)
+	dir, err := storage.ProfileDir(storage.DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := storage.StatePath(dir)
+	broken := []byte(`{"version": 2, "best_scores": {`)
+	if err := os.WriteFile(path, broken, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, command := range []string{"stats", "history", "export"} {
+		var stdout, stderr bytes.Buffer
+		if code := Main([]string{command}, &stdout, &stderr); code != ExitError || !strings.Contains(stderr.String(), "doctor --repair") {
+			t.Errorf("gotype %s = %d %q", command, code, stderr.String())
+		}
+	}
+	// nothing was quarantined or rewritten
+	if raw, err := os.ReadFile(path); err != nil || !bytes.Equal(raw, broken) {
+		t.Fatalf("state = %q, %v", raw, err)
+	}
+	if quarantined := storage.Quarantined(path); len(quarantined) != 0 {
+		t.Fatalf("quarantined = %v", quarantined)
+	}
+}
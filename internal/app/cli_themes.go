@@ -0,0 +1,139 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/yossefsabry/gotype/internal/config"
+	"github.com/yossefsabry/gotype/internal/themes"
+)
+
+// Themes lists the themes for `gotype themes`, the saved one is marked
+// with a star and the broken theme files are listed after them. `gotype
+// themes import FILE` converts colour schemes into theme files.
+func Themes(w, stderr io.Writer, cfg config.Config, dir string, filesErr error, args []string) error {
+	flags := flag.NewFlagSet("themes", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	switch flags.Arg(0) {
+	case "":
+	case "import":
+		return importThemes(w, stderr, dir, flags.Args()[1:])
+	default:
+		return usageError{err: fmt.Errorf("unknown themes command %q (want import)", flags.Arg(0))}
+	}
+	current := DefaultThemeID()
+	// listing the themes works without any saved state
+	if stored, err := openCommandStorage(cfg); err == nil {
+		if id := stored.data.Preferences.ThemeID; id != "" {
+			current = ThemeByID(id).ID
+		}
+		stored.backend.Close()
+	}
+	// the ids line up on the longest one
+	width := 0
+	for _, theme := range ThemeOptions() {
+		width = max(width, len(theme.ID))
+	}
+	for _, theme := range ThemeOptions() {
+		mark := " "
+		if theme.ID == current {
+			mark = "*"
+		}
+		label := theme.Label
+		if theme.User {
+			label += " (user)"
+		}
+		if theme.ID == themes.AutoID {
+			label += fmt.Sprintf(" (%s or %s by the terminal)", cfg.Display.LightTheme, cfg.Display.DarkTheme)
+		}
+		fmt.Fprintf(w, "%s %-*s  %s\n", mark, width, theme.ID, label)
+	}
+	// the broken theme files are skipped
+	if filesErr != nil {
+		for _, line := range strings.Split(filesErr.Error(), "\n") {
+			fmt.Fprintf(w, "! %s\n", line)
+		}
+	}
+	return nil
+}
+
+// importThemes writes the schemes of a monkeytype theme or a base16 or
+// base24 scheme into the themes directory, a theme that is already there
+// is skipped without --force
+func importThemes(w, stderr io.Writer, dir string, args []string) error {
+	flags := flag.NewFlagSet("themes import", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	name := flags.String("name", "", "theme id instead of the scheme name, for one scheme")
+	force := flags.Bool("force", false, "replace themes that already exist")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usageError{err: errors.New("usage: gotype themes import [--name ID] [--force] <theme.css|theme.json|scheme.yaml>")}
+	}
+	if dir == "" {
+		return errors.New("no themes directory without a config path")
+	}
+	path := flags.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	files, err := themes.Import(filepath.Base(path), data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if *name != "" {
+		if len(files) != 1 {
+			return usageError{err: fmt.Errorf("--name needs one scheme, %s has %d", path, len(files))}
+		}
+		if err := themes.ValidID(*name); err != nil {
+			return usageError{err: err}
+		}
+		files[0].ID = *name
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	imported := 0
+	for _, file := range files {
+		target := filepath.Join(dir, file.ID+themes.Ext)
+		if slices.ContainsFunc(builtinThemes, func(t Theme) bool { return t.ID == file.ID }) {
+			fmt.Fprintf(w, "skipped %s: a built-in theme has this id, use --name\n", file.ID)
+			continue
+		}
+		if _, err := os.Stat(target); err == nil && !*force {
+			fmt.Fprintf(w, "skipped %s: %s exists, --force replaces it\n", file.ID, target)
+			continue
+		}
+		if err := writeThemeFile(target, file, "imported from "+filepath.Base(path)); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "imported %s to %s\n", file.ID, target)
+		imported++
+	}
+	if imported == 0 {
+		return errors.New("no theme imported")
+	}
+	return nil
+}
+
+// write a theme file with a comment on where it came from
+func writeThemeFile(target string, file themes.File, comment string) error {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# %s\n", comment)
+	if err := themes.Encode(&b, file); err != nil {
+		return err
+	}
+	return os.WriteFile(target, b.Bytes(), 0o644)
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"os"
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/themes"
+)
+
+// trueColors is what a truecolour terminal reports
+const trueColors = 1 << 24
+
+// the number of colours to draw with, the colours of the config win over
+// what the screen reports. No colours at all is 0, with NO_COLOR set too
+// (https://no-color.org).
+func colorCount(screen tcell.Screen, mode string) int {
+	switch mode {
+	case "none":
+		return 0
+	case "truecolor":
+		return trueColors
+	case "256":
+		return 256
+	case "16":
+		return 16
+	case "8":
+		return 8
+	}
+	if os.Getenv("NO_COLOR") != "" {
+		return 0
+	}
+	return screen.Colors()
+}
+
+// the colours of a theme by their place in Theme.colors
+const (
+	colorBackground = iota
+	colorPanel
+	colorText
+	colorDim
+	colorAccent
+	colorError
+	colorCursorText
+	colorKeyBackground
+	colorKeyText
+	colorKeyActiveBg
+	colorKeyActiveText
+)
+
+// the colours in the order they are picked. on is the colour it is read
+// on, -1 for backgrounds, and apart are the colours it must not become when
+// the theme has them apart
+var colorRoles = []struct {
+	color int
+	on    int
+	apart []int
+}{
+	{colorBackground, -1, nil},
+	{colorText, colorBackground, nil},
+	{colorDim, colorBackground, []int{colorText}},
+	{colorAccent, colorBackground, []int{colorText, colorDim}},
+	{colorError, colorBackground, []int{colorText, colorDim, colorAccent}},
+	{colorPanel, -1, nil},
+	{colorCursorText, colorAccent, nil},
+	{colorKeyBackground, -1, nil},
+	{colorKeyText, colorKeyBackground, nil},
+	{colorKeyActiveBg, -1, []int{colorKeyBackground}},
+	{colorKeyActiveText, colorKeyActiveBg, nil},
+}
+
+// text keeps at least this contrast with its background when the theme
+// has it, less only when the palette has nothing better
+const minQuantizedContrast = 3
+
+// quantizeTheme moves the colours of theme onto the palette of a terminal
+// with count colours. Every colour takes the nearest palette colour that
+// stays apart from the colours it is shown with and keeps the contrast
+// with its background, so the dim text never turns into the typed text on
+// a 16 colour console. Truecolour terminals get the theme as it is.
+func quantizeTheme(theme Theme, count int) Theme {
+	if count >= trueColors || count <= 0 {
+		return theme
+	}
+	palette := quantizePalette(count)
+	original := theme.colors()
+	quantized := theme
+	colors := quantized.colors()
+	// a colour the theme uses twice, like the accent for the lit key, is
+	// picked once
+	picked := map[tcell.Color]tcell.Color{}
+	for _, role := range colorRoles {
+		color := *original[role.color]
+		rgb, ok := colorRGB(color)
+		// the terminal colours, like the default background, stay
+		if !ok {
+			continue
+		}
+		if same, ok := picked[color]; ok {
+			*colors[role.color] = same
+			continue
+		}
+		// the contrast is kept with the background as it was picked
+		var on themes.RGB
+		hasOn, minContrast := false, 0.0
+		if role.on >= 0 {
+			onOriginal, ok := colorRGB(*original[role.on])
+			on, hasOn = colorRGB(*colors[role.on])
+			hasOn = hasOn && ok
+			if hasOn {
+				minContrast = min(themes.Contrast(rgb, onOriginal), minQuantizedContrast)
+			}
+		}
+		candidates := slices.Clone(palette)
+		slices.SortStableFunc(candidates, func(a, b paletteColor) int {
+			da, db := colorDistance(rgb, a.rgb), colorDistance(rgb, b.rgb)
+			switch {
+			case da < db:
+				return -1
+			case da > db:
+				return 1
+			}
+			return 0
+		})
+		best := candidates[0]
+		for _, candidate := range candidates {
+			if hasOn && themes.Contrast(candidate.rgb, on) < minContrast {
+				continue
+			}
+			if slices.ContainsFunc(role.apart, func(other int) bool {
+				return *original[other] != color && *colors[other] == candidate.color
+			}) {
+				continue
+			}
+			best = candidate
+			break
+		}
+		*colors[role.color] = best.color
+		picked[color] = best.color
+	}
+	return quantized
+}
+
+// one colour of the terminal palette
+type paletteColor struct {
+	color tcell.Color
+	rgb   themes.RGB
+}
+
+// the palette of a terminal with count colours. With 256 the first 16 are
+// left out, terminals change them while the cube and the grays are the
+// same everywhere.
+func quantizePalette(count int) []paletteColor {
+	first, last := 0, min(count, 256)
+	if count >= 256 {
+		first = 16
+	}
+	palette := make([]paletteColor, 0, last-first)
+	for i := first; i < last; i++ {
+		color := tcell.PaletteColor(i)
+		rgb, _ := colorRGB(color)
+		palette = append(palette, paletteColor{color, rgb})
+	}
+	return palette
+}
+
+func colorRGB(color tcell.Color) (themes.RGB, bool) {
+	if color == tcell.ColorDefault || !color.Valid() {
+		return themes.RGB{}, false
+	}
+	r, g, b := color.RGB()
+	if r < 0 {
+		return themes.RGB{}, false
+	}
+	return themes.RGB{R: uint8(r), G: uint8(g), B: uint8(b)}, true
+}
+
+// how different two colours look, the redmean weighting of the channels
+// is close to the eye at a fraction of the cost of Lab
+func colorDistance(a, b themes.RGB) float64 {
+	mean := (float64(a.R) + float64(b.R)) / 2
+	dr := float64(a.R) - float64(b.R)
+	dg := float64(a.G) - float64(b.G)
+	db := float64(a.B) - float64(b.B)
+	return (2+mean/256)*dr*dr + 4*dg*dg + (2+(255-mean)/256)*db*db
+}
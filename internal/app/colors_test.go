@@ -0,0 +1,94 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/themes"
+)
+
+func TestQuantizeThemeKeepsTextStatesApart(t *testing.T) {
+	for _, count := range []int{256, 16, 8} {
+		for _, theme := range ThemeOptions() {
+			// drawn as one of the others
+			if theme.ID == themes.AutoID {
+				continue
+			}
+			q := quantizeTheme(theme, count)
+			for i, color := range q.colors() {
+				original := *theme.colors()[i]
+				if _, ok := colorRGB(original); ok && (color.IsRGB() || int(*color-tcell.ColorValid) >= count) {
+					t.Errorf("%d colours, %s: colour %d is %v", count, theme.ID, i, color)
+				}
+			}
+			for _, pair := range [][2]tcell.Color{{q.Text, q.Dim}, {q.Text, q.Error}, {q.Dim, q.Background}, {q.Accent, q.Background}} {
+				if pair[0] == pair[1] {
+					t.Errorf("%d colours, %s: %+v", count, theme.ID, q)
+				}
+			}
+			if bg, ok := colorRGB(q.Background); ok {
+				text, _ := colorRGB(q.Text)
+				if c := themes.Contrast(text, bg); c < minQuantizedContrast {
+					t.Errorf("%d colours, %s: text contrast %.1f", count, theme.ID, c)
+				}
+			}
+		}
+	}
+	// truecolour keeps the theme
+	theme := ThemeByID("forest")
+	if quantizeTheme(theme, trueColors) != theme {
+		t.Fatal("truecolour theme changed")
+	}
+}
+
+func TestAccessibleThemes(t *testing.T) {
+	// the high contrast themes keep AAA for every pair
+	for _, id := range []string{"high-contrast-dark", "high-contrast-light"} {
+		theme := ThemeByID(id)
+		if theme.ID != id {
+			t.Fatalf("no theme %s", id)
+		}
+		const want = 7
+		for _, check := range contrastChecks {
+			fg, _ := colorRGB(*theme.colors()[check.fg])
+			bg, _ := colorRGB(*theme.colors()[check.bg])
+			if c := themes.Contrast(fg, bg); c < want {
+				t.Errorf("%s: %s on %s %.1f:1, want %d:1", id, colorName(check.fg), colorName(check.bg), c, want)
+			}
+		}
+	}
+	// the colour-blind safe ones pass AA
+	for _, id := range []string{"colorblind-dark", "colorblind-light"} {
+		theme := ThemeByID(id)
+		if theme.ID != id {
+			t.Fatalf("no theme %s", id)
+		}
+		if warnings := lowContrast(theme); len(warnings) > 0 {
+			t.Errorf("%s: %v", id, warnings)
+		}
+	}
+}
+
+func TestNoColor(t *testing.T) {
+	screen := tcell.NewSimulationScreen("UTF-8")
+	t.Setenv("NO_COLOR", "1")
+	if colorCount(screen, "auto") != 0 {
+		t.Fatal("NO_COLOR is ignored")
+	}
+	t.Setenv("NO_COLOR", "")
+	if colorCount(screen, "none") != 0 {
+		t.Fatal("colours with colors = none")
+	}
+
+	// without colours the mistakes and the cursor still show
+	styles := NewStyles(ThemeByID("forest"), 0)
+	for name, style := range map[string]tcell.Style{"mistake": styles.Mistake, "cursor": styles.Cursor, "left to type": styles.Dim} {
+		fg, bg, attrs := style.Decompose()
+		if fg != tcell.ColorDefault || bg != tcell.ColorDefault || attrs == tcell.AttrNone {
+			t.Errorf("%s: %v %v %v", name, fg, bg, attrs)
+		}
+	}
+	if got := markMistake(styles.Correct, "reverse"); got != styles.Correct.Reverse(true) {
+		t.Errorf("reverse mark = %v", got)
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/config"
+	"github.com/yossefsabry/gotype/internal/keymap"
+)
+
+// Tuning is what the config file sets for the model, the renderer and the
+// main loop. It lives on the model and is only used from the ui goroutine, a
+// reload replaces it as a whole.
+type Tuning struct {
+	VisibleLines int
+	WordsPerLine int
+	PlainText    bool
+	// see config.ColorModes
+	Colors string
+	// the themes of the auto theme
+	LightTheme   string
+	DarkTheme    string
+	KeyHighlight time.Duration
+	MessageTime  time.Duration
+	WarningTime  time.Duration
+	// the redraw interval and how often the storage and the config file
+	// are checked for changes by other windows
+	Tick      time.Duration
+	Watch     time.Duration
+	Selectors []SelectorOption
+	Keymap    keymap.Keymap
+}
+
+// newTuning reads the tuning out of a validated config
+func newTuning(cfg config.Config) Tuning {
+	keys, err := keymap.Build(cfg.Keys.Preset, cfg.Keys.Bind)
+	if err != nil {
+		// validation builds the keymap too, this is only a safety net
+		keys, _ = keymap.Build(keymap.DefaultPreset, nil)
+	}
+	return Tuning{
+		VisibleLines: cfg.Display.VisibleLines,
+		WordsPerLine: cfg.Display.WordsPerLine,
+		PlainText:    cfg.Display.PlainText,
+		Colors:       cfg.Display.Colors,
+		LightTheme:   cfg.Display.LightTheme,
+		DarkTheme:    cfg.Display.DarkTheme,
+		KeyHighlight: time.Duration(cfg.Display.KeyHighlight),
+		MessageTime:  time.Duration(cfg.Display.MessageTime),
+		WarningTime:  time.Duration(cfg.Display.WarningTime),
+		Tick:         time.Duration(cfg.Timing.Tick),
+		Watch:        time.Duration(cfg.Timing.Watch),
+		Selectors:    buildSelectors(cfg.Selectors),
+		Keymap:       keys,
+	}
+}
+
+// the tuning of a new model, from the default config
+func defaultTuning() Tuning {
+	return newTuning(config.Default())
+}
+
+// SetTuning switches the model to a new tuning, the lines and the top bar
+// are laid out again for it
+func (m *Model) SetTuning(tuning Tuning) {
+	m.Tuning = tuning
+	m.Layout.Selectors = tuning.Selectors
+	// the lines depend on the words per line
+	m.bumpTargetVersion()
+	if m.Layout.Width > 0 && m.Layout.Height > 0 {
+		m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	}
+	// the length may be one of the new selectors now, or no longer
+	m.syncCustomSelector()
+}
+
+// one selector button per duration and word count pair, in the order of
+// the config
+func buildSelectors(selectors config.Selectors) []SelectorOption {
+	options := make([]SelectorOption, 0, len(selectors.Durations))
+	for i, d := range selectors.Durations {
+		if i >= len(selectors.WordCounts) {
+			break
+		}
+		duration := time.Duration(d)
+		label := durationLabel(duration)
+		options = append(options, SelectorOption{
+			ID:        "sel:" + label,
+			Duration:  duration,
+			WordCount: selectors.WordCounts[i],
+			LabelTime: label,
+			LabelWord: strconv.Itoa(selectors.WordCounts[i]),
+		})
+	}
+	return options
+}
+
+// short label of a selector duration: 60s, 10m or 2h, seconds up to two
+// minutes like the buttons always had
+func durationLabel(d time.Duration) string {
+	switch {
+	case d >= 2*time.Hour && d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d >= 2*time.Minute && d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
+
+// reload the config file when it changed, a broken file keeps the current
+// config and says why in the footer. It reports if anything changed.
+func (a *App) watchConfig(now time.Time) bool {
+	if a.config == nil || now.Sub(a.lastConfigCheck) < a.model.Tuning.Watch {
+		return false
+	}
+	a.lastConfigCheck = now
+	if !a.config.Changed() {
+		return false
+	}
+	cfg, err := a.config.Load()
+	if err != nil {
+		// validation lists every problem on its own line, the footer has one
+		message, _, _ := strings.Cut(err.Error(), "\n")
+		a.model.SetMessage(message, now, a.model.Tuning.WarningTime)
+		return true
+	}
+	previous := a.cfg
+	a.cfg = cfg
+	a.model.SetTuning(newTuning(cfg))
+	if a.store != nil {
+		a.store.Watch(a.model.Tuning.Watch)
+	}
+	if cfg.Profile != previous.Profile || cfg.Backend != previous.Backend {
+		a.switchProfile(cfg.Profile, now)
+		return true
+	}
+	a.model.SetMessage("config reloaded", now, a.model.Tuning.MessageTime)
+	return true
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/config"
+)
+
+// the ids of the selector buttons in their order
+func selectorIDs(options []SelectorOption) []string {
+	ids := make([]string, len(options))
+	for i, option := range options {
+		ids[i] = option.ID
+	}
+	return ids
+}
+
+func TestWatchConfigReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	loader := &config.Loader{Path: path, Getenv: func(string) string { return "" }}
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &App{model: NewModel(), cfg: cfg, config: loader}
+	a.model.Layout.Recalculate(120, 40, a.model.Options.Mode, false)
+	a.model.SetTuning(newTuning(cfg))
+	if got := selectorIDs(a.model.Tuning.Selectors); !slices.Equal(got, []string{"sel:30s", "sel:60s", "sel:10m", "sel:30m"}) {
+		t.Fatalf("default selectors = %v", got)
+	}
+	now := time.Now()
+	if err := os.WriteFile(path, []byte("[display]\nvisible_lines = 5\n[selectors]\ndurations = [\"15s\", \"2h\"]\nword_counts = [5, 500]\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !a.watchConfig(now) || a.model.Tuning.VisibleLines != 5 {
+		t.Fatalf("config not reloaded, %d lines", a.model.Tuning.VisibleLines)
+	}
+	if got := selectorIDs(a.model.Layout.Selectors); !slices.Equal(got, []string{"sel:15s", "sel:2h"}) {
+		t.Fatalf("selectors = %v", got)
+	}
+
+	// a broken file keeps the config and says why
+	if err := os.WriteFile(path, []byte("[display]\nvisible_lines = 99\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !a.watchConfig(now.Add(time.Minute)) || a.model.Tuning.VisibleLines != 5 {
+		t.Fatalf("broken config applied, %d lines", a.model.Tuning.VisibleLines)
+	}
+	if !strings.Contains(a.model.UI.Message, "display.visible_lines") {
+		t.Fatalf("message = %q", a.model.UI.Message)
+	}
+}
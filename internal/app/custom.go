@@ -0,0 +1,136 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// region id of the custom selector, it comes after the configured ones
+const customSelectorRegion = "sel:custom"
+
+// the current test length when it isn't one of the selector buttons
+func (m *Model) customLength() (string, bool) {
+	for _, option := range m.Tuning.Selectors {
+		if m.Options.Mode == ModeWords && option.WordCount == m.Options.WordCount {
+			return "", false
+		}
+		if m.Options.Mode != ModeWords && option.Duration == m.Options.Duration {
+			return "", false
+		}
+	}
+	if m.Options.Mode == ModeWords {
+		return strconv.Itoa(m.Options.WordCount), true
+	}
+	return durationLabel(m.Options.Duration), true
+}
+
+// label of the custom selector: the input while it's typed, the custom
+// length when one is used and "custom" otherwise
+func customSelectorLabel(m *Model) string {
+	if m.EditingCustom {
+		return string(m.customInput) + "_"
+	}
+	if label, ok := m.customLength(); ok {
+		return label
+	}
+	return "custom"
+}
+
+// keep the custom selector label in step with the options, the top bar is
+// laid out again when its width changes
+func (m *Model) syncCustomSelector() {
+	label := customSelectorLabel(m)
+	if m.Layout.Custom == label {
+		return
+	}
+	m.Layout.Custom = label
+	if m.Layout.Width > 0 && m.Layout.Height > 0 {
+		m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	}
+}
+
+// start typing a custom length in place of the custom selector, a second
+// click cancels
+func (m *Model) toggleCustomInput() bool {
+	m.EditingCustom = !m.EditingCustom
+	m.customInput = m.customInput[:0]
+	m.syncCustomSelector()
+	return true
+}
+
+// keys while the custom length is typed: enter applies it, esc cancels
+func (m *Model) handleCustomKey(event *tcell.EventKey, now time.Time) bool {
+	switch event.Key() {
+	case tcell.KeyEsc:
+		return m.toggleCustomInput()
+	case tcell.KeyEnter:
+		return m.applyCustomLength(string(m.customInput), now)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(m.customInput) > 0 {
+			m.customInput = m.customInput[:len(m.customInput)-1]
+			m.syncCustomSelector()
+		}
+		return true
+	case tcell.KeyRune:
+		// digits, and the units of a duration in time mode
+		r := event.Rune()
+		digit := r >= '0' && r <= '9'
+		unit := m.Options.Mode != ModeWords && (r == 'h' || r == 'm' || r == 's')
+		if (digit || unit) && len(m.customInput) < 8 {
+			m.customInput = append(m.customInput, r)
+			m.syncCustomSelector()
+		}
+		return true
+	}
+	return false
+}
+
+// use the typed length for the test, it is checked like the saved
+// preferences are so a custom length always loads again
+func (m *Model) applyCustomLength(input string, now time.Time) bool {
+	prefs := preferencesFromModel(m)
+	var err error
+	if m.Options.Mode == ModeWords {
+		if prefs.WordCount, err = strconv.Atoi(input); err != nil {
+			err = fmt.Errorf("invalid word count %q", input)
+		} else {
+			err = storage.ValidWordCount(prefs.WordCount)
+		}
+	} else {
+		var duration time.Duration
+		if duration, err = parseCustomDuration(input); err == nil {
+			prefs.DurationSeconds = int(duration / time.Second)
+			err = storage.ValidDuration(prefs.DurationSeconds)
+		}
+	}
+	if err != nil {
+		m.SetMessage(err.Error(), now, m.Tuning.WarningTime)
+		return true
+	}
+	m.EditingCustom = false
+	m.Options.WordCount = prefs.WordCount
+	m.Options.Duration = time.Duration(prefs.DurationSeconds) * time.Second
+	m.Reset()
+	if m.Options.Mode == ModeWords {
+		m.SetMessage(fmt.Sprintf("length: %d words", m.Options.WordCount), now, m.Tuning.MessageTime)
+	} else {
+		m.SetMessage("length: "+durationLabel(m.Options.Duration), now, m.Tuning.MessageTime)
+	}
+	return true
+}
+
+// a duration like 15s, 2m or 1h30m, a bare number is seconds
+func parseCustomDuration(input string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(input); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	duration, err := time.ParseDuration(input)
+	if err != nil || duration%time.Second != 0 {
+		return 0, fmt.Errorf("invalid duration %q (like 15s, 2m or 1h30m)", input)
+	}
+	return duration, nil
+}
@@ -0,0 +1,57 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func typeCustomLength(m *Model, input string, now time.Time) {
+	m.applyRegion(customSelectorRegion, now)
+	for _, r := range input {
+		m.HandleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), now)
+	}
+	m.HandleKey(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), now)
+}
+
+func TestCustomLength(t *testing.T) {
+	now := time.Now()
+	m := NewModel()
+	m.Layout.Recalculate(160, 40, m.Options.Mode, false)
+
+	typeCustomLength(m, "1m30s", now)
+	if m.EditingCustom || m.Options.Duration != 90*time.Second || m.Timer.Remaining != 90*time.Second {
+		t.Fatalf("duration = %s, editing %v", m.Options.Duration, m.EditingCustom)
+	}
+	if m.Layout.Custom != "90s" {
+		t.Fatalf("custom label = %q", m.Layout.Custom)
+	}
+	// the custom length is saved and loaded like any other
+	prefs := preferencesFromModel(m)
+	other := NewModel()
+	if !applyPreferences(other, prefs) || other.Options.Duration != 90*time.Second {
+		t.Fatalf("custom duration not loaded: %+v", prefs)
+	}
+
+	// out of range stays in the input with a warning
+	m.applyRegion("mode:words", now)
+	typeCustomLength(m, "0", now)
+	if !m.EditingCustom || m.UI.Message == "" {
+		t.Fatal("a zero word count was accepted")
+	}
+	m.HandleKey(tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone), now)
+	for _, r := range "37s" {
+		m.HandleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), now)
+	}
+	m.HandleKey(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), now)
+	if m.Options.WordCount != 37 || len(m.Text.Target) == 0 {
+		t.Fatalf("word count = %d", m.Options.WordCount)
+	}
+
+	// a configured slot turns the custom selector back to its label
+	m.applyRegion(m.Tuning.Selectors[0].ID, now)
+	if m.Layout.Custom != "custom" {
+		t.Fatalf("custom label = %q", m.Layout.Custom)
+	}
+}
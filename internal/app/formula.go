@@ -0,0 +1,159 @@
+package app
+
+// formula decides which characters count towards the net and gross speed
+type Formula int
+
+const (
+	// net counts correct chars, gross counts every keystroke (cpm/kpm).
+	// The default, it is the speed gotype showed before there were formulas.
+	FormulaPlain Formula = iota
+	// gross counts every typed char, net subtracts uncorrected errors per minute
+	FormulaStandard
+	// net counts only fully-correct words plus their spaces
+	FormulaMonkeytype
+)
+
+// unit used for showing speeds in the UI
+type Unit int
+
+const (
+	UnitWPM Unit = iota
+	UnitCPM
+)
+
+// average word length used to turn chars per minute into words per minute
+const charsPerWord = 5.0
+
+// order used when cycling through formulas and units
+var formulaOrder = []Formula{FormulaPlain, FormulaStandard, FormulaMonkeytype}
+var unitOrder = []Unit{UnitWPM, UnitCPM}
+
+// convert the formula enum to a string for storage and labels
+func formulaToString(formula Formula) string {
+	switch formula {
+	case FormulaStandard:
+		return "standard"
+	case FormulaMonkeytype:
+		return "monkeytype"
+	default:
+		return "plain"
+	}
+}
+
+// convert the formula string from storage back to the enum
+func formulaFromString(value string) Formula {
+	switch value {
+	case "standard":
+		return FormulaStandard
+	case "monkeytype":
+		return FormulaMonkeytype
+	default:
+		return FormulaPlain
+	}
+}
+
+// convert the unit enum to a string for storage
+func unitToString(unit Unit) string {
+	if unit == UnitCPM {
+		return "cpm"
+	}
+	return "wpm"
+}
+
+// convert the unit string from storage back to the enum
+func unitFromString(value string) Unit {
+	if value == "cpm" {
+		return UnitCPM
+	}
+	return UnitWPM
+}
+
+// return the next formula in the cycle order
+func nextFormula(formula Formula) Formula {
+	for i, f := range formulaOrder {
+		if f == formula {
+			return formulaOrder[(i+1)%len(formulaOrder)]
+		}
+	}
+	return formulaOrder[0]
+}
+
+// return the next unit in the cycle order
+func nextUnit(unit Unit) Unit {
+	for i, u := range unitOrder {
+		if u == unit {
+			return unitOrder[(i+1)%len(unitOrder)]
+		}
+	}
+	return unitOrder[0]
+}
+
+// rates returns the net and gross chars per minute for the current formula
+func (m *Model) rates(minutes float64) (float64, float64) {
+	if minutes <= 0 {
+		return 0, 0
+	}
+	typed := float64(m.Stats.Correct + m.Stats.Incorrect)
+	var net, gross float64
+	switch m.Options.Formula {
+	case FormulaMonkeytype:
+		net = float64(m.correctWordChars()) / minutes
+		gross = typed / minutes
+	case FormulaPlain:
+		net = float64(m.Stats.Correct) / minutes
+		gross = float64(m.Stats.Keystrokes) / minutes
+	default:
+		gross = typed / minutes
+		net = gross - float64(m.Stats.Incorrect)*charsPerWord/minutes
+	}
+	if net < 0 {
+		net = 0
+	}
+	return net, gross
+}
+
+// correctWordChars counts the chars of words that were typed without any
+// mistake, plus the space after each of them
+func (m *Model) correctWordChars() int {
+	total := 0
+	wordStart := 0
+	wordCorrect := true
+	for i, typed := range m.Text.Typed {
+		target := m.Text.Target[i]
+		if target == ' ' {
+			if wordCorrect && typed == ' ' {
+				total += i - wordStart + 1
+			}
+			wordStart = i + 1
+			wordCorrect = true
+			continue
+		}
+		if typed != target {
+			wordCorrect = false
+		}
+	}
+	// the word under the cursor counts once the test is over
+	if m.Timer.Finished && wordCorrect && wordStart < len(m.Text.Typed) {
+		total += len(m.Text.Typed) - wordStart
+	}
+	return total
+}
+
+// rateLabels returns the labels for the net and raw speed
+func rateLabels(formula Formula, unit Unit) (string, string) {
+	if unit == UnitCPM {
+		if formula == FormulaPlain {
+			return "cpm", "kpm"
+		}
+		return "cpm", "raw"
+	}
+	return "wpm", "raw"
+}
+
+// pick the value for the current unit from a wpm/cpm pair
+func unitValue(unit Unit, wpm, cpm int) int {
+	if unit == UnitCPM {
+		return cpm
+	}
+	return wpm
+}
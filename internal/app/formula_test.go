@@ -0,0 +1,82 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+func newFormulaModel(target, typed string, formula Formula) *Model {
+	model := &Model{}
+	model.Options.Formula = formula
+	model.Text.Target = []rune(target)
+	for _, r := range typed {
+		index := len(model.Text.Typed)
+		model.Text.Typed = append(model.Text.Typed, r)
+		model.Stats.Keystrokes++
+		if model.Text.Target[index] == r {
+			model.Stats.Correct++
+		} else {
+			model.Stats.Incorrect++
+		}
+	}
+	return model
+}
+
+func TestRatesStandard(t *testing.T) {
+	model := newFormulaModel("the quick fox", "thx quick", FormulaStandard)
+	net, gross := model.rates(1)
+	if gross != 9 {
+		t.Fatalf("gross = %v, want 9", gross)
+	}
+	// one uncorrected error takes a whole word off the net speed
+	if net != 4 {
+		t.Fatalf("net = %v, want 4", net)
+	}
+}
+
+func TestRatesMonkeytype(t *testing.T) {
+	model := newFormulaModel("the quick fox", "thx quick fo", FormulaMonkeytype)
+	net, _ := model.rates(1)
+	// only "quick " is fully correct, the unfinished word doesn't count yet
+	if net != 6 {
+		t.Fatalf("net = %v, want 6", net)
+	}
+	model.Timer.Finished = true
+	net, _ = model.rates(1)
+	if net != 8 {
+		t.Fatalf("net after finish = %v, want 8", net)
+	}
+}
+
+func TestRatesPlain(t *testing.T) {
+	model := newFormulaModel("the", "thx", FormulaPlain)
+	model.Stats.Keystrokes += 2
+	net, gross := model.rates(0.5)
+	if net != 4 || gross != 10 {
+		t.Fatalf("net, gross = %v, %v, want 4, 10", net, gross)
+	}
+}
+
+func TestScoreKeyIncludesFormula(t *testing.T) {
+	standard := scoreKey(Options{Formula: FormulaStandard})
+	plain := scoreKey(Options{Formula: FormulaPlain})
+	if standard == plain {
+		t.Fatalf("score keys should differ per formula, got %q", standard)
+	}
+}
+
+// the speed before there were formulas was plain, the best scores migrated
+// from then are keyed with it
+func TestPlainFormulaIsTheDefault(t *testing.T) {
+	if m := NewModel(); m.Options.Formula != FormulaPlain {
+		t.Fatalf("new model formula = %s", formulaToString(m.Options.Formula))
+	}
+	m := NewModel()
+	m.Options.Formula = FormulaMonkeytype
+	applyPreferences(m, storage.Preferences{Mode: "time", DurationSeconds: 30})
+	if m.Options.Formula != FormulaPlain || !strings.HasSuffix(scoreKey(m.Options), "|formula=plain") {
+		t.Fatalf("formula of preferences without one = %s", formulaToString(m.Options.Formula))
+	}
+}
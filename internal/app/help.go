@@ -0,0 +1,72 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yossefsabry/gotype/internal/keymap"
+)
+
+// width of one column of the help
+const helpColumnWidth = 44
+
+// one line of the help for every binding of the keymap
+func helpLines(keys keymap.Keymap) []string {
+	var lines []string
+	for _, binding := range keys.Bindings() {
+		action, ok := actionByName(binding.Action)
+		if !ok {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%-15s %s", binding.Chord, action.Title))
+	}
+	return append(lines, fmt.Sprintf("%-15s %s", "ctrl+c", "quit"))
+}
+
+// "<tab> reset" with the first key bound to action, empty without one
+func keyHint(keys keymap.Keymap, action, label string) string {
+	chords := keys.Chords(action)
+	if len(chords) == 0 {
+		return ""
+	}
+	return "<" + string(chords[0]) + "> " + label
+}
+
+// the hints of the footer for the keys in use, pairs of action and label
+func keyHints(keys keymap.Keymap, pairs ...string) string {
+	var hints []string
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if hint := keyHint(keys, pairs[i], pairs[i+1]); hint != "" {
+			hints = append(hints, hint)
+		}
+	}
+	return strings.Join(hints, "  ")
+}
+
+// renders the bindings of the keymap in columns over the screen, any key
+// closes it
+func (r *Renderer) drawHelp(model *Model, width, height int) {
+	if !model.Help {
+		return
+	}
+	lines := helpLines(model.Tuning.Keymap)
+	columns := max(1, min(3, (width-4)/helpColumnWidth))
+	rows := (len(lines) + columns - 1) / columns
+	boxWidth := min(columns*helpColumnWidth+2, width)
+	boxHeight := rows + 4
+	x := (width - boxWidth) / 2
+	y := max((height-boxHeight)/2, model.Layout.TopY+2)
+	for row := 0; row < boxHeight; row++ {
+		for col := 0; col < boxWidth; col++ {
+			r.setContent(x+col, y+row, ' ', r.styles.Panel)
+		}
+	}
+	title := "keys, " + model.Tuning.Keymap.Preset + " keymap"
+	r.drawClipped(x+2, y, boxWidth-4, title, r.panelStyle(r.styles.Accent))
+	for i, line := range lines {
+		column, row := i/rows, i%rows
+		r.drawClipped(x+2+column*helpColumnWidth, y+2+row, helpColumnWidth-2, line, r.panelStyle(r.styles.Dim))
+	}
+	note := "characters only act once the test is over, any key closes"
+	r.drawClipped(x+2, y+boxHeight-1, boxWidth-4, note, r.panelStyle(r.styles.Dim))
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/config"
+	"github.com/yossefsabry/gotype/internal/keymap"
+)
+
+func TestEveryKeymapActionExists(t *testing.T) {
+	for _, name := range keymap.Actions {
+		if _, ok := actionByName(name); !ok {
+			t.Errorf("no action named %s", name)
+		}
+	}
+}
+
+func TestKeymapPreset(t *testing.T) {
+	cfg := config.Default()
+	cfg.Keys = config.Keys{Preset: "vim-ish", Bind: map[string]string{"f5": "next-theme"}}
+
+	now := time.Now()
+	m := NewModel()
+	m.Layout.Recalculate(160, 40, m.Options.Mode, false)
+	m.SetTuning(newTuning(cfg))
+	typeRune := func(r rune) (bool, bool) {
+		return m.HandleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), now)
+	}
+
+	// esc goes to the top bar instead of quitting
+	if _, quit := m.HandleKey(tcell.NewEventKey(tcell.KeyEsc, 0, tcell.ModNone), now); quit || !m.Bar.Active {
+		t.Fatalf("esc quit %v, top bar %v", quit, m.Bar.Active)
+	}
+	pressKey(m, tcell.KeyEsc, now)
+	theme := m.ThemeID
+	pressKey(m, tcell.KeyF5, now)
+	if m.ThemeID == theme {
+		t.Fatal("f5 didn't change the theme")
+	}
+
+	// characters are typed while the test runs
+	if _, quit := typeRune('q'); quit || len(m.Text.Typed) != 1 {
+		t.Fatalf("q quit %v, typed %q", quit, string(m.Text.Typed))
+	}
+	m.Timer.Finished = true
+	typeRune(':')
+	if !m.Palette.Open {
+		t.Fatal(": didn't open the palette on the results")
+	}
+	pressKey(m, tcell.KeyEsc, now)
+	typeRune('?')
+	if !m.Help {
+		t.Fatal("? didn't open the help")
+	}
+	// any key closes the help and does nothing else
+	if _, quit := typeRune('q'); quit || m.Help {
+		t.Fatalf("q quit %v, help %v", quit, m.Help)
+	}
+	if _, quit := typeRune('q'); !quit {
+		t.Fatal("q didn't quit on the results")
+	}
+}
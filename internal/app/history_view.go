@@ -0,0 +1,370 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// HistorySource gives the history browser read access to the stored runs,
+// writes (deletes) go back to the app so they run on the persister
+type HistorySource interface {
+	Runs() []storage.Summary
+	Get(id string) (storage.Run, error)
+}
+
+// column used for sorting the history table
+type HistorySort int
+
+const (
+	SortDate HistorySort = iota
+	SortWPM
+	SortAccuracy
+)
+
+// height of the per-second chart under the history table
+const historyChartHeight = 5
+
+// state of the history screen, filters are empty when they show everything
+type HistoryBrowser struct {
+	Runs          []storage.Summary
+	Rows          []int
+	Selected      int
+	Start         int
+	Sort          HistorySort
+	Ascending     bool
+	Mode          string
+	Amount        string
+	Language      string
+	Detail        storage.Run
+	HasDetail     bool
+	confirmDelete string
+}
+
+// OpenHistory switches to the history screen with a fresh copy of the runs
+func (m *Model) OpenHistory(now time.Time) bool {
+	if m.focusActive() || m.Replaying() {
+		return false
+	}
+	if m.HistorySource == nil {
+		m.SetMessage("history is not available", now, m.Tuning.MessageTime)
+		return true
+	}
+	m.CloseStats()
+	m.View = ViewHistory
+	m.closeMenus()
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	m.Browser.Runs = m.HistorySource.Runs()
+	m.Browser.Selected = 0
+	m.Browser.Start = 0
+	m.Browser.confirmDelete = ""
+	m.applyHistoryFilter()
+	return true
+}
+
+// CloseHistory goes back to the typing screen
+func (m *Model) CloseHistory() bool {
+	if m.View != ViewHistory {
+		return false
+	}
+	m.View = ViewTyping
+	m.Browser.Runs = nil
+	m.Browser.Rows = nil
+	m.Browser.HasDetail = false
+	return true
+}
+
+// TakeDeletes returns the runs deleted in the browser since the last call
+func (m *Model) TakeDeletes() []string {
+	deletes := m.pendingDeletes
+	m.pendingDeletes = nil
+	return deletes
+}
+
+// historyVisibleRows returns how many table rows fit above the details
+func historyVisibleRows(layout Layout) int {
+	rows := historyDetailY(layout) - 1 - (layout.StatsY + 2)
+	if rows < 1 {
+		rows = 1
+	}
+	return rows
+}
+
+// historyDetailY is the line of the selected run details, the chart is
+// drawn right below it
+func historyDetailY(layout Layout) int {
+	return layout.FooterY - historyChartHeight - 2
+}
+
+// handle keys while the history screen is open
+func (m *Model) handleHistoryKey(event *tcell.EventKey, now time.Time) (bool, bool) {
+	b := &m.Browser
+	visible := historyVisibleRows(m.Layout)
+	switch event.Key() {
+	case tcell.KeyCtrlC:
+		return false, true
+	case tcell.KeyEsc, tcell.KeyF2:
+		return m.CloseHistory(), false
+	case tcell.KeyUp:
+		return m.selectHistoryRow(b.Selected - 1), false
+	case tcell.KeyDown:
+		return m.selectHistoryRow(b.Selected + 1), false
+	case tcell.KeyPgUp:
+		return m.selectHistoryRow(b.Selected - visible), false
+	case tcell.KeyPgDn:
+		return m.selectHistoryRow(b.Selected + visible), false
+	case tcell.KeyHome:
+		return m.selectHistoryRow(0), false
+	case tcell.KeyEnd:
+		return m.selectHistoryRow(len(b.Rows) - 1), false
+	case tcell.KeyEnter:
+		return m.retrySelectedRun(now), false
+	case tcell.KeyDelete:
+		return m.deleteSelectedRun(now), false
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'q':
+			return m.CloseHistory(), false
+		case 'r':
+			return m.retrySelectedRun(now), false
+		case 'p':
+			return m.replaySelectedRun(now), false
+		case 'x':
+			return m.deleteSelectedRun(now), false
+		case 's':
+			b.Sort = (b.Sort + 1) % 3
+			m.applyHistoryFilter()
+			return true, false
+		case 'o':
+			b.Ascending = !b.Ascending
+			m.applyHistoryFilter()
+			return true, false
+		case 'm':
+			b.Mode = cycleFilter(b.Mode, []string{"time", "words"})
+			b.Amount = ""
+			m.applyHistoryFilter()
+			return true, false
+		case 'a':
+			b.Amount = cycleFilter(b.Amount, m.historyValues(runAmountLabel))
+			m.applyHistoryFilter()
+			return true, false
+		case 'l':
+			b.Language = cycleFilter(b.Language, m.historyValues(func(run storage.Summary) string {
+				return run.Options.Language
+			}))
+			m.applyHistoryFilter()
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// rebuild the visible rows from the filters and the sort order
+func (m *Model) applyHistoryFilter() {
+	b := &m.Browser
+	selectedID := ""
+	if run, ok := m.selectedRun(); ok {
+		selectedID = run.ID
+	}
+	b.Rows = b.Rows[:0]
+	for i, run := range b.Runs {
+		if b.Mode != "" && run.Options.Mode != b.Mode {
+			continue
+		}
+		if b.Amount != "" && runAmountLabel(run) != b.Amount {
+			continue
+		}
+		if b.Language != "" && run.Options.Language != b.Language {
+			continue
+		}
+		b.Rows = append(b.Rows, i)
+	}
+	sort.SliceStable(b.Rows, func(i, j int) bool {
+		left, right := b.Runs[b.Rows[i]], b.Runs[b.Rows[j]]
+		if b.Ascending {
+			return historySortValue(left, b.Sort) < historySortValue(right, b.Sort)
+		}
+		return historySortValue(left, b.Sort) > historySortValue(right, b.Sort)
+	})
+	// keep the same run selected when it is still visible
+	selected := 0
+	for i, index := range b.Rows {
+		if b.Runs[index].ID == selectedID {
+			selected = i
+			break
+		}
+	}
+	b.Selected = -1
+	b.Start = 0
+	m.selectHistoryRow(selected)
+}
+
+// move the selection, keep it inside the scroll window and load its details
+func (m *Model) selectHistoryRow(index int) bool {
+	b := &m.Browser
+	if index >= len(b.Rows) {
+		index = len(b.Rows) - 1
+	}
+	if index < 0 {
+		index = 0
+	}
+	if index == b.Selected {
+		return false
+	}
+	b.Selected = index
+	b.confirmDelete = ""
+	visible := historyVisibleRows(m.Layout)
+	if index < b.Start {
+		scrollBy(&b.Start, index-b.Start, len(b.Rows), visible)
+	} else if index >= b.Start+visible {
+		scrollBy(&b.Start, index-b.Start-visible+1, len(b.Rows), visible)
+	}
+	m.loadHistoryDetail()
+	return true
+}
+
+// read the full selected run from disk for the details and the chart
+func (m *Model) loadHistoryDetail() {
+	b := &m.Browser
+	b.HasDetail = false
+	run, ok := m.selectedRun()
+	if !ok || m.HistorySource == nil {
+		return
+	}
+	detail, err := m.HistorySource.Get(run.ID)
+	if err != nil {
+		detail = storage.Run{Summary: run}
+	}
+	b.Detail = detail
+	b.HasDetail = true
+}
+
+// return the summary of the selected row
+func (m *Model) selectedRun() (storage.Summary, bool) {
+	b := &m.Browser
+	if b.Selected < 0 || b.Selected >= len(b.Rows) {
+		return storage.Summary{}, false
+	}
+	return b.Runs[b.Rows[b.Selected]], true
+}
+
+// start a new test with the same options and text as the selected run
+func (m *Model) retrySelectedRun(now time.Time) bool {
+	run, ok := m.selectedRun()
+	if !ok {
+		return false
+	}
+	m.CloseHistory()
+	m.Options = optionsFromRun(run.Options)
+	// a retry of a preset run counts for the preset again
+	m.Preset = run.Options.Preset
+	if run.Seed != 0 {
+		m.ResetWithSeed(run.Seed)
+	} else {
+		m.Reset()
+	}
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	m.SetMessage("retrying run from "+formatRunTime(run.StartedAt), now, m.Tuning.MessageTime)
+	return true
+}
+
+// play the key log of the selected run back on the typing screen
+func (m *Model) replaySelectedRun(now time.Time) bool {
+	b := &m.Browser
+	if !b.HasDetail || len(b.Detail.Log) == 0 {
+		m.SetMessage("no key log for this run", now, m.Tuning.MessageTime)
+		return true
+	}
+	detail := b.Detail
+	m.CloseHistory()
+	m.StartReplay(optionsFromRun(detail.Options), detail.Seed, eventsFromRun(detail), now)
+	m.SetMessage("replaying run from "+formatRunTime(detail.StartedAt), now, m.Tuning.MessageTime)
+	return true
+}
+
+// delete the selected run, the first press only asks for confirmation
+func (m *Model) deleteSelectedRun(now time.Time) bool {
+	b := &m.Browser
+	run, ok := m.selectedRun()
+	if !ok {
+		return false
+	}
+	if b.confirmDelete != run.ID {
+		b.confirmDelete = run.ID
+		m.SetMessage("press x again to delete this run", now, m.Tuning.MessageTime)
+		return true
+	}
+	m.pendingDeletes = append(m.pendingDeletes, run.ID)
+	index := b.Rows[b.Selected]
+	b.Runs = append(b.Runs[:index], b.Runs[index+1:]...)
+	selected := b.Selected
+	// the rows still point into the old runs so drop the selection first
+	b.Selected = -1
+	m.applyHistoryFilter()
+	m.selectHistoryRow(selected)
+	m.SetMessage("run deleted", now, m.Tuning.MessageTime)
+	return true
+}
+
+// collect the distinct values of a field over the runs, sorted
+func (m *Model) historyValues(value func(storage.Summary) string) []string {
+	seen := map[string]bool{}
+	values := []string{}
+	for _, run := range m.Browser.Runs {
+		if m.Browser.Mode != "" && run.Options.Mode != m.Browser.Mode {
+			continue
+		}
+		v := value(run)
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		values = append(values, v)
+	}
+	sort.Strings(values)
+	return values
+}
+
+// value of the sort column for one run
+func historySortValue(run storage.Summary, column HistorySort) int64 {
+	switch column {
+	case SortWPM:
+		return int64(run.Metrics.WPM)
+	case SortAccuracy:
+		return int64(run.Metrics.Accuracy)
+	default:
+		return run.StartedAt
+	}
+}
+
+// step a filter to the next value, after the last value it shows all again
+func cycleFilter(current string, values []string) string {
+	if current == "" {
+		if len(values) == 0 {
+			return ""
+		}
+		return values[0]
+	}
+	for i, value := range values {
+		if value == current && i+1 < len(values) {
+			return values[i+1]
+		}
+	}
+	return ""
+}
+
+// short label for the length of a run like "60s" or "50w"
+func runAmountLabel(run storage.Summary) string {
+	if run.Options.Mode == "words" {
+		return fmt.Sprintf("%dw", run.Options.WordCount)
+	}
+	return formatDuration(time.Duration(run.Options.DurationSeconds) * time.Second)
+}
+
+// format a unix millisecond timestamp for the history screen
+func formatRunTime(ms int64) string {
+	return time.UnixMilli(ms).Local().Format("2006-01-02 15:04")
+}
@@ -0,0 +1,275 @@
+package app
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// a history in memory, Get gives the full run of a summary
+type historyRuns []storage.Run
+
+func (h historyRuns) Runs() []storage.Summary {
+	runs := make([]storage.Summary, len(h))
+	for i, run := range h {
+		runs[i] = run.Summary
+	}
+	return runs
+}
+
+func (h historyRuns) Get(id string) (storage.Run, error) {
+	for _, run := range h {
+		if run.ID == id {
+			return run, nil
+		}
+	}
+	return storage.Run{}, storage.ErrRunNotFound
+}
+
+func historyRun(id string, mode string, amount int, language string, wpm int, startedAt int64) storage.Run {
+	options := storage.RunOptions{Mode: mode, Language: language}
+	if mode == "words" {
+		options.WordCount = amount
+	} else {
+		options.DurationSeconds = amount
+	}
+	return storage.Run{Summary: storage.Summary{
+		ID:        id,
+		Options:   options,
+		Seed:      startedAt,
+		StartedAt: startedAt,
+		Metrics:   storage.Metrics{WPM: wpm, Accuracy: 100 - wpm/10},
+	}}
+}
+
+func pressRune(m *Model, r rune, now time.Time) {
+	m.HandleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), now)
+}
+
+// the ids of the rows in the order they are shown
+func historyRows(m *Model) string {
+	ids := make([]string, len(m.Browser.Rows))
+	for i, index := range m.Browser.Rows {
+		ids[i] = m.Browser.Runs[index].ID
+	}
+	return strings.Join(ids, " ")
+}
+
+func openTestHistory(t *testing.T, runs historyRuns, now time.Time) *Model {
+	t.Helper()
+	m := NewModel()
+	m.Layout.Recalculate(120, 40, m.Options.Mode, false)
+	m.HistorySource = runs
+	if !m.OpenHistory(now) || m.View != ViewHistory {
+		t.Fatal("the history didn't open")
+	}
+	return m
+}
+
+func TestHistoryBrowserFiltersAndSorts(t *testing.T) {
+	now := time.Now()
+	m := openTestHistory(t, historyRuns{
+		historyRun("a", "time", 60, "english", 50, 1000),
+		historyRun("b", "time", 30, "english", 80, 2000),
+		historyRun("c", "words", 25, "english", 60, 3000),
+		historyRun("d", "time", 60, "german", 70, 4000),
+	}, now)
+
+	// newest first, the selected run has its details loaded
+	if got := historyRows(m); got != "d c b a" {
+		t.Fatalf("rows = %s", got)
+	}
+	if !m.Browser.HasDetail || m.Browser.Detail.ID != "d" {
+		t.Fatalf("detail = %+v", m.Browser.Detail.Summary)
+	}
+
+	// sorting keeps the selected run selected
+	pressRune(m, 's', now)
+	if got := historyRows(m); got != "b d c a" || m.Browser.Selected != 1 {
+		t.Fatalf("by wpm rows = %s, selected %d", got, m.Browser.Selected)
+	}
+	pressRune(m, 's', now)
+	if got := historyRows(m); got != "a c d b" {
+		t.Fatalf("by accuracy rows = %s", got)
+	}
+	pressRune(m, 'o', now)
+	if got := historyRows(m); got != "b d c a" {
+		t.Fatalf("by accuracy ascending rows = %s", got)
+	}
+	pressRune(m, 's', now)
+	pressRune(m, 'o', now)
+
+	// the mode filter, then the amounts and the languages of that mode
+	for _, step := range []struct {
+		key  rune
+		want string
+	}{
+		{'m', "d b a"},
+		{'a', "d a"},
+		{'a', "b"},
+		{'a', "d b a"},
+		{'l', "b a"},
+		{'l', "d"},
+		{'l', "d b a"},
+		{'m', "c"},
+		{'m', "d c b a"},
+	} {
+		pressRune(m, step.key, now)
+		if got := historyRows(m); got != step.want {
+			t.Fatalf("after %c rows = %s, want %s (mode %q amount %q language %q)", step.key, got, step.want,
+				m.Browser.Mode, m.Browser.Amount, m.Browser.Language)
+		}
+	}
+
+	pressKey(m, tcell.KeyEsc, now)
+	if m.View != ViewTyping || m.Browser.Runs != nil {
+		t.Fatal("esc didn't close the history")
+	}
+}
+
+func TestHistoryBrowserDelete(t *testing.T) {
+	now := time.Now()
+	m := openTestHistory(t, historyRuns{
+		historyRun("a", "time", 60, "english", 50, 1000),
+		historyRun("b", "time", 60, "english", 60, 2000),
+		historyRun("c", "time", 60, "english", 70, 3000),
+	}, now)
+	pressKey(m, tcell.KeyDown, now)
+
+	// the first x only asks, moving away forgets it
+	pressRune(m, 'x', now)
+	if historyRows(m) != "c b a" || !strings.Contains(m.UI.Message, "again") {
+		t.Fatalf("rows = %s, message %q", historyRows(m), m.UI.Message)
+	}
+	pressKey(m, tcell.KeyUp, now)
+	pressKey(m, tcell.KeyDown, now)
+	pressRune(m, 'x', now)
+	if len(m.TakeDeletes()) != 0 {
+		t.Fatal("deleted without asking")
+	}
+
+	// the row below moves up into the selection
+	pressRune(m, 'x', now)
+	if got := historyRows(m); got != "c a" || m.Browser.Selected != 1 || m.Browser.Detail.ID != "a" {
+		t.Fatalf("rows = %s, selected %d", got, m.Browser.Selected)
+	}
+	if deletes := m.TakeDeletes(); !slices.Equal(deletes, []string{"b"}) {
+		t.Fatalf("deletes = %v", deletes)
+	}
+
+	// the last one selects the one above it
+	pressRune(m, 'x', now)
+	pressRune(m, 'x', now)
+	if got := historyRows(m); got != "c" || m.Browser.Selected != 0 {
+		t.Fatalf("rows = %s, selected %d", got, m.Browser.Selected)
+	}
+}
+
+func TestHistoryBrowserRetryAndReplay(t *testing.T) {
+	now := time.Now()
+	run := historyRun("a", "time", 30, "english", 50, 1234)
+	// the text the run had comes back from its seed
+	reference := NewModel()
+	reference.Options = optionsFromRun(run.Options)
+	reference.ResetWithSeed(run.Seed)
+	target := reference.Text.Target
+	for i, r := range target[:3] {
+		run.Log = append(run.Log, storage.Keystroke{At: int64(i+1) * 100, Rune: r})
+	}
+
+	m := openTestHistory(t, historyRuns{run}, now)
+	pressKey(m, tcell.KeyEnter, now)
+	if m.View != ViewTyping || m.Seed != run.Seed || m.Options.Duration != 30*time.Second ||
+		!slices.Equal(m.Text.Target[:20], target[:20]) {
+		t.Fatalf("retry: view %v, seed %d, duration %v", m.View, m.Seed, m.Options.Duration)
+	}
+
+	// the replay types the logged keys when they are due
+	m.OpenHistory(now)
+	pressRune(m, 'p', now)
+	if !m.Replaying() || m.View != ViewTyping {
+		t.Fatal("the replay didn't start")
+	}
+	m.Update(now.Add(250 * time.Millisecond))
+	if got := string(m.Text.Typed); got != string(target[:2]) {
+		t.Fatalf("typed %q after 250ms, want %q", got, string(target[:2]))
+	}
+	// typing does nothing and esc stops it
+	pressRune(m, 'z', now)
+	if string(m.Text.Typed) != string(target[:2]) {
+		t.Fatal("a key typed into the replay")
+	}
+	pressKey(m, tcell.KeyEsc, now)
+	if m.Replaying() || len(m.Text.Typed) != 0 {
+		t.Fatal("esc didn't stop the replay")
+	}
+
+	// a run without a key log can't be replayed
+	m = openTestHistory(t, historyRuns{historyRun("b", "time", 30, "english", 50, 1)}, now)
+	pressRune(m, 'p', now)
+	if m.Replaying() || m.View != ViewHistory || m.UI.Message == "" {
+		t.Fatal("replayed a run without keys")
+	}
+}
+
+func TestHistoryBrowserScrolls(t *testing.T) {
+	now := time.Now()
+	var runs historyRuns
+	for i := range 60 {
+		runs = append(runs, historyRun(string(rune('A'+i)), "time", 60, "english", 50, int64(i)))
+	}
+	m := openTestHistory(t, runs, now)
+	visible := historyVisibleRows(m.Layout)
+	pressKey(m, tcell.KeyPgDn, now)
+	if m.Browser.Selected != visible || m.Browser.Start != 1 {
+		t.Fatalf("page down: selected %d, start %d", m.Browser.Selected, m.Browser.Start)
+	}
+	pressKey(m, tcell.KeyEnd, now)
+	if m.Browser.Selected != 59 || m.Browser.Start != 60-visible {
+		t.Fatalf("end: selected %d, start %d", m.Browser.Selected, m.Browser.Start)
+	}
+	pressKey(m, tcell.KeyHome, now)
+	if m.Browser.Selected != 0 || m.Browser.Start != 0 {
+		t.Fatalf("home: selected %d, start %d", m.Browser.Selected, m.Browser.Start)
+	}
+}
+
+func TestScrollHelpers(t *testing.T) {
+	start := 0
+	if scrollBy(&start, -1, 10, 4) || start != 0 {
+		t.Fatalf("scrolled above the top to %d", start)
+	}
+	if !scrollBy(&start, 20, 10, 4) || start != 6 {
+		t.Fatalf("scrolled past the end to %d", start)
+	}
+	if scrollBottom(&start, 10, 4) || !scrollTop(&start) || start != 0 {
+		t.Fatalf("top = %d", start)
+	}
+	// a window bigger than the rows never scrolls
+	if scrollBy(&start, 1, 3, 4) || scrollBottom(&start, 3, 4) || scrollTop(&start) {
+		t.Fatal("scrolled a full window")
+	}
+
+	// the review of the results scrolls its lines with them
+	m := NewModel()
+	m.Layout.Recalculate(120, 40, m.Options.Mode, false)
+	if m.ScrollReview(1) {
+		t.Fatal("the review scrolled before the end of the test")
+	}
+	m.Timer.Finished = true
+	lines := len(m.linesForWidth(m.Layout.TextWidth))
+	visible := m.Tuning.VisibleLines
+	if lines <= visible {
+		t.Fatalf("%d lines fit on the screen", lines)
+	}
+	if !m.ReviewBottom() || m.ReviewStart != lines-visible || m.ScrollReview(1) {
+		t.Fatalf("review bottom = %d of %d lines", m.ReviewStart, lines)
+	}
+	if !m.ReviewTop() || m.ScrollReview(-1) || !m.ScrollReview(1) || m.ReviewStart != 1 {
+		t.Fatalf("review start = %d", m.ReviewStart)
+	}
+}
@@ -1,68 +1,114 @@
 package app
 
 import (
-	"strings"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/keymap"
 )
 
-// handle click key and check what is doing
+// handle click key and check what is doing, the help, the command palette
+// and the focused top bar get the keys first in every view. Ctrl+C always
+// quits.
 func (m *Model) HandleKey(event *tcell.EventKey, now time.Time) (bool, bool) {
-	switch event.Key() {
-	case tcell.KeyCtrlC, tcell.KeyEsc:
+	redraw, quit := m.dispatchKey(event, now)
+	return redraw, quit || m.takeQuit()
+}
+
+func (m *Model) dispatchKey(event *tcell.EventKey, now time.Time) (bool, bool) {
+	if event.Key() == tcell.KeyCtrlC {
 		return false, true
-	case tcell.KeyTab:
-		m.Reset()
+	}
+	switch {
+	case m.Help:
+		// any key closes the help
+		m.Help = false
 		return true, false
-	case tcell.KeyCtrlW:
-		if m.Timer.Finished {
-			return false, false
-		}
-		if m.BackspaceWord(now) {
-			return true, false
-		}
-		return false, false
-	case tcell.KeyUp:
-		if m.ScrollReview(-1) {
-			return true, false
-		}
-		return false, false
-	case tcell.KeyDown:
-		if m.ScrollReview(1) {
-			return true, false
-		}
-		return false, false
-	case tcell.KeyPgUp:
-		if m.ScrollReview(-maxVisibleLines) {
-			return true, false
-		}
-		return false, false
-	case tcell.KeyPgDn:
-		if m.ScrollReview(maxVisibleLines) {
-			return true, false
-		}
-		return false, false
-	case tcell.KeyHome:
-		if m.ReviewTop() {
+	case m.Palette.Open:
+		return m.handlePaletteKey(event, now), false
+	case m.Bar.Active:
+		if m.handleBarKey(event, now) {
+			m.syncBarPreview()
 			return true, false
 		}
-		return false, false
-	case tcell.KeyEnd:
-		if m.ReviewBottom() {
+		// any other key leaves the top bar and does what it always does
+		m.Bar = BarFocus{}
+		_, quit := m.handleKey(event, now)
+		return true, quit
+	}
+	// the palette, the top bar and the help open from every view, only esc
+	// is kept for going back from the history and stats screens
+	if name, ok := m.boundAction(event); ok && globalActions[name] && !m.typingText() &&
+		(m.View == ViewTyping || event.Key() != tcell.KeyEsc) {
+		return m.runNamedAction(name, now), false
+	}
+	return m.handleKey(event, now)
+}
+
+// the actions bound keys run from every view
+var globalActions = map[string]bool{"palette": true, "top-bar": true, "help": true}
+
+// the action bound to the key of event, characters only count once the
+// test is over because they are typed while it runs
+func (m *Model) boundAction(event *tcell.EventKey) (string, bool) {
+	chord := keymap.ChordOf(event)
+	name, ok := m.Tuning.Keymap.Action(chord)
+	if !ok || (chord.Plain() && !(m.View == ViewTyping && m.Timer.Finished)) {
+		return "", false
+	}
+	return name, true
+}
+
+// tells if the key of event is bound to the action name, characters never
+// are here because they are typed in the palette
+func (m *Model) boundTo(event *tcell.EventKey, name string) bool {
+	chord := keymap.ChordOf(event)
+	action, ok := m.Tuning.Keymap.Action(chord)
+	return ok && action == name && !chord.Plain()
+}
+
+// a preset name, a custom length or a setting is being typed
+func (m *Model) typingText() bool {
+	return m.NamingPreset || m.EditingCustom || m.Settings.Editing ||
+		m.Editor.Editing || m.Editor.Naming
+}
+
+func (m *Model) handleKey(event *tcell.EventKey, now time.Time) (bool, bool) {
+	switch m.View {
+	case ViewHistory:
+		return m.handleHistoryKey(event, now)
+	case ViewStats:
+		return m.handleStatsKey(event)
+	case ViewSettings:
+		return m.handleSettingsKey(event, now), false
+	case ViewThemeEditor:
+		return m.handleThemeEditorKey(event, now), false
+	}
+	// while a replay is playing typing is ignored and esc stops it
+	if m.Replaying() && !m.Timer.Finished {
+		switch event.Key() {
+		case tcell.KeyEsc, tcell.KeyTab:
+			m.Reset()
 			return true, false
 		}
 		return false, false
+	}
+	// the name of a new preset is being typed in the menu row
+	if m.NamingPreset {
+		return m.handlePresetNameKey(event, now), false
+	}
+	// a custom test length is being typed in the top bar
+	if m.EditingCustom {
+		return m.handleCustomKey(event, now), false
+	}
+	if name, ok := m.boundAction(event); ok {
+		return m.runNamedAction(name, now), false
+	}
+	switch event.Key() {
 	case tcell.KeyBackspace, tcell.KeyBackspace2:
 		if m.Timer.Finished {
 			return false, false
 		}
-		if event.Modifiers()&tcell.ModCtrl != 0 {
-			if m.BackspaceWord(now) {
-				return true, false
-			}
-			return false, false
-		}
 		if m.Backspace(now) {
 			return true, false
 		}
@@ -75,10 +121,6 @@ func (m *Model) HandleKey(event *tcell.EventKey, now time.Time) (bool, bool) {
 		}
 		m.registerKey(r, now)
 		if m.Timer.Finished {
-			if r == 'r' || r == 'R' {
-				m.Reset()
-				return true, false
-			}
 			return false, false
 		}
 		if !m.Timer.Started {
@@ -115,72 +157,23 @@ func (m *Model) HandleClick(x, y int, now time.Time) bool {
 	return false
 }
 
-// check for position for actions and apply them if the click is within a region
-func (m *Model) applyRegion(id string, now time.Time) bool {
-	// so now this how gone work first gone check for x, y if on the regions 
-	// so the regions is just value for position for the buttons so if x,y on 
-	// position for specific region passing the id for this region and then 
-	// apply the action that it's donig 
-	switch {
-	case id == "opt:punct":
-		m.Options.Punctuation = !m.Options.Punctuation
-		m.Reset()
-		return true
-	case id == "opt:numbers":
-		m.Options.Numbers = !m.Options.Numbers
-		m.Reset()
-		return true
-	case strings.HasPrefix(id, "mode:"):
-		switch id {
-		case "mode:time":
-			m.Options.Mode = ModeTime
-			m.Reset()
-			m.Layout.Recalculate(m.Layout.Width, m.Layout.Height,
-				m.Options.Mode, m.focusActive())
-			return true
-		case "mode:words":
-			m.Options.Mode = ModeWords
-			m.Reset()
-			m.Layout.Recalculate(m.Layout.Width, m.Layout.Height,
-				m.Options.Mode, m.focusActive())
-			return true
-		}
+// HandleHover shows the theme under the mouse while the theme menu is
+// open, anywhere else shows the current one again
+func (m *Model) HandleHover(x, y int) bool {
+	if !m.ThemeMenu || m.Bar.Active {
 		return false
-	// change the options for words or time and reset the test
-	case strings.HasPrefix(id, "sel:"):
-		option, ok := selectorByID(id)
-		if !ok {
-			return false
-		}
-		if m.Options.Mode == ModeWords {
-			m.Options.WordCount = option.WordCount
-			m.Reset()
-			return true
-		}
-		m.Options.Duration = option.Duration
-		m.Reset()
-		return true
-	// themes and shit
-	case id == "btn:themes":
-		m.ThemeMenu = !m.ThemeMenu
-		m.Layout.MenuOpen = m.ThemeMenu
-		m.Layout.Recalculate(m.Layout.Width, m.Layout.Height,
-			m.Options.Mode, m.focusActive())
-		return true
-	case strings.HasPrefix(id, "theme:"):
-		// so the theme is just value for the theme that we want to change 
-		// to it so if the click on the region for specific theme we get the 
-		// id for this theme and then set it as current theme
-		themeID, ok := ThemeIDFromRegion(id)
-		if !ok {
-			return false
+	}
+	id := ""
+	for _, region := range m.Layout.MenuRegions {
+		if region.Contains(x, y) {
+			id = region.ID
 		}
-		// so we get the theme id from the region id and then set it as current theme
-		_ = m.SetTheme(themeID)
-		m.ThemeMenu = false
-		m.Layout.MenuOpen = false
-		m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
-		return true
 	}
-	return false
+	return m.previewRegion(id)
+}
+
+// run the action of a clicked region, the region id is the action id (see
+// actions.go)
+func (m *Model) applyRegion(id string, now time.Time) bool {
+	return m.runAction(id, now)
 }
@@ -26,6 +26,15 @@ type Layout struct {
 	TextX       int
 	FooterY     int
 	MenuOpen    bool
+	// the menu row shows the profiles or the presets instead of the themes
+	ProfileMenu bool
+	Profiles    []string
+	PresetMenu  bool
+	Presets     []string
+	// the selector buttons of the tuning and the label of the custom
+	// selector, see customSelectorLabel
+	Selectors   []SelectorOption
+	Custom      string
 	Focus       bool
 	Regions     []Region
 	MenuRegions []Region
@@ -81,7 +90,7 @@ func (l *Layout) Recalculate(width, height int, mode Mode, focus bool) {
 	x := 2
 	// adding options and modes regions
 	add := func(id string) {
-		label := labelForRegion(id, mode)
+		label := l.labelForRegion(id, mode)
 		l.Regions = append(l.Regions, Region{ID: id, X: x, Y: l.TopY, Width: len(label)})
 		x += len(label) + 2
 	}
@@ -99,15 +108,41 @@ func (l *Layout) Recalculate(width, height int, mode Mode, focus bool) {
 	x += 3
 
 	// adding all selectors times
-	for _, id := range selectorOrder {
-		add(id)
+	for _, option := range l.Selectors {
+		add(option.ID)
 	}
+	custom := l.customLabel()
+	l.Regions = append(l.Regions, Region{ID: customSelectorRegion, X: x, Y: l.TopY, Width: len(custom)})
+	x += len(custom) + 2
+	l.Separators = append(l.Separators, x)
+	x += 3
+
+	// adding speed formula and unit
+	add("opt:formula")
+	add("opt:unit")
 	l.Separators = append(l.Separators, x)
 	x += 3
 
+	add("btn:presets")
 	add("btn:themes")
+	add("btn:history")
+	add("btn:stats")
+	add("btn:profile")
 
-	if menuOpen {
+	if menuOpen && l.PresetMenu {
+		x := 2
+		for _, name := range l.Presets {
+			l.MenuRegions = append(l.MenuRegions, Region{ID: presetRegionID(name), X: x, Y: l.MenuY, Width: len(name)})
+			x += len(name) + 2
+		}
+		l.MenuRegions = append(l.MenuRegions, Region{ID: savePresetRegion, X: x, Y: l.MenuY, Width: len("+ save")})
+	} else if menuOpen && l.ProfileMenu {
+		x := 2
+		for _, name := range l.Profiles {
+			l.MenuRegions = append(l.MenuRegions, Region{ID: profileRegionID(name), X: x, Y: l.MenuY, Width: len(name)})
+			x += len(name) + 2
+		}
+	} else if menuOpen {
 		x := 2
 		// adding all themes for menu themes
 		for _, theme := range ThemeOptions() {
@@ -115,7 +150,16 @@ func (l *Layout) Recalculate(width, height int, mode Mode, focus bool) {
 			l.MenuRegions = append(l.MenuRegions, Region{ID: ThemeRegionID(theme.ID), X: x, Y: l.MenuY, Width: len(label)})
 			x += len(label) + 2
 		}
+		l.MenuRegions = append(l.MenuRegions, Region{ID: editThemeRegion, X: x, Y: l.MenuY, Width: len("+ edit")})
+	}
+}
+
+// the custom selector label, "custom" before the model set one
+func (l *Layout) customLabel() string {
+	if l.Custom == "" {
+		return "custom"
 	}
+	return l.Custom
 }
 
 var regionLabels = map[string]string{
@@ -123,7 +167,13 @@ var regionLabels = map[string]string{
 	"opt:numbers": "# numbers",
 	"mode:time":   "time",
 	"mode:words":  "words",
+	"opt:formula": "formula",
+	"opt:unit":    "unit",
 	"btn:themes":  "themes",
+	"btn:history": "history",
+	"btn:stats":   "stats",
+	"btn:profile": "profile",
+	"btn:presets": "presets",
 }
 
 var modeOrder = []string{
@@ -132,8 +182,8 @@ var modeOrder = []string{
 }
 
 // so this is return the selector label based on the id and mode, if the id is not a selector or if the
-func labelForRegion(id string, mode Mode) string {
-	if label, ok := selectorLabel(id, mode); ok {
+func (l *Layout) labelForRegion(id string, mode Mode) string {
+	if label, ok := selectorLabel(l.Selectors, id, mode); ok {
 		return label
 	}
 	if label, ok := regionLabels[id]; ok {
@@ -21,7 +21,7 @@ func (m *Model) linesForWidth(width int) []Line {
 	if m.lineCache.width == width && m.lineCache.version == m.targetVersion {
 		return m.lineCache.lines
 	}
-	lines := buildLines(m.Text.Target, width)
+	lines := buildLines(m.Text.Target, width, m.Tuning.WordsPerLine)
 	m.lineCache.width = width
 	m.lineCache.version = m.targetVersion
 	m.lineCache.lines = lines
@@ -4,6 +4,9 @@ import (
 	"math"
 	"math/rand"
 	"time"
+
+	"github.com/yossefsabry/gotype/internal/storage"
+	"github.com/yossefsabry/gotype/internal/themes"
 )
 
 type Mode int
@@ -14,12 +17,37 @@ const (
 	ModeWords
 )
 
+// the screen shown below the top bar
+type View int
+
+const (
+	ViewTyping View = iota
+	ViewHistory
+	ViewStats
+	ViewSettings
+	ViewThemeEditor
+)
+
 type Options struct {
 	Punctuation bool
 	Numbers     bool
 	Mode        Mode
 	Duration    time.Duration
 	WordCount   int
+	Formula     Formula
+}
+
+// changes over the theme and what the typing screen shows, the colours
+// are #rrggbb and empty keeps the theme's
+type Appearance struct {
+	Accent       string
+	Error        string
+	HideKeyboard bool
+	LiveStats    bool
+	// how mistakes show besides the error colour, see errorMarks, and if
+	// the typed letter is drawn in place of the expected one
+	ErrorMark string
+	ShowTyped bool
 }
 
 type Timer struct {
@@ -32,12 +60,15 @@ type Timer struct {
 }
 
 type Stats struct {
-	Correct   int
-	Incorrect int
-	WPM       int
-	RawWPM    int
-	Accuracy  int
-	Streak    int
+	Correct    int
+	Incorrect  int
+	Keystrokes int
+	WPM        int
+	RawWPM     int
+	CPM        int
+	RawCPM     int
+	Accuracy   int
+	Streak     int
 }
 
 type Text struct {
@@ -59,12 +90,47 @@ type Model struct {
 	Layout            Layout
 	UI                UIState
 	ThemeID           string
+	Appearance        Appearance
+	Background        Background
+	Preview           string
+	Editor            ThemeEditor
 	ThemeMenu         bool
+	Profile           string
+	Profiles          []string
+	ProfileMenu       bool
+	Presets           []storage.Preset
+	Preset            string
+	PresetMenu        bool
+	NamingPreset      bool
+	EditingCustom     bool
+	Palette           PaletteState
+	Bar               BarFocus
+	Help              bool
+	Unit              Unit
 	LastKey           rune
 	LastKeyAt         time.Time
 	Results           ResultsState
 	ReviewStart       int
 	Mistakes          map[rune]int
+	KeyStats          map[rune]KeyStat
+	Seed              int64
+	View              View
+	Browser           HistoryBrowser
+	HistorySource     HistorySource
+	Dashboard         DashboardState
+	Settings          SettingsState
+	StatsSource       StatsSource
+	Replay            *ReplayState
+	Tuning            Tuning
+	seeds             *rand.Rand
+	keyLog            []KeyEvent
+	pendingDeletes    []string
+	pendingProfile    string
+	pendingTheme      *themes.File
+	presetName        []rune
+	presetsChanged    bool
+	customInput       []rune
+	quit              bool
 	history           StatsHistory
 	lineCache         LineCache
 	targetVersion     int
@@ -73,9 +139,14 @@ type Model struct {
 
 // this value for the inital word count
 const (
-	initialWordCount     = 220
-	extendWordCount      = 80
-	keyHighlightDuration = 450 * time.Millisecond
+	initialWordCount = 220
+	extendWordCount  = 80
+)
+
+// the test length of a new model and of the reset settings
+const (
+	defaultDuration  = 60 * time.Second
+	defaultWordCount = 50
 )
 
 // creating the model
@@ -83,18 +154,31 @@ func NewModel() *Model {
 	model := &Model{
 		Options: Options{
 			Mode:      ModeTime,
-			Duration:  60 * time.Second,
-			WordCount: 50,
+			Duration:  defaultDuration,
+			WordCount: defaultWordCount,
 		},
 		Generator: NewGenerator(rand.NewSource(time.Now().UnixNano())),
 		ThemeID:   DefaultThemeID(),
+		seeds:     rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
+	model.SetTuning(defaultTuning())
 	model.Reset()
 	return model
 }
 
-// updating the model to the initial state
+// updating the model to the initial state with a new random text
 func (m *Model) Reset() {
+	if m.seeds == nil {
+		m.seeds = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	m.ResetWithSeed(m.seeds.Int63())
+}
+
+// updating the model to the initial state, the seed decides the text so
+// the same seed and options give the same test again
+func (m *Model) ResetWithSeed(seed int64) {
+	m.Seed = seed
+	m.Generator.Reseed(seed)
 	if m.Options.Mode == ModeWords {
 		m.Text.Target = m.Generator.Build(m.Options.WordCount, m.Options)
 	} else {
@@ -111,11 +195,15 @@ func (m *Model) Reset() {
 	m.ResetResults()
 	m.ResetReview()
 	m.resetMistakes()
+	m.resetKeyStats()
+	m.keyLog = m.keyLog[:0]
+	m.Replay = nil
 	m.history.Reset()
 	m.lastDerivedSecond = -1
 	m.LastKey = 0
 	m.UpdateDerived(time.Now())
 	m.syncLayoutFocus()
+	m.syncCustomSelector()
 }
 
 // when start typing the timer starts
@@ -136,7 +224,7 @@ func (m *Model) StartTimer(now time.Time) {
 
 // update the mode data every second and when needed
 func (m *Model) Update(now time.Time) bool {
-	changed := false
+	changed := m.stepReplay(now)
 	if m.Options.Mode == ModeTime && m.Timer.Started && m.Timer.Running {
 		remaining := m.Timer.End.Sub(now)
 		if remaining <= 0 {
@@ -164,7 +252,7 @@ func (m *Model) Update(now time.Time) bool {
 		m.UI.Message = ""
 		changed = true
 	}
-	if m.LastKey != 0 && now.Sub(m.LastKeyAt) > keyHighlightDuration {
+	if m.LastKey != 0 && now.Sub(m.LastKeyAt) > m.Tuning.KeyHighlight {
 		m.LastKey = 0
 		changed = true
 	}
@@ -180,6 +268,8 @@ func (m *Model) AddRune(r rune, now time.Time) {
 	m.ensureTarget(index + 1)
 	expected := m.Text.Target[index]
 	m.Text.Typed = append(m.Text.Typed, r)
+	m.Stats.Keystrokes++
+	m.logKey(r, now)
 	if r == expected {
 		m.Stats.Correct++
 		m.Stats.Streak++
@@ -188,6 +278,7 @@ func (m *Model) AddRune(r rune, now time.Time) {
 		m.Stats.Streak = 0
 		m.recordMistake(normalizeRune(r))
 	}
+	m.recordKey(normalizeRune(expected), r == expected)
 	if m.Options.Mode == ModeWords && len(m.Text.Typed) >= len(m.Text.Target) {
 		m.Timer.Finished = true
 		m.Timer.Running = false
@@ -203,6 +294,8 @@ func (m *Model) Backspace(now time.Time) bool {
 		return false
 	}
 	index := len(m.Text.Typed) - 1
+	m.Stats.Keystrokes++
+	m.logKey(logBackspace, now)
 	m.removeTypedRange(index, index+1)
 	m.UpdateDerived(now)
 	return true
@@ -222,6 +315,8 @@ func (m *Model) BackspaceWord(now time.Time) bool {
 		start--
 	}
 	if start < end {
+		m.Stats.Keystrokes++
+		m.logKey(logDeleteWord, now)
 		m.removeTypedRange(start, end)
 		m.UpdateDerived(now)
 		return true
@@ -229,28 +324,33 @@ func (m *Model) BackspaceWord(now time.Time) bool {
 	return false
 }
 
-// calc the WPM and accuracy every second and when needed
+// calc the WPM and accuracy every second and when needed, the speed
+// depends on the formula picked in the options (see formula.go)
 func (m *Model) UpdateDerived(now time.Time) bool {
 	newAccuracy := 100
 	if total := m.Stats.Correct + m.Stats.Incorrect; total > 0 {
 		newAccuracy = int(math.Round(float64(m.Stats.Correct) / float64(total) * 100))
 	}
-	newWPM := 0
-	newRawWPM := 0
+	newWPM, newRawWPM, newCPM, newRawCPM := 0, 0, 0, 0
 	if m.Timer.Started {
 		elapsed := m.elapsedForStats(now)
 		m.lastDerivedSecond = int64(elapsed / time.Second)
-		minutes := elapsed.Minutes()
-		newWPM = int(float64(m.Stats.Correct)/5.0/minutes + 0.5)
-		newRawWPM = int(float64(m.Stats.Correct+m.Stats.Incorrect)/5.0/minutes + 0.5)
-		m.history.Record(elapsed, newWPM)
+		net, gross := m.rates(elapsed.Minutes())
+		newWPM = int(net/charsPerWord + 0.5)
+		newRawWPM = int(gross/charsPerWord + 0.5)
+		newCPM = int(net + 0.5)
+		newRawCPM = int(gross + 0.5)
+		m.history.Record(elapsed, newWPM, newRawWPM, m.Stats.Incorrect)
 	} else {
 		m.lastDerivedSecond = -1
 	}
-	changed := newAccuracy != m.Stats.Accuracy || newWPM != m.Stats.WPM || newRawWPM != m.Stats.RawWPM
+	changed := newAccuracy != m.Stats.Accuracy || newWPM != m.Stats.WPM ||
+		newRawWPM != m.Stats.RawWPM || newCPM != m.Stats.CPM || newRawCPM != m.Stats.RawCPM
 	m.Stats.Accuracy = newAccuracy
 	m.Stats.WPM = newWPM
 	m.Stats.RawWPM = newRawWPM
+	m.Stats.CPM = newCPM
+	m.Stats.RawCPM = newRawCPM
 	return changed
 }
 
@@ -329,3 +429,35 @@ func (m *Model) SetTheme(id string) bool {
 	m.ThemeID = id
 	return true
 }
+
+// set the background the terminal reported and return true if it changed
+func (m *Model) SetBackground(background Background) bool {
+	if m.Background == background {
+		return false
+	}
+	m.Background = background
+	return true
+}
+
+// the theme that is drawn, the previewed one while the theme menu is
+// browsed and the light or the dark one for the auto theme
+func (m *Model) themeInUse() string {
+	id := m.ThemeID
+	// the menu is hidden while a test runs, and its preview with it
+	if m.Preview != "" && m.ThemeMenu && !m.focusActive() {
+		id = m.Preview
+	}
+	if id == themes.AutoID {
+		return m.Tuning.autoThemeID(m.Background)
+	}
+	return id
+}
+
+// the colours that are drawn with the colours of the settings over the
+// theme, the theme editor shows the ones it edits as they are
+func (m *Model) drawnTheme() Theme {
+	if m.View == ViewThemeEditor {
+		return m.Editor.Theme
+	}
+	return appearanceTheme(ThemeByID(m.themeInUse()), m.Appearance)
+}
@@ -57,6 +57,37 @@ func (m *Model) recordMistake(r rune) {
 	m.Mistakes[r]++
 }
 
+// hits and misses for one key, counted on the key that was expected
+type KeyStat struct {
+	Hits   int
+	Misses int
+}
+
+// reset the per-key stats for the model
+func (m *Model) resetKeyStats() {
+	if m.KeyStats == nil {
+		m.KeyStats = make(map[rune]KeyStat, 32)
+		return
+	}
+	for key := range m.KeyStats {
+		delete(m.KeyStats, key)
+	}
+}
+
+// record a hit or a miss for the expected key
+func (m *Model) recordKey(r rune, hit bool) {
+	if m.KeyStats == nil {
+		m.KeyStats = make(map[rune]KeyStat, 32)
+	}
+	stat := m.KeyStats[r]
+	if hit {
+		stat.Hits++
+	} else {
+		stat.Misses++
+	}
+	m.KeyStats[r] = stat
+}
+
 // recalculate the current streak of correct characters in the model's stats
 func (m *Model) recalculateStreak() {
 	streak := 0
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// matches the palette shows at once
+const paletteRows = 8
+
+// state of the command palette, Matches are the actions for the query in
+// the order they are shown
+type PaletteState struct {
+	Open     bool
+	Query    []rune
+	Matches  []Action
+	Selected int
+}
+
+// open the command palette with every action
+func (m *Model) openPalette() bool {
+	m.Palette = PaletteState{Open: true}
+	m.filterPalette()
+	return true
+}
+
+func (m *Model) closePalette() bool {
+	m.Palette = PaletteState{}
+	return true
+}
+
+// keys while the palette is open: type to search, up and down pick, enter
+// runs and esc or the palette key closes
+func (m *Model) handlePaletteKey(event *tcell.EventKey, now time.Time) bool {
+	p := &m.Palette
+	if m.boundTo(event, "palette") {
+		return m.closePalette()
+	}
+	switch event.Key() {
+	case tcell.KeyEsc:
+		return m.closePalette()
+	case tcell.KeyEnter:
+		if len(p.Matches) == 0 {
+			return false
+		}
+		action := p.Matches[p.Selected]
+		// closed first so an action can open something else
+		m.closePalette()
+		m.runAction(action.ID, now)
+		return true
+	case tcell.KeyUp, tcell.KeyCtrlK:
+		if p.Selected > 0 {
+			p.Selected--
+		}
+		return true
+	case tcell.KeyDown, tcell.KeyCtrlJ:
+		if p.Selected < len(p.Matches)-1 {
+			p.Selected++
+		}
+		return true
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(p.Query) > 0 {
+			p.Query = p.Query[:len(p.Query)-1]
+			m.filterPalette()
+		}
+		return true
+	case tcell.KeyRune:
+		p.Query = append(p.Query, event.Rune())
+		m.filterPalette()
+		return true
+	}
+	return false
+}
+
+// find the actions for the query, the best matches first and the registry
+// order between equal ones
+func (m *Model) filterPalette() {
+	type match struct {
+		action Action
+		score  int
+	}
+	query := string(m.Palette.Query)
+	var matches []match
+	for _, action := range m.actions() {
+		if score, ok := fuzzyScore(query, action.Title); ok {
+			matches = append(matches, match{action, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	m.Palette.Matches = m.Palette.Matches[:0]
+	for _, match := range matches {
+		m.Palette.Matches = append(m.Palette.Matches, match.action)
+	}
+	m.Palette.Selected = 0
+}
+
+// fuzzyScore matches the letters of the query in order anywhere in text,
+// ignoring case. Letters in a row and at the start of a word score higher,
+// shorter titles win ties.
+func fuzzyScore(query, text string) (int, bool) {
+	// spaces only separate words for the reader, any gap matches them
+	q := []rune(strings.ToLower(strings.ReplaceAll(query, " ", "")))
+	t := []rune(strings.ToLower(text))
+	score, next, last := 0, 0, -2
+	for i := 0; i < len(t) && next < len(q); i++ {
+		if t[i] != q[next] {
+			continue
+		}
+		score += 10
+		if i == last+1 {
+			score += 15
+		}
+		if i == 0 || t[i-1] == ' ' {
+			score += 20
+		}
+		last = i
+		next++
+	}
+	if next < len(q) {
+		return 0, false
+	}
+	return score - len(t), true
+}
+
+// renders the command palette over the screen under the stats line, the query
+// on the first line and the matches below it
+func (r *Renderer) drawPalette(model *Model, width, height int) {
+	p := model.Palette
+	if !p.Open {
+		return
+	}
+	boxWidth := min(60, width-4)
+	x := (width - boxWidth) / 2
+	y := model.Layout.StatsY + 2
+	if boxWidth <= 0 || y+paletteRows+1 >= height {
+		return
+	}
+	for row := 0; row <= paletteRows; row++ {
+		for col := 0; col < boxWidth; col++ {
+			r.setContent(x+col, y+row, ' ', r.styles.Panel)
+		}
+	}
+	r.drawClipped(x+1, y, boxWidth-2, "> "+string(p.Query)+"_", r.panelStyle(r.styles.Accent))
+	if len(p.Matches) == 0 {
+		r.drawClipped(x+3, y+1, boxWidth-4, "no matching command", r.panelStyle(r.styles.Dim))
+		return
+	}
+	// scroll so the selected match stays in view
+	start := max(p.Selected-paletteRows+1, 0)
+	for row, action := range p.Matches[start:min(start+paletteRows, len(p.Matches))] {
+		style := r.panelStyle(r.styles.Dim)
+		if start+row == p.Selected {
+			style = r.panelStyle(r.styles.Accent).Reverse(true)
+		}
+		r.drawClipped(x+3, y+1+row, boxWidth-4, action.Title, style)
+	}
+}
@@ -0,0 +1,99 @@
+package app
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func pressKey(m *Model, key tcell.Key, now time.Time) {
+	m.HandleKey(tcell.NewEventKey(key, 0, tcell.ModNone), now)
+}
+
+func TestEveryRegionHasAnAction(t *testing.T) {
+	m := NewModel()
+	m.Layout.Recalculate(200, 40, m.Options.Mode, false)
+	m.toggleThemeMenu()
+	regions := slices.Concat(m.Layout.Regions, m.Layout.MenuRegions)
+	m.togglePresetMenu()
+	regions = append(regions, m.Layout.MenuRegions...)
+	for _, region := range regions {
+		if _, ok := findAction(region.ID); !ok {
+			t.Errorf("no action for region %s", region.ID)
+		}
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	for _, tc := range []struct {
+		query, best, other string
+	}{
+		{"punc", "toggle punctuation", "toggle numbers"},
+		{"nt", "next theme", "toggle punctuation"},
+		{"words", "words mode", "length 10 words"},
+	} {
+		best, ok := fuzzyScore(tc.query, tc.best)
+		other, _ := fuzzyScore(tc.query, tc.other)
+		if !ok || best <= other {
+			t.Errorf("%q: %q scored %d, %q %d", tc.query, tc.best, best, tc.other, other)
+		}
+	}
+	if _, ok := fuzzyScore("xyz", "toggle numbers"); ok {
+		t.Error("letters that aren't there matched")
+	}
+}
+
+func TestPaletteRunsAction(t *testing.T) {
+	now := time.Now()
+	m := NewModel()
+	m.Layout.Recalculate(160, 40, m.Options.Mode, false)
+
+	pressKey(m, tcell.KeyCtrlP, now)
+	for _, r := range "words mode" {
+		m.HandleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), now)
+	}
+	if len(m.Palette.Matches) == 0 || m.Palette.Matches[0].ID != "mode:words" {
+		t.Fatalf("matches = %+v", m.Palette.Matches)
+	}
+	pressKey(m, tcell.KeyEnter, now)
+	if m.Palette.Open || m.Options.Mode != ModeWords || m.Timer.Started {
+		t.Fatalf("palette open %v, mode %v", m.Palette.Open, m.Options.Mode)
+	}
+}
+
+func TestTopBarKeyboardFocus(t *testing.T) {
+	now := time.Now()
+	m := NewModel()
+	m.Layout.Recalculate(160, 40, m.Options.Mode, false)
+
+	pressKey(m, tcell.KeyCtrlT, now)
+	pressKey(m, tcell.KeyRight, now)
+	pressKey(m, tcell.KeyEnter, now)
+	if !m.Options.Numbers || !m.BarFocused(false, 1) {
+		t.Fatalf("numbers %v, focus %+v", m.Options.Numbers, m.Bar)
+	}
+
+	// the themes menu takes the focus and gives it back once a theme is picked
+	for m.Layout.Regions[m.Bar.Index].ID != "btn:themes" {
+		pressKey(m, tcell.KeyRight, now)
+	}
+	themes := m.Bar.Index
+	theme := m.ThemeID
+	pressKey(m, tcell.KeyEnter, now)
+	if !m.Layout.MenuOpen || !m.BarFocused(true, 0) {
+		t.Fatalf("menu open %v, focus %+v", m.Layout.MenuOpen, m.Bar)
+	}
+	pressKey(m, tcell.KeyRight, now)
+	pressKey(m, tcell.KeyEnter, now)
+	if m.ThemeID == theme || m.Layout.MenuOpen || !m.BarFocused(false, themes) {
+		t.Fatalf("theme %s, focus %+v", m.ThemeID, m.Bar)
+	}
+
+	// typing leaves the bar and starts the test
+	m.HandleKey(tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone), now)
+	if m.Bar.Active || !m.Timer.Started {
+		t.Fatal("typing didn't leave the top bar")
+	}
+}
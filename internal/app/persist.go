@@ -1,27 +1,66 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/yossefsabry/gotype/internal/config"
 	"github.com/yossefsabry/gotype/internal/storage"
 )
 
 // Persister handles saving data to disk in a non-blocking way
-// saving data like best scores and preferences without blocking the main thread
+// saving data like best scores and preferences without blocking the main thread,
+// finished runs are appended to the history from the same goroutine.
+// Failed writes are kept and retried with a growing delay, every failure is
+// sent on Errors so the ui can show it. The same goroutine polls the backend
+// for saves from other gotype windows and sends the state it finds on States.
 type Persister struct {
-	path string
-	ch   chan storage.Data
-	done chan struct{}
+	backend  storage.Backend
+	ch       chan storage.Data
+	runs     chan storage.Run
+	deletes  chan string
+	errs     chan error
+	states   chan storage.Data
+	watches  chan time.Duration
+	done     chan struct{}
+	finished chan struct{}
+
+	// work that failed and waits for the next retry, only used by the loop
+	pendingData    *storage.Data
+	pendingRuns    []storage.Run
+	pendingDeletes []string
+	backoff        time.Duration
+	failing        bool
+
+	mu        sync.Mutex
+	lastErr   error
+	lastSaved time.Time
 }
 
-// NewPersister creates a new Persister with the given file path and 
-// starts the background loop
-func NewPersister(path string) *Persister {
+// delays between retries of a failed write
+const (
+	minRetryDelay = 500 * time.Millisecond
+	maxRetryDelay = 30 * time.Second
+)
+
+// NewPersister creates a new Persister writing to the backend and starts
+// the background loop, the backend is closed with the persister
+func NewPersister(backend storage.Backend) *Persister {
 	p := &Persister{
-		path: path,
-		ch:   make(chan storage.Data, 1),
-		done: make(chan struct{}),
+		backend:  backend,
+		ch:       make(chan storage.Data, 1),
+		runs:     make(chan storage.Run, 16),
+		deletes:  make(chan string, 16),
+		errs:     make(chan error, 8),
+		states:   make(chan storage.Data, 1),
+		watches:  make(chan time.Duration, 1),
+		done:     make(chan struct{}),
+		finished: make(chan struct{}),
+		backoff:  minRetryDelay,
 	}
 	go p.loop()
 	return p
@@ -29,27 +68,153 @@ func NewPersister(path string) *Persister {
 
 // loop runs in the background and listens for data to save or a signal to stop
 func (p *Persister) loop() {
+	defer close(p.finished)
+	var retry, watch <-chan time.Time
+	var interval time.Duration
 	for {
 		select {
+		case interval = <-p.watches:
+			watch = watchAfter(interval)
+			continue
+		case <-watch:
+			p.refresh()
+			watch = watchAfter(interval)
+			continue
 		case data := <-p.ch:
-			_ = storage.Save(p.path, data)
+			p.pendingData = &data
+		case run := <-p.runs:
+			p.pendingRuns = append(p.pendingRuns, run)
+		case id := <-p.deletes:
+			p.pendingDeletes = append(p.pendingDeletes, id)
+		case <-retry:
+			retry = nil
 		case <-p.done:
-			for {
-				select {
-				case data := <-p.ch:
-					// save on close to ensure we don't lose any pending data
-					_ = storage.Save(p.path, data)
-				default:
-					return
-				}
+			// take everything still queued and write it one last time
+			p.drain()
+			err := p.flush()
+			if closeErr := p.backend.Close(); err == nil {
+				err = closeErr
 			}
+			p.setStatus(err)
+			return
+		}
+		// while failing only the retry timer writes, so a broken disk isn't
+		// hammered on every key press
+		if p.failing && retry != nil {
+			continue
+		}
+		if err := p.flush(); err != nil {
+			p.failing = true
+			p.report(err)
+			retry = time.After(p.backoff)
+			p.backoff = min(p.backoff*2, maxRetryDelay)
+			continue
+		}
+		if p.failing {
+			// tell the ui the earlier error is gone
+			p.report(nil)
+		} else {
+			p.setStatus(nil)
+		}
+		p.failing = false
+		p.backoff = minRetryDelay
+		retry = nil
+	}
+}
+
+// the next poll for saves of other windows, never with a zero interval
+func watchAfter(interval time.Duration) <-chan time.Time {
+	if interval <= 0 {
+		return nil
+	}
+	return time.After(interval)
+}
+
+// refresh sends the stored state when another window saved since the last
+// look. Only the latest state matters so an unread one is replaced.
+func (p *Persister) refresh() {
+	changed, err := p.backend.Refresh()
+	if err != nil || !changed {
+		return
+	}
+	data, err := p.backend.Load()
+	if err != nil {
+		return
+	}
+	select {
+	case <-p.states:
+	default:
+	}
+	p.states <- data
+}
+
+// move everything still queued into the pending work
+func (p *Persister) drain() {
+	for {
+		select {
+		case data := <-p.ch:
+			p.pendingData = &data
+		case run := <-p.runs:
+			p.pendingRuns = append(p.pendingRuns, run)
+		case id := <-p.deletes:
+			p.pendingDeletes = append(p.pendingDeletes, id)
+		default:
+			return
+		}
+	}
+}
+
+// flush writes all pending work in order and keeps whatever failed
+func (p *Persister) flush() error {
+	if p.pendingData != nil {
+		if _, err := p.backend.Save(*p.pendingData); err != nil {
+			return fmt.Errorf("save state: %w", err)
 		}
+		p.pendingData = nil
+	}
+	for len(p.pendingRuns) > 0 {
+		if _, err := p.backend.Append(p.pendingRuns[0]); err != nil {
+			return fmt.Errorf("save run: %w", err)
+		}
+		p.pendingRuns = p.pendingRuns[1:]
+	}
+	for len(p.pendingDeletes) > 0 {
+		// a run that is already gone is not worth retrying
+		err := p.backend.Delete(p.pendingDeletes[0])
+		if err != nil && !errors.Is(err, storage.ErrRunNotFound) {
+			return fmt.Errorf("delete run: %w", err)
+		}
+		p.pendingDeletes = p.pendingDeletes[1:]
+	}
+	return nil
+}
+
+// record the result of a write and send it to the ui, nil means writes
+// work again after a failure. The ui only needs the latest one so a full
+// channel just drops it.
+func (p *Persister) report(err error) {
+	p.setStatus(err)
+	select {
+	case p.errs <- err:
+	default:
+	}
+}
+
+func (p *Persister) setStatus(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastErr = err
+	if err == nil {
+		p.lastSaved = time.Now()
 	}
 }
 
 // Save sends data to be saved in the background,
 // if the channel is full it will drop the oldest data
 func (p *Persister) Save(data storage.Data) {
+	// the ui keeps changing its map while this copy waits to be written
+	data.BestScores = maps.Clone(data.BestScores)
+	data.Presets = slices.Clone(data.Presets)
 	select {
 	case p.ch <- data:
 	default:
@@ -61,26 +226,135 @@ func (p *Persister) Save(data storage.Data) {
 	}
 }
 
-// close signals the background loop to stop and ensures any pending data is saved
-func (p *Persister) Close() {
+// AppendRun queues a finished run for the history, runs are never dropped
+func (p *Persister) AppendRun(run storage.Run) {
+	p.runs <- run
+}
+
+// DeleteRun queues a run to be removed from the history
+func (p *Persister) DeleteRun(id string) {
+	p.deletes <- id
+}
+
+// Errors delivers write failures, and nil once writing works again
+func (p *Persister) Errors() <-chan error {
+	return p.errs
+}
+
+// Watch sets how often the backend is polled for saves from other gotype
+// windows, zero stops polling
+func (p *Persister) Watch(interval time.Duration) {
+	select {
+	case <-p.watches:
+	default:
+	}
+	p.watches <- interval
+}
+
+// States delivers the stored state after another window saved
+func (p *Persister) States() <-chan storage.Data {
+	return p.states
+}
+
+// LastError is the error of the latest write, nil when it worked
+func (p *Persister) LastError() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastErr
+}
+
+// LastSaved is when the latest write worked, zero if none did yet
+func (p *Persister) LastSaved() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lastSaved
+}
+
+// Close stops the background loop, waits until everything pending was
+// written and returns the error of that final write
+func (p *Persister) Close() error {
 	close(p.done)
+	<-p.finished
+	return p.LastError()
+}
+
+// persisted is what openStorage found in the profile directory, backend
+// is nil when there is no config directory and nothing is saved
+type persisted struct {
+	profile string
+	dir     string
+	backend storage.Backend
+	data    storage.Data
+	warning string
+}
+
+// openStorage opens the named backend of a profile and loads the
+// preferences and best scores. With the json backend a corrupted state file is repaired
+// and the warning tells the user what happened. A state from a newer
+// version or one that can't be read is an error, the app must not start
+// and overwrite it.
+func openStorage(profile, name string) (persisted, error) {
+	if err := storage.ValidProfileName(profile); err != nil {
+		return persisted{}, err
+	}
+	dir, err := storage.ProfileDir(profile)
+	if err != nil {
+		return persisted{profile: profile, data: storage.Data{BestScores: map[string]storage.BestScore{}}}, nil
+	}
+	path := storage.StatePath(dir)
+	stored := persisted{profile: profile, dir: dir}
+	if name == "json" {
+		now := time.Now()
+		_, recovery, err := storage.LoadOrRecover(path, now)
+		if err != nil {
+			return persisted{}, fmt.Errorf("%s: %w", path, err)
+		}
+		if recovery != nil {
+			stored.warning = recovery.Summary()
+		} else {
+			// keep a copy of every good state we start from, a failed
+			// backup must not stop the app
+			_ = storage.Backup(path, now)
+		}
+	}
+	return loadStorage(stored, name)
 }
 
-// loadPersistedData loads the persisted data from disk and returns the 
-// file path and data
-func loadPersistedData() (string, storage.Data) {
-	path, err := storage.DefaultPath()
+// the backend of the profile directory with what it holds
+func loadStorage(stored persisted, name string) (persisted, error) {
+	backend, err := storage.Open(name, stored.dir)
 	if err != nil {
-		return "", storage.Data{}
+		return persisted{}, err
 	}
-	data, err := storage.Load(path)
+	data, err := backend.Load()
 	if err != nil {
-		return path, storage.Data{}
+		backend.Close()
+		return persisted{}, fmt.Errorf("%s storage in %s: %w", name, stored.dir, err)
 	}
 	if data.BestScores == nil {
 		data.BestScores = map[string]storage.BestScore{}
 	}
-	return path, data
+	stored.backend = backend
+	stored.data = data
+	return stored, nil
+}
+
+// openCommandStorage opens the storage for the commands, they have nothing
+// to do without it. Unlike openStorage it only reads: a corrupted state is
+// an error and repairing it is left to the app and `gotype doctor --repair`.
+func openCommandStorage(cfg config.Config) (persisted, error) {
+	if err := storage.ValidProfileName(cfg.Profile); err != nil {
+		return persisted{}, err
+	}
+	dir, err := storage.ProfileDir(cfg.Profile)
+	if err != nil {
+		return persisted{}, errors.New("no config directory for the history")
+	}
+	stored, err := loadStorage(persisted{profile: cfg.Profile, dir: dir}, cfg.Backend)
+	if errors.Is(err, storage.ErrCorrupt) {
+		return persisted{}, fmt.Errorf("%w, run `gotype doctor --repair` to fix it", err)
+	}
+	return stored, err
 }
 
 // saving perferences from the model to the storage format
@@ -92,6 +366,14 @@ func preferencesFromModel(model *Model) storage.Preferences {
 		WordCount:       model.Options.WordCount,
 		Punctuation:     model.Options.Punctuation,
 		Numbers:         model.Options.Numbers,
+		Formula:         formulaToString(model.Options.Formula),
+		Unit:            unitToString(model.Unit),
+		AccentColor:     model.Appearance.Accent,
+		ErrorColor:      model.Appearance.Error,
+		HideKeyboard:    model.Appearance.HideKeyboard,
+		LiveStats:       model.Appearance.LiveStats,
+		ErrorMark:       model.Appearance.ErrorMark,
+		ShowTyped:       model.Appearance.ShowTyped,
 	}
 }
 
@@ -104,17 +386,17 @@ func applyPreferences(model *Model, prefs storage.Preferences) bool {
 		model.Options.Mode = mode
 		changed = true
 	}
-	// only apply duration if it's greater than 0 to avoid overriding defaults 
-	// with invalid values
-	if prefs.DurationSeconds > 0 {
+	// only apply a valid duration to avoid overriding defaults with invalid
+	// values, custom lengths go through the same check
+	if storage.ValidDuration(prefs.DurationSeconds) == nil {
 		duration := time.Duration(prefs.DurationSeconds) * time.Second
 		if model.Options.Duration != duration {
 			model.Options.Duration = duration
 			changed = true
 		}
 	}
-	// count is applied if it's only greater then 0 so (when user type)
-	if prefs.WordCount > 0 {
+	// count is applied if it's only valid so (when user type)
+	if storage.ValidWordCount(prefs.WordCount) == nil {
 		if model.Options.WordCount != prefs.WordCount {
 			model.Options.WordCount = prefs.WordCount
 			changed = true
@@ -128,35 +410,55 @@ func applyPreferences(model *Model, prefs storage.Preferences) bool {
 		model.Options.Numbers = prefs.Numbers
 		changed = true
 	}
+	// formula and unit don't change the text so no reset is needed
+	model.Options.Formula = formulaFromString(prefs.Formula)
+	model.Unit = unitFromString(prefs.Unit)
 	if prefs.ThemeID != "" {
 		theme := ThemeByID(prefs.ThemeID)
 		if theme.ID != "" && model.ThemeID != theme.ID {
 			model.ThemeID = theme.ID
 		}
 	}
+	// like the theme these only change how things are drawn, a broken
+	// colour falls back to the theme's
+	appearance := Appearance{
+		HideKeyboard: prefs.HideKeyboard,
+		LiveStats:    prefs.LiveStats,
+		ShowTyped:    prefs.ShowTyped,
+	}
+	if slices.Contains(errorMarks, prefs.ErrorMark) {
+		appearance.ErrorMark = prefs.ErrorMark
+	}
+	if storage.ValidColor(prefs.AccentColor) == nil {
+		appearance.Accent = prefs.AccentColor
+	}
+	if storage.ValidColor(prefs.ErrorColor) == nil {
+		appearance.Error = prefs.ErrorColor
+	}
+	model.Appearance = appearance
 	return changed
 }
 
 // generte a uniqe key for best score based on options, options -> for 
-//  different modes
+//  different modes, the formula is part of the key so scores measured in
+//  different ways never get compared
 func scoreKey(options Options) string {
 	if options.Mode == ModeWords {
-		return fmt.Sprintf("words:%d|punct=%t|numbers=%t", options.WordCount,
-			options.Punctuation, options.Numbers)
+		return fmt.Sprintf("words:%d|punct=%t|numbers=%t|formula=%s", options.WordCount,
+			options.Punctuation, options.Numbers, formulaToString(options.Formula))
 	}
-	return fmt.Sprintf("time:%ds|punct=%t|numbers=%t",
+	return fmt.Sprintf("time:%ds|punct=%t|numbers=%t|formula=%s",
 		int(options.Duration.Seconds()), options.Punctuation,
-		options.Numbers)
+		options.Numbers, formulaToString(options.Formula))
 }
 
 // udpate the best score in the data if new stats are better than the current
 // stats
-func updateBestScore(data *storage.Data, options Options, stats Stats, now time.Time) bool {
+func updateBestScore(data *storage.Data, key string, options Options, stats Stats, now time.Time) bool {
 	// no score yet
 	if data.BestScores == nil {
 		data.BestScores = map[string]storage.BestScore{}
 	}
-	key := scoreKey(options)
 	current, ok := data.BestScores[key]
 	// compare between the new stats and old stats that is store
 	if ok {
@@ -172,7 +474,9 @@ func updateBestScore(data *storage.Data, options Options, stats Stats, now time.
 	// update too the new score
 	data.BestScores[key] = storage.BestScore{
 		WPM:       stats.WPM,
+		CPM:       stats.CPM,
 		Accuracy:  stats.Accuracy,
+		Formula:   formulaToString(options.Formula),
 		Timestamp: now.Unix(),
 	}
 	return true
@@ -193,8 +497,6 @@ func modeFromString(value string) Mode {
 	switch value {
 	case "words":
 		return ModeWords
-	case "zen":
-		return ModeTime
 	default:
 		return ModeTime
 	}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// memory backend whose saves fail while broken is set
+type brokenBackend struct {
+	*storage.MemoryBackend
+	broken atomic.Bool
+}
+
+func (b *brokenBackend) Save(data storage.Data) (storage.Data, error) {
+	if b.broken.Load() {
+		return storage.Data{}, errors.New("disk full")
+	}
+	return b.MemoryBackend.Save(data)
+}
+
+func TestPersisterRetriesFailedSave(t *testing.T) {
+	backend := &brokenBackend{MemoryBackend: storage.NewMemory()}
+	backend.broken.Store(true)
+	persister := NewPersister(backend)
+	persister.Save(storage.Data{Preferences: storage.Preferences{Mode: "words"}})
+
+	select {
+	case err := <-persister.Errors():
+		if err == nil {
+			t.Fatal("expected a save error")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("save error was not reported")
+	}
+	if persister.LastError() == nil || !persister.LastSaved().IsZero() {
+		t.Fatal("status does not show the failure")
+	}
+
+	// the disk works again, the retry writes the pending data
+	backend.broken.Store(false)
+	select {
+	case err := <-persister.Errors():
+		if err != nil {
+			t.Fatalf("retry failed: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("retry did not happen")
+	}
+	if err := persister.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := backend.Load()
+	if err != nil || data.Preferences.Mode != "words" {
+		t.Fatalf("pending data not saved: %+v %v", data, err)
+	}
+}
+
+func TestPersisterCloseFlushes(t *testing.T) {
+	backend := storage.NewMemory()
+	persister := NewPersister(backend)
+	for i := 0; i < 20; i++ {
+		persister.Save(storage.Data{Preferences: storage.Preferences{WordCount: i}})
+	}
+	persister.AppendRun(storage.Run{Summary: storage.Summary{ID: "1"}})
+	if err := persister.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Close returned, so the last data has to be written already
+	data, err := backend.Load()
+	if err != nil || data.Preferences.WordCount != 19 || backend.Len() != 1 {
+		t.Fatalf("last save missing: %+v %v", data.Preferences, err)
+	}
+	if persister.LastSaved().IsZero() {
+		t.Fatal("last saved time not set")
+	}
+}
+
+// memory backend that reports a save of another window on every refresh
+type changingBackend struct {
+	*storage.MemoryBackend
+}
+
+func (b changingBackend) Refresh() (bool, error) {
+	return true, nil
+}
+
+func TestPersisterWatchesOtherWindows(t *testing.T) {
+	backend := changingBackend{storage.NewMemory()}
+	if _, err := backend.Save(storage.Data{BestScores: map[string]storage.BestScore{"a": {WPM: 90}}}); err != nil {
+		t.Fatal(err)
+	}
+	persister := NewPersister(backend)
+	defer persister.Close()
+	persister.Watch(10 * time.Millisecond)
+	select {
+	case data := <-persister.States():
+		if data.BestScores["a"].WPM != 90 {
+			t.Fatalf("state = %+v", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the saved state was not sent")
+	}
+}
+
+func TestMergeStateAppliesNewerPreferences(t *testing.T) {
+	a := &App{model: NewModel()}
+	a.model.Layout.Recalculate(120, 40, a.model.Options.Mode, false)
+	a.attach(persisted{profile: storage.DefaultProfile, data: storage.Data{PreferencesAt: 10}})
+	disk := storage.Data{
+		Preferences:   preferencesFromModel(a.model),
+		Presets:       []storage.Preset{{Name: "sprint"}},
+		PreferencesAt: 20,
+		BestScores:    map[string]storage.BestScore{"a": {WPM: 90}},
+	}
+	disk.Preferences.Mode = "words"
+	disk.Preferences.WordCount = 25
+
+	// a started test keeps its options until it is over
+	a.model.Timer.Started = true
+	a.mergeState(disk)
+	if a.model.Options.Mode != ModeTime || a.data.BestScores["a"].WPM != 90 {
+		t.Fatalf("mode %v during a test, scores %+v", a.model.Options.Mode, a.data.BestScores)
+	}
+	a.model.Reset()
+	a.syncPersistence(time.Now())
+	if a.model.Options.Mode != ModeWords || a.model.Options.WordCount != 25 ||
+		len(a.model.Presets) != 1 || a.prefs != preferencesFromModel(a.model) {
+		t.Fatalf("options %+v, presets %+v", a.model.Options, a.model.Presets)
+	}
+
+	// older preferences are not applied
+	older := disk
+	older.Preferences.Mode = "time"
+	older.Presets = nil
+	older.PreferencesAt = 5
+	a.mergeState(older)
+	if a.model.Options.Mode != ModeWords || len(a.model.Presets) != 1 {
+		t.Fatalf("older state applied: %+v", a.model.Options)
+	}
+}
@@ -0,0 +1,196 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// region id of the menu entry that saves the current setup, + can't be in
+// a preset name
+const savePresetRegion = "preset:+"
+
+// region id of a preset in the presets menu
+func presetRegionID(name string) string {
+	return "preset:" + name
+}
+
+// preset name from a region id
+func presetFromRegion(id string) (string, bool) {
+	name, ok := strings.CutPrefix(id, "preset:")
+	return name, ok
+}
+
+// label of a preset menu entry, the save entry shows the name being typed
+func presetMenuLabel(model *Model, id string) string {
+	if id != savePresetRegion {
+		name, _ := presetFromRegion(id)
+		return name
+	}
+	if model.NamingPreset {
+		return "name: " + string(model.presetName) + "_"
+	}
+	return "+ save"
+}
+
+// find a preset by name
+func findPreset(presets []storage.Preset, name string) (storage.Preset, bool) {
+	for _, preset := range presets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return storage.Preset{}, false
+}
+
+// the score key of a preset's runs, they get their own best scores
+func presetScoreKey(name string, options Options) string {
+	return "preset=" + name + "|" + scoreKey(options)
+}
+
+// short description of a preset like "time 30s punctuation standard"
+func presetSummary(preset storage.Preset) string {
+	run := preset.Options
+	parts := []string{run.Mode, runAmountLabel(storage.Summary{Options: run})}
+	if run.Punctuation {
+		parts = append(parts, "punctuation")
+	}
+	if run.Numbers {
+		parts = append(parts, "numbers")
+	}
+	parts = append(parts, run.Formula)
+	if preset.ThemeID != "" {
+		parts = append(parts, "theme "+preset.ThemeID)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ActivePreset is the preset the current test runs, empty once the
+// options no longer match the one that was picked
+func (m *Model) ActivePreset() string {
+	if m.Preset == "" {
+		return ""
+	}
+	preset, ok := findPreset(m.Presets, m.Preset)
+	if !ok || preset.Options != runOptionsFromOptions(m.Options) {
+		return ""
+	}
+	return m.Preset
+}
+
+// ScoreKey is the key the best score of the current test is saved under
+func (m *Model) ScoreKey() string {
+	if name := m.ActivePreset(); name != "" {
+		return presetScoreKey(name, m.Options)
+	}
+	return scoreKey(m.Options)
+}
+
+// open or close the presets menu, it shares the menu row with the themes
+func (m *Model) togglePresetMenu() bool {
+	open := !m.PresetMenu
+	m.closeMenus()
+	m.PresetMenu = open
+	m.Layout.MenuOpen = open
+	m.Layout.PresetMenu = open
+	m.Layout.Presets = m.presetNames()
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	return true
+}
+
+func (m *Model) presetNames() []string {
+	names := make([]string, len(m.Presets))
+	for i, preset := range m.Presets {
+		names[i] = preset.Name
+	}
+	return names
+}
+
+// switch to the options and theme of a preset and start a new test
+func (m *Model) applyPreset(name string, now time.Time) bool {
+	preset, ok := findPreset(m.Presets, name)
+	if !ok {
+		m.SetMessage("no preset "+name, now, m.Tuning.MessageTime)
+		return true
+	}
+	m.closeMenus()
+	m.Options = optionsFromRun(preset.Options)
+	if preset.ThemeID != "" {
+		m.SetTheme(ThemeByID(preset.ThemeID).ID)
+	}
+	m.Preset = name
+	m.Reset()
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	m.SetMessage("preset: "+presetSummary(preset), now, m.Tuning.MessageTime)
+	return true
+}
+
+// start typing the name of a new preset in the menu row
+func (m *Model) startNamingPreset() bool {
+	m.NamingPreset = true
+	m.presetName = m.presetName[:0]
+	return true
+}
+
+// keys while the preset name is typed: enter saves, esc cancels
+func (m *Model) handlePresetNameKey(event *tcell.EventKey, now time.Time) bool {
+	switch event.Key() {
+	case tcell.KeyEsc:
+		m.NamingPreset = false
+		return true
+	case tcell.KeyEnter:
+		return m.savePreset(string(m.presetName), now)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(m.presetName) > 0 {
+			m.presetName = m.presetName[:len(m.presetName)-1]
+		}
+		return true
+	case tcell.KeyRune:
+		// only what a preset name may hold, and a space types a dash
+		r := event.Rune()
+		if r == ' ' {
+			r = '-'
+		}
+		if storage.ValidPresetName(string(r)) == nil && len(m.presetName) < 32 {
+			m.presetName = append(m.presetName, r)
+		}
+		return true
+	}
+	return false
+}
+
+// save the current options and theme under name, replacing a preset of
+// the same name
+func (m *Model) savePreset(name string, now time.Time) bool {
+	if err := storage.ValidPresetName(name); err != nil {
+		m.SetMessage(err.Error(), now, m.Tuning.WarningTime)
+		return true
+	}
+	preset := storage.Preset{Name: name, Options: runOptionsFromOptions(m.Options), ThemeID: m.ThemeID}
+	presets := slices.Clone(m.Presets)
+	index := slices.IndexFunc(presets, func(p storage.Preset) bool { return p.Name == name })
+	if index >= 0 {
+		presets[index] = preset
+	} else {
+		presets = append(presets, preset)
+	}
+	m.Presets = presets
+	m.Preset = name
+	m.presetsChanged = true
+	m.NamingPreset = false
+	m.Layout.Presets = m.presetNames()
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	m.SetMessage(fmt.Sprintf("saved preset %s: %s", name, presetSummary(preset)), now, m.Tuning.MessageTime)
+	return true
+}
+
+// TakePresets returns the presets when the user changed them
+func (m *Model) TakePresets() ([]storage.Preset, bool) {
+	changed := m.presetsChanged
+	m.presetsChanged = false
+	return m.Presets, changed
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func typePresetName(m *Model, name string, now time.Time) {
+	for _, r := range name {
+		m.HandleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), now)
+	}
+	m.HandleKey(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone), now)
+}
+
+func TestSaveAndApplyPreset(t *testing.T) {
+	now := time.Now()
+	m := NewModel()
+	m.Layout.Recalculate(160, 40, m.Options.Mode, false)
+	m.Options = Options{Mode: ModeTime, Duration: 10 * time.Minute, Numbers: true}
+	m.SetTheme("forest")
+
+	m.applyRegion("btn:presets", now)
+	m.applyRegion(savePresetRegion, now)
+	// typing the name doesn't start the test
+	typePresetName(m, "exam 1", now)
+	if m.Timer.Started || len(m.Presets) != 1 || m.Presets[0].Name != "exam-1" {
+		t.Fatalf("presets = %+v, started %v", m.Presets, m.Timer.Started)
+	}
+	presets, changed := m.TakePresets()
+	if !changed || len(presets) != 1 {
+		t.Fatal("saved preset not handed to the app")
+	}
+	if key := m.ScoreKey(); !strings.HasPrefix(key, "preset=exam-1|time:600s|") {
+		t.Fatalf("score key = %q", key)
+	}
+
+	// changing an option leaves the preset and its best scores
+	m.applyRegion("opt:punct", now)
+	if m.ActivePreset() != "" || strings.HasPrefix(m.ScoreKey(), "preset=") {
+		t.Fatalf("preset still active: %q", m.ScoreKey())
+	}
+	m.SetTheme("dark")
+	m.applyRegion("btn:presets", now)
+	m.applyRegion(presetRegionID("exam-1"), now)
+	if m.ActivePreset() != "exam-1" || m.Options.Punctuation || m.ThemeID != "forest" {
+		t.Fatalf("preset not applied: %+v %s", m.Options, m.ThemeID)
+	}
+	if m.PresetMenu || m.Layout.MenuOpen {
+		t.Fatal("menu still open")
+	}
+}
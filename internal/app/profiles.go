@@ -0,0 +1,61 @@
+package app
+
+import (
+	"strings"
+	"time"
+)
+
+// region id of a profile in the profile menu
+func profileRegionID(name string) string {
+	return "profile:" + name
+}
+
+// profile name from a region id
+func profileFromRegion(id string) (string, bool) {
+	name, ok := strings.CutPrefix(id, "profile:")
+	return name, ok
+}
+
+// open or close the profile menu, it shares the menu row with the themes
+func (m *Model) toggleProfileMenu() bool {
+	open := !m.ProfileMenu
+	m.closeMenus()
+	m.ProfileMenu = open
+	m.Layout.MenuOpen = open
+	m.Layout.Profiles = m.Profiles
+	m.Layout.ProfileMenu = open
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	return true
+}
+
+// close whichever menu is open
+func (m *Model) closeMenus() {
+	m.ThemeMenu = false
+	m.Preview = ""
+	m.ProfileMenu = false
+	m.PresetMenu = false
+	m.NamingPreset = false
+	m.Layout.MenuOpen = false
+	m.Layout.ProfileMenu = false
+	m.Layout.PresetMenu = false
+}
+
+// ask the app to switch to another profile, the storage is swapped by the
+// app the next time it syncs
+func (m *Model) selectProfile(name string, now time.Time) bool {
+	m.closeMenus()
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	if name == m.Profile {
+		return true
+	}
+	m.pendingProfile = name
+	m.SetMessage("switching to profile "+name, now, m.Tuning.MessageTime)
+	return true
+}
+
+// TakeProfileSwitch returns the profile the user picked, empty if none
+func (m *Model) TakeProfileSwitch() string {
+	name := m.pendingProfile
+	m.pendingProfile = ""
+	return name
+}
@@ -6,7 +6,11 @@ import "github.com/gdamore/tcell/v2"
 type Renderer struct {
 	screen     tcell.Screen
 	styles     Styles
-	themeID    string
+	theme      Theme
+	appearance Appearance
+	colors     int
+	view       View
+	overlay    bool
 	forceClear bool
 	lastWidth  int
 	lastHeight int
@@ -16,10 +20,13 @@ func NewRenderer(screen tcell.Screen) *Renderer {
 	// get default theme
 	defaultTheme := DefaultThemeID()
 	// passing the style for detault style too render structure
+	// the colours of the config are picked up on the first draw
+	colors := colorCount(screen, "auto")
 	return &Renderer{
 		screen:  screen,
-		styles:  NewStyles(ThemeByID(defaultTheme)),
-		themeID: defaultTheme,
+		styles: NewStyles(ThemeByID(defaultTheme), colors),
+		theme:  ThemeByID(defaultTheme),
+		colors: colors,
 	}
 }
 
@@ -28,6 +35,16 @@ func (r *Renderer) Render(model *Model) {
 	r.syncTheme(model)
 
 	width, height := r.screen.Size()
+	// switching screens leaves old content around so redraw everything
+	if model.View != r.view {
+		r.forceClear = true
+		r.view = model.View
+	}
+	// a closed palette or help leaves its box behind too
+	if overlay := model.Palette.Open || model.Help; overlay != r.overlay {
+		r.forceClear = true
+		r.overlay = overlay
+	}
 	// if the size of the terminal has changed since the last render, 
 	// 	we need to clear the screen to avoid render shits
 	if width != r.lastWidth || height != r.lastHeight {
@@ -50,7 +67,26 @@ func (r *Renderer) Render(model *Model) {
 	if !focus {
 		r.fillLine(0, width, r.styles.Base)
 		r.drawTopBar(model, width)
-		r.drawThemeMenu(model, width)
+		r.drawMenu(model, width)
+	}
+
+	// the history, stats, settings and theme editor screens replace everything between the top bar and the footer
+	if model.View != ViewTyping {
+		switch model.View {
+		case ViewStats:
+			r.drawDashboard(model, width, height)
+		case ViewSettings:
+			r.drawSettings(model, width, height)
+		case ViewThemeEditor:
+			r.drawThemeEditor(model, width, height)
+		default:
+			r.drawHistory(model, width, height)
+		}
+		r.drawFooter(model, width, height)
+		r.drawPalette(model, width, height)
+		r.drawHelp(model, width, height)
+		r.screen.Show()
+		return
 	}
 
 	// if the timer is active then we need to render the stats, text, keyboard and results
@@ -60,6 +96,8 @@ func (r *Renderer) Render(model *Model) {
 	r.drawKeyboard(model, width, height, keyboardStartY)
 	r.drawResults(model, width, height, keyboardStartY)
 	r.drawFooter(model, width, height)
+	r.drawPalette(model, width, height)
+	r.drawHelp(model, width, height)
 
 	r.screen.Show()
 }
@@ -67,12 +105,20 @@ func (r *Renderer) Render(model *Model) {
 func (r *Renderer) syncTheme(model *Model) {
 
 	if model.ThemeID == "" { return }
-	if r.themeID == model.ThemeID { return }
-
-	// get the id for the theme and update the renderer
-	r.themeID = model.ThemeID
+	// the colour mode of the config can change while running, and the
+	// terminal background the auto theme follows. The colours are compared
+	// so the edits of the theme editor show.
+	colors := colorCount(r.screen, model.Tuning.Colors)
+	theme := model.drawnTheme()
+	if r.theme == theme && r.appearance == model.Appearance && r.colors == colors { return }
+
+	// get the theme and update the renderer
+	r.theme = theme
+	r.appearance = model.Appearance
+	r.colors = colors
 	// get the sytles for theme
-	r.styles = NewStyles(ThemeByID(model.ThemeID)) 
+	r.styles = NewStyles(theme, colors)
+	r.styles.Mistake = markMistake(r.styles.Mistake, model.Appearance.ErrorMark)
 
 	// force clear the screen to apply new theme
 	r.forceClear = true
@@ -0,0 +1,51 @@
+package app
+
+import "fmt"
+
+// eighth blocks used for drawing the charts, index 0 is empty
+var chartBlocks = []rune{' ', '▁', '▂', '▃', '▄', '▅', '▆', '▇', '█'}
+
+// draws values as a bar chart with the peak and the floor printed on the
+// left, the values are spread or squeezed to fill the width and marked
+// values are drawn in the error colour
+func (r *Renderer) drawBars(x, y, width, height int, values []int, floor int, marks []bool) {
+	if len(values) == 0 || width <= 0 || height <= 0 {
+		return
+	}
+	peak := floor
+	for _, value := range values {
+		peak = max(peak, value)
+	}
+	if peak <= floor {
+		return
+	}
+	label := fmt.Sprintf("%d", peak)
+	bottom := fmt.Sprintf("%*d", len(label), floor)
+	chartX := x + len(label) + 1
+	chartWidth := width - len(label) - 1
+	if chartWidth <= 0 {
+		return
+	}
+	r.drawString(x, y, label, r.styles.Dim)
+	r.drawString(x, y+height-1, bottom, r.styles.Dim)
+	columns := min(chartWidth, len(values))
+	for col := 0; col < columns; col++ {
+		index := col * len(values) / columns
+		// value in eighths of a cell
+		level := (values[index] - floor) * height * 8 / (peak - floor)
+		style := r.styles.Accent
+		if index < len(marks) && marks[index] {
+			style = r.styles.Error
+		}
+		for row := 0; row < height; row++ {
+			fill := level - row*8
+			if fill <= 0 {
+				break
+			}
+			if fill > 8 {
+				fill = 8
+			}
+			r.setContent(chartX+col, y+height-1-row, chartBlocks[fill], style)
+		}
+	}
+}
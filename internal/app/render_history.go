@@ -0,0 +1,121 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// renders the history screen, a table of past runs with the details and
+// the per-second chart of the selected run under it
+func (r *Renderer) drawHistory(model *Model, width, height int) {
+	layout := model.Layout
+	b := &model.Browser
+	for y := layout.StatsY; y < layout.FooterY; y++ {
+		r.fillLine(y, width, r.styles.Base)
+	}
+	x := layout.TextX
+	tableWidth := layout.TextWidth
+
+	// filters and sort order
+	status := fmt.Sprintf("runs: %d  mode: %s  amount: %s  list: %s  sort: %s %s",
+		len(b.Rows), filterLabel(b.Mode), filterLabel(b.Amount), filterLabel(b.Language),
+		historySortLabel(b.Sort), historyOrderLabel(b.Ascending))
+	r.drawClipped(x, layout.StatsY, tableWidth, status, r.styles.Dim)
+
+	rateLabel, rawLabel := rateLabels(FormulaStandard, model.Unit)
+	header := fmt.Sprintf("%-16s  %-5s  %6s  %4s  %4s  %4s  %4s  %s",
+		"date", "mode", "amount", rateLabel, rawLabel, "acc", "cons", "formula")
+	r.drawClipped(x, layout.StatsY+1, tableWidth, header, r.styles.Accent)
+
+	if len(b.Rows) == 0 {
+		r.drawClipped(x, layout.StatsY+2, tableWidth, "no runs yet, finish a test to see it here", r.styles.Dim)
+		return
+	}
+	visible := historyVisibleRows(layout)
+	end := b.Start + visible
+	if end > len(b.Rows) {
+		end = len(b.Rows)
+	}
+	for i := b.Start; i < end; i++ {
+		run := b.Runs[b.Rows[i]]
+		metrics := run.Metrics
+		line := fmt.Sprintf("%-16s  %-5s  %6s  %4d  %4d  %3d%%  %4d  %s",
+			formatRunTime(run.StartedAt), run.Options.Mode, runAmountLabel(run),
+			unitValue(model.Unit, metrics.WPM, metrics.CPM),
+			unitValue(model.Unit, metrics.RawWPM, metrics.RawCPM),
+			metrics.Accuracy, metrics.Consistency, run.Options.Formula)
+		style := r.styles.Dim
+		if i == b.Selected {
+			style = r.styles.Cursor
+			line += strings.Repeat(" ", max(tableWidth-len(line), 0))
+		}
+		r.drawClipped(x, layout.StatsY+2+i-b.Start, tableWidth, line, style)
+	}
+
+	detailY := historyDetailY(layout)
+	if !b.HasDetail || detailY <= layout.StatsY+2 {
+		return
+	}
+	detail := b.Detail
+	flags := []string{}
+	if detail.Options.Punctuation {
+		flags = append(flags, "punctuation")
+	}
+	if detail.Options.Numbers {
+		flags = append(flags, "numbers")
+	}
+	info := fmt.Sprintf("%s %s  %s  seed: %d  keys: %d",
+		detail.Options.Mode, runAmountLabel(detail.Summary), strings.Join(flags, " "),
+		detail.Seed, detail.Metrics.Keystrokes)
+	r.drawClipped(x, detailY, tableWidth, info, r.styles.Dim)
+	r.drawChart(x, detailY+1, tableWidth, historyChartHeight, detail.Samples)
+}
+
+// draws the wpm of every sample, seconds with new errors are marked
+func (r *Renderer) drawChart(x, y, width, height int, samples []storage.Sample) {
+	values := make([]int, len(samples))
+	marks := make([]bool, len(samples))
+	for i, sample := range samples {
+		values[i] = sample.WPM
+		marks[i] = i > 0 && sample.Errors > samples[i-1].Errors
+	}
+	r.drawBars(x, y, width, height, values, 0, marks)
+}
+
+// draw a string but never past the given width
+func (r *Renderer) drawClipped(x, y, width int, text string, style tcell.Style) {
+	runes := []rune(text)
+	if len(runes) > width {
+		runes = runes[:max(width, 0)]
+	}
+	r.drawString(x, y, string(runes), style)
+}
+
+// label for a filter value, empty means no filter
+func filterLabel(value string) string {
+	if value == "" {
+		return "all"
+	}
+	return value
+}
+
+func historySortLabel(column HistorySort) string {
+	switch column {
+	case SortWPM:
+		return "speed"
+	case SortAccuracy:
+		return "accuracy"
+	default:
+		return "date"
+	}
+}
+
+func historyOrderLabel(ascending bool) string {
+	if ascending {
+		return "asc"
+	}
+	return "desc"
+}
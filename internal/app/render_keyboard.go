@@ -20,7 +20,7 @@ const (
 // with a gap defined by keyboardFooterGap. If the available space is insufficient
 // to display the keyboard, it will be hidden.
 func (r *Renderer) drawKeyboard(model *Model, width, height, keyboardStartY int) {
-	if len(keyboardRows) == 0 || width <= 0 || height <= 0 {
+	if len(keyboardRows) == 0 || width <= 0 || height <= 0 || model.Appearance.HideKeyboard {
 		return
 	}
 	startY := keyboardStartY
@@ -186,7 +186,8 @@ func (r *Renderer) drawKey(x, y int, key Key, width int, style tcell.Style) {
 // based on the model's layout and the available height.
 func (r *Renderer) keyboardStartY(model *Model, height int) int {
 	keyboardHeight := keyboardHeight()
-	if keyboardHeight == 0 {
+	// a hidden keyboard leaves the room to the text
+	if keyboardHeight == 0 || model.Appearance.HideKeyboard {
 		return model.Layout.FooterY
 	}
 	gap := keyboardFooterGap
@@ -20,29 +20,47 @@ func (r *Renderer) drawTopBar(model *Model, width int) {
 
 	// draw the labels for the options and mode selectors based on the 
 	// regions defined in the layout
-	for _, region := range model.Layout.Regions {
-		label := labelForRegion(region.ID, model.Options.Mode)
+	for i, region := range model.Layout.Regions {
+		label := model.Layout.labelForRegion(region.ID, model.Options.Mode)
+		if region.ID == customSelectorRegion {
+			label = model.Layout.customLabel()
+		}
 		style := r.styleForRegion(model, region.ID)
+		if model.BarFocused(false, i) {
+			style = style.Reverse(true)
+		}
 		r.drawString(region.X, region.Y, label, r.panelStyle(style))
 	}
 }
 
-// renders the theme menu when the themes button is active, 
+// renders the theme or profile menu when its button is active, 
 // otherwise it clears the menu area
-func (r *Renderer) drawThemeMenu(model *Model, width int) {
+func (r *Renderer) drawMenu(model *Model, width int) {
 	if !model.Layout.MenuOpen {
 		r.fillLine(model.Layout.TopY+1, width, r.styles.Base)
 		return
 	}
 	y := model.Layout.MenuY
 	r.fillLine(y, width, r.styles.Panel)
-	for _, region := range model.Layout.MenuRegions {
-		themeID, ok := ThemeIDFromRegion(region.ID)
+	for i, region := range model.Layout.MenuRegions {
+		label, ok := profileFromRegion(region.ID)
+		if _, preset := presetFromRegion(region.ID); preset {
+			label, ok = presetMenuLabel(model, region.ID), true
+		}
+		if region.ID == editThemeRegion {
+			label, ok = "+ edit", true
+		}
 		if !ok {
-			continue
+			themeID, ok := ThemeIDFromRegion(region.ID)
+			if !ok {
+				continue
+			}
+			label = ThemeLabel(themeID)
 		}
-		label := ThemeLabel(themeID)
 		style := r.styleForRegion(model, region.ID)
+		if model.BarFocused(true, i) {
+			style = style.Reverse(true)
+		}
 		r.drawString(region.X, region.Y, label, r.panelStyle(style))
 	}
 }
@@ -61,10 +79,13 @@ func (r *Renderer) drawStats(model *Model, width int) {
 		status = "time: " + formatDuration(model.Timer.Remaining)
 	}
 	chars := len(model.Text.Typed)
+	formula := formulaToString(model.Options.Formula)
+	rateLabel, _ := rateLabels(model.Options.Formula, model.Unit)
+	rate := unitValue(model.Unit, model.Stats.WPM, model.Stats.CPM)
 	// if the timer is finished then we show the finished status instead of the time/words left
-	stats := fmt.Sprintf("%s  wpm: %d  acc: %d%%  ch: %d  streak: %d  %s", label, model.Stats.WPM, model.Stats.Accuracy, chars, model.Stats.Streak, status)
+	stats := fmt.Sprintf("%s  %s  %s: %d  acc: %d%%  ch: %d  streak: %d  %s", label, formula, rateLabel, rate, model.Stats.Accuracy, chars, model.Stats.Streak, status)
 	if model.Timer.Finished {
-		stats = fmt.Sprintf("finished  wpm: %d  acc: %d%%  ch: %d", model.Stats.WPM, model.Stats.Accuracy, chars)
+		stats = fmt.Sprintf("finished  %s: %d  acc: %d%%  ch: %d", rateLabel, rate, model.Stats.Accuracy, chars)
 	}
 	r.fillLine(model.Layout.StatsY, width, r.styles.Base)
 	x := (width - len(stats)) / 2
@@ -75,7 +96,8 @@ func (r *Renderer) drawStats(model *Model, width int) {
 }
 
 // focus status is the status line that is shown when the timer is active,
-// it shows the time/words left in a more prominent way
+// it shows the time/words left in a more prominent way and the live speed
+// and accuracy when the settings ask for them
 func (r *Renderer) drawFocusStatus(model *Model, width int) {
 	prefix := "time left: "
 	value := formatDuration(model.Timer.Remaining)
@@ -83,7 +105,14 @@ func (r *Renderer) drawFocusStatus(model *Model, width int) {
 		prefix = "words left: "
 		value = fmt.Sprintf("%d", model.WordsLeft())
 	}
-	lineLen := len(prefix) + len(value)
+	// the live stats follow the value in the dim style
+	live := ""
+	if model.Appearance.LiveStats {
+		rateLabel, _ := rateLabels(model.Options.Formula, model.Unit)
+		live = fmt.Sprintf("  %s: %d  acc: %d%%", rateLabel,
+			unitValue(model.Unit, model.Stats.WPM, model.Stats.CPM), model.Stats.Accuracy)
+	}
+	lineLen := len(prefix) + len(value) + len(live)
 	x := (width - lineLen) / 2
 	if x < 0 {
 		x = 0
@@ -92,14 +121,48 @@ func (r *Renderer) drawFocusStatus(model *Model, width int) {
 	// print the prefix in dim style and the value in accent style to make it more prominent
 	r.drawString(x, model.Layout.StatsY, prefix, r.styles.Dim)
 	r.drawString(x+len(prefix), model.Layout.StatsY, value, r.styles.Accent)
+	r.drawString(x+len(prefix)+len(value), model.Layout.StatsY, live, r.styles.Dim)
 }
 
 // renders the footer with the instructions for the user, 
 // it changes based on the timer state and any messages set in the model
 func (r *Renderer) drawFooter(model *Model, width, height int) {
-	message := " type to start <tab> reset  <ctrl+w> del word  <esc> quit "
+	// the hints follow the keymap in use
+	keys := model.Tuning.Keymap
+	message := " type to start " + keyHints(keys, "restart", "reset", "delete-word", "del word", "palette", "commands",
+		"history", "history", "stats", "stats", "help", "keys", "quit", "quit") + " "
 	if model.Timer.Finished {
-		message = " finished <tab> restart  <ctrl+w> del word  <esc> quit  up/down review "
+		message = " finished " + keyHints(keys, "restart", "restart", "quit", "quit", "review-down", "review", "help", "keys") + " "
+	}
+	if model.Replaying() && !model.Timer.Finished {
+		message = " replaying  <esc> stop "
+	}
+	if model.View == ViewHistory {
+		message = " <enter> retry  p replay  x delete  s sort  o order  m mode  a amount  l list  <esc> back "
+	}
+	if model.View == ViewStats {
+		message = " up/down scroll  f formula  <esc> back "
+	}
+	if model.View == ViewSettings {
+		message = " up/down select  left/right change  <enter> edit  d default  <esc> back "
+		if model.Settings.Editing {
+			message = " <enter> apply  <esc> cancel "
+		}
+	}
+	if model.View == ViewThemeEditor {
+		message = " up/down pick  left/right change  tab h s l  <enter> hex  d undo  s save  <esc> back "
+		switch {
+		case model.Editor.Editing:
+			message = " <enter> apply  <esc> cancel "
+		case model.Editor.Naming:
+			message = " save as " + string(model.Editor.Input) + "_  <enter> save  <esc> cancel "
+		}
+	}
+	if model.Bar.Active {
+		message = " left/right move  up/down menu  <enter> select  <esc> back "
+	}
+	if model.Palette.Open {
+		message = " type to search  up/down pick  <enter> run  <esc> close "
 	}
 	if model.UI.Message != "" {
 		message = model.UI.Message
@@ -110,6 +173,15 @@ func (r *Renderer) drawFooter(model *Model, width, height int) {
 		x = 0
 	}
 	r.drawString(x, model.Layout.FooterY, message, r.styles.Dim)
+
+	// the active profile in the right corner, when it doesn't cover the text
+	if model.Profile != "" {
+		label := "profile: " + model.Profile + " "
+		px := width - len(label)
+		if px > x+len(message) {
+			r.drawString(px, model.Layout.FooterY, label, r.styles.Dim)
+		}
+	}
 }
 
 // check the region id and return the appropriate style based on the model
@@ -131,6 +203,37 @@ func (r *Renderer) styleForRegion(model *Model, id string) tcell.Style {
 			return r.styles.Accent
 		}
 		return r.styles.Dim
+	// lit while a length is typed or a custom one is used
+	case customSelectorRegion:
+		if _, custom := model.customLength(); custom || model.EditingCustom {
+			return r.styles.Accent
+		}
+		return r.styles.Dim
+	case "btn:history":
+		if model.View == ViewHistory {
+			return r.styles.Accent
+		}
+		return r.styles.Dim
+	case "btn:stats":
+		if model.View == ViewStats {
+			return r.styles.Accent
+		}
+		return r.styles.Dim
+	case "btn:profile":
+		if model.ProfileMenu {
+			return r.styles.Accent
+		}
+		return r.styles.Dim
+	case "btn:presets":
+		if model.PresetMenu {
+			return r.styles.Accent
+		}
+		return r.styles.Dim
+	case savePresetRegion:
+		if model.NamingPreset {
+			return r.styles.Accent
+		}
+		return r.styles.Dim
 	case "mode:time":
 		if model.Options.Mode == ModeTime {
 			return r.styles.Accent
@@ -142,14 +245,31 @@ func (r *Renderer) styleForRegion(model *Model, id string) tcell.Style {
 		}
 		return r.styles.Dim
 	default:
+		if name, ok := profileFromRegion(id); ok {
+			if name == model.Profile {
+				return r.styles.Accent
+			}
+			return r.styles.Dim
+		}
+		if name, ok := presetFromRegion(id); ok {
+			if name == model.ActivePreset() {
+				return r.styles.Accent
+			}
+			return r.styles.Dim
+		}
 		if strings.HasPrefix(id, "theme:") {
 			themeID, ok := ThemeIDFromRegion(id)
-			if ok && model.ThemeID == themeID {
+			// the previewed theme is lit, the picked one while there is none
+			current := model.ThemeID
+			if model.Preview != "" {
+				current = model.Preview
+			}
+			if ok && current == themeID {
 				return r.styles.Accent
 			}
 			return r.styles.Dim
 		}
-		if option, ok := selectorByID(id); ok {
+		if option, ok := selectorByID(model.Tuning.Selectors, id); ok {
 			if model.Options.Mode == ModeWords {
 				if model.Options.WordCount == option.WordCount {
 					return r.styles.Accent
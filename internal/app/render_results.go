@@ -9,7 +9,7 @@ func (r *Renderer) drawResults(model *Model, width, height, keyboardStartY int)
 		return
 	}
 	keyboardBottom := -1
-	if keyboardStartY < height {
+	if keyboardStartY < height && !model.Appearance.HideKeyboard {
 		if kbdHeight := keyboardHeight(); kbdHeight > 0 {
 			keyboardBottom = keyboardStartY + kbdHeight - 1
 		}
@@ -22,9 +22,12 @@ func (r *Renderer) drawResults(model *Model, width, height, keyboardStartY int)
 	if !model.Results.Visible || !model.Timer.Finished {
 		return
 	}
-	prefix := "final  net: "
-	netValue := fmt.Sprintf("%d", model.Results.NetWPM)
-	rest := fmt.Sprintf("  raw: %d  acc: %d%%  cons: %d", model.Results.RawWPM, model.Results.Accuracy, model.Results.Consistency)
+	netLabel, rawLabel := rateLabels(model.Options.Formula, model.Unit)
+	prefix := fmt.Sprintf("final  %s: ", netLabel)
+	netValue := fmt.Sprintf("%d", unitValue(model.Unit, model.Results.NetWPM, model.Results.NetCPM))
+	rest := fmt.Sprintf("  %s: %d  acc: %d%%  cons: %d  (%s)", rawLabel,
+		unitValue(model.Unit, model.Results.RawWPM, model.Results.RawCPM),
+		model.Results.Accuracy, model.Results.Consistency, formulaToString(model.Options.Formula))
 	lineLen := len(prefix) + len(netValue) + len(rest)
 	startX := (width - lineLen) / 2
 	if startX < 0 {
@@ -40,7 +43,8 @@ func (r *Renderer) drawResults(model *Model, width, height, keyboardStartY int)
 	r.drawString(startX+len(prefix), resultsTop, netValue, netStyle)
 	r.drawString(startX+len(prefix)+len(netValue), resultsTop, rest, r.styles.Dim)
 
-	bestLine := fmt.Sprintf("best   wpm: %d  acc: %d%%", model.Results.BestWPM, model.Results.BestAccuracy)
+	bestLine := fmt.Sprintf("best   %s: %d  acc: %d%%", netLabel,
+		unitValue(model.Unit, model.Results.BestWPM, model.Results.BestCPM), model.Results.BestAccuracy)
 	indicator := ""
 	indicatorStyle := r.styles.Dim
 	newBest := ""
@@ -0,0 +1,82 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// width of the label column of the settings screen
+const settingsLabelWidth = 26
+
+// renders the settings screen, the rows grouped under their titles and a
+// line of text in the current colours below them
+func (r *Renderer) drawSettings(model *Model, width, height int) {
+	layout := model.Layout
+	state := model.Settings
+	for y := layout.StatsY; y < layout.FooterY; y++ {
+		r.fillLine(y, width, r.styles.Base)
+	}
+	x := layout.TextX
+	areaWidth := layout.TextWidth
+
+	lines := settingsLines()
+	visible := settingsVisibleRows(layout)
+	start := min(state.Start, scrollMax(len(lines), visible))
+	end := min(start+visible, len(lines))
+	for i := start; i < end; i++ {
+		y := layout.StatsY + i - start
+		line := lines[i]
+		if line.index < 0 {
+			r.drawClipped(x, y, areaWidth, line.title, r.styles.Accent)
+			continue
+		}
+		row := settings[line.index]
+		selected := line.index == state.Selected
+		value := row.value(model)
+		switch {
+		case selected && state.Editing:
+			value = string(state.Input) + "_"
+		case selected && row.kind != settingInfo && row.kind != settingAction:
+			value = "< " + value + " >"
+		}
+		text := fmt.Sprintf("  %-*s %s", settingsLabelWidth, row.label, value)
+		style := r.styles.Dim
+		if selected {
+			style = r.styles.Correct.Reverse(true)
+		}
+		r.drawClipped(x, y, areaWidth, text, style)
+		if row.swatch != nil && len(text)+3 <= areaWidth {
+			color, _, _ := row.swatch(r.styles).Decompose()
+			swatch := tcell.StyleDefault.Background(color)
+			r.drawString(x+len(text)+1, y, "  ", swatch)
+		}
+	}
+
+	// the colours show on a sample right away
+	previewY := layout.StatsY + visible + 1
+	if previewY >= layout.FooterY {
+		return
+	}
+	r.drawSample(x, previewY, areaWidth)
+}
+
+// a line of typed, mistyped and untyped text with the cursor in the
+// current colours
+func (r *Renderer) drawSample(x, y, width int) {
+	px := x
+	for _, part := range []struct {
+		text  string
+		style tcell.Style
+	}{
+		{"preview  ", r.styles.Dim},
+		{"the quick ", r.styles.Correct},
+		{"brwon", r.styles.Mistake},
+		{" ", r.styles.Correct},
+		{"f", r.styles.Cursor},
+		{"ox jumps", r.styles.Dim},
+	} {
+		r.drawClipped(px, y, width-(px-x), part.text, part.style)
+		px += len(part.text)
+	}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/yossefsabry/gotype/internal/analytics"
+)
+
+// how many days the daily time chart goes back
+const statsChartDays = 30
+
+// renders the stats screen, the rolling numbers and the weakest keys on
+// top, the score keys in the middle and the weekly and daily charts at the
+// bottom
+func (r *Renderer) drawDashboard(model *Model, width, height int) {
+	layout := model.Layout
+	report := model.Dashboard.Report
+	for y := layout.StatsY; y < layout.FooterY; y++ {
+		r.fillLine(y, width, r.styles.Base)
+	}
+	x := layout.TextX
+	areaWidth := layout.TextWidth
+
+	summary := fmt.Sprintf("formula: %s  tests: %d  time typed: %s  per week: %+.1f wpm",
+		formulaToString(model.Dashboard.Formula), report.Runs,
+		analytics.FormatSeconds(report.TotalSeconds), report.WeeklyGain)
+	r.drawClipped(x, layout.StatsY, areaWidth, summary, r.styles.Dim)
+	if report.Runs == 0 {
+		r.drawClipped(x, layout.StatsY+2, areaWidth, "no runs for this formula yet", r.styles.Dim)
+		return
+	}
+	rolling := fmt.Sprintf("last 10: %.1f wpm %.1f%%  last 100: %.1f wpm %.1f%%",
+		report.Rolling10, report.Accuracy10, report.Rolling100, report.Accuracy100)
+	r.drawClipped(x, layout.StatsY+1, areaWidth, rolling, r.styles.Accent)
+	if len(report.Letters) > 0 {
+		r.drawClipped(x, layout.StatsY+2, areaWidth, weakestLetters(report.Letters), r.styles.Dim)
+	}
+
+	header := fmt.Sprintf("%-48s  %4s  %4s  %6s  %5s", "score key", "runs", "best", "median", "p90")
+	r.drawClipped(x, layout.StatsY+3, areaWidth, header, r.styles.Accent)
+	visible := statsVisibleRows(layout)
+	start := model.Dashboard.Start
+	end := min(start+visible, len(report.Keys))
+	for i := start; i < end; i++ {
+		key := report.Keys[i]
+		line := fmt.Sprintf("%-48s  %4d  %4d  %6.1f  %5.1f", scoreKeyLabel(key.Key), key.Runs, key.Best, key.Median, key.P90)
+		r.drawClipped(x, layout.StatsY+4+i-start, areaWidth, line, r.styles.Dim)
+	}
+
+	titleY := statsChartTitleY(layout)
+	if titleY <= layout.StatsY+4 {
+		return
+	}
+	chartWidth := (areaWidth - 4) / 3
+	weeklyWPM := make([]int, len(report.Weeks))
+	weeklyAccuracy := make([]int, len(report.Weeks))
+	lowest := 100
+	for i, week := range report.Weeks {
+		weeklyWPM[i] = int(math.Round(week.WPM))
+		weeklyAccuracy[i] = int(math.Round(week.Accuracy))
+		lowest = min(lowest, weeklyAccuracy[i])
+	}
+	days := report.Days
+	if len(days) > statsChartDays {
+		days = days[len(days)-statsChartDays:]
+	}
+	dailyMinutes := make([]int, len(days))
+	for i, day := range days {
+		dailyMinutes[i] = int((day.Seconds + 59) / 60)
+	}
+	charts := []struct {
+		title  string
+		values []int
+		floor  int
+	}{
+		{"wpm per week", weeklyWPM, 0},
+		{"accuracy per week", weeklyAccuracy, max(lowest-1, 0)},
+		{"minutes per day", dailyMinutes, 0},
+	}
+	for i, chart := range charts {
+		chartX := x + i*(chartWidth+2)
+		r.drawClipped(chartX, titleY, chartWidth, chart.title, r.styles.Dim)
+		r.drawBars(chartX, titleY+1, chartWidth, statsChartHeight, chart.values, chart.floor, nil)
+	}
+}
+
+// how many of the least accurate keys the stats screen lists
+const statsLetters = 10
+
+// the least accurate keys over every run, on the line above the score keys
+func weakestLetters(letters []analytics.Letter) string {
+	var line strings.Builder
+	line.WriteString("weakest keys:")
+	for _, letter := range letters[:min(len(letters), statsLetters)] {
+		fmt.Fprintf(&line, "  %s %.0f%%", letter.Label(), letter.Accuracy)
+	}
+	return line.String()
+}
+
+// the report is already for one formula so it is left out of the key
+func scoreKeyLabel(key string) string {
+	if index := strings.Index(key, "|formula="); index >= 0 {
+		key = key[:index]
+	}
+	return strings.ReplaceAll(key, "|", " ")
+}
@@ -2,8 +2,6 @@ package app
 
 import "github.com/gdamore/tcell/v2"
 
-const usePlainText = true
-
 // textScale is the size of each character in terminal cells. The default is 2,
 // which means each character is rendered as a 2x2 block of cells. Setting it to 1
 // will render characters as single cells, which may be less visually appealing 
@@ -14,7 +12,7 @@ func (r *Renderer) drawText(model *Model, width, height, keyboardStartY int) {
 	}
 	scale := textScale
 	textWidth := model.Layout.TextWidth / textScale
-	if usePlainText {
+	if model.Tuning.PlainText {
 		scale = 1
 		textWidth = model.Layout.TextWidth
 	}
@@ -33,7 +31,7 @@ func (r *Renderer) drawText(model *Model, width, height, keyboardStartY int) {
 		areaBottom = areaTop
 	}
 	availableHeight := areaBottom - areaTop + 1
-	maxLines := maxVisibleLines
+	maxLines := model.Tuning.VisibleLines
 	textBlockHeight := (maxLines-1)*lineSpacing + lineHeight
 	if availableHeight < textBlockHeight {
 		maxLines = availableHeight / lineHeight
@@ -47,7 +45,7 @@ func (r *Renderer) drawText(model *Model, width, height, keyboardStartY int) {
 		textStartY = areaTop + (availableHeight-textBlockHeight)/2
 	}
 	cursorIndex := len(model.Text.Typed)
-	startLine := defaultStartLine(lines, cursorIndex)
+	startLine := defaultStartLine(lines, cursorIndex, model.Tuning.VisibleLines)
 	if !model.Timer.Finished {
 		maxStart := len(lines) - maxLines
 		if maxStart < 0 {
@@ -105,8 +103,12 @@ func (r *Renderer) drawLine(model *Model, line Line, x, y, scale int) {
 			if typed == target {
 				style = r.styles.Correct
 			} else {
-				style = r.styles.Error
-				if target == ' ' {
+				style = r.styles.Mistake
+				// the letter that was typed shows in place of the expected one
+				if model.Appearance.ShowTyped {
+					renderCh = typed
+				}
+				if renderCh == ' ' {
 					renderCh = '_'
 				}
 			}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// width of the colour names of the theme editor
+const themeEditorLabelWidth = 22
+
+// renders the theme editor: a row per colour with its hex, a swatch and
+// its hsl, a sample in the edited colours and the pairs that are hard to
+// read
+func (r *Renderer) drawThemeEditor(model *Model, width, height int) {
+	layout := model.Layout
+	e := model.Editor
+	for y := layout.StatsY; y < layout.FooterY; y++ {
+		r.fillLine(y, width, r.styles.Base)
+	}
+	x := layout.TextX
+	areaWidth := layout.TextWidth
+	y := layout.StatsY
+	// the lines are drawn top down while they fit above the footer
+	next := func() (int, bool) {
+		line := y
+		y++
+		return line, line < layout.FooterY
+	}
+
+	title := "editing " + e.Original.Label
+	if e.Base != "" && e.Base != e.Original.ID {
+		title += " (base " + e.Base + ")"
+	}
+	if line, ok := next(); ok {
+		r.drawClipped(x, line, areaWidth, title, r.styles.Accent)
+	}
+	next()
+
+	colors := e.Theme.colors()
+	for i, color := range colors {
+		line, ok := next()
+		if !ok {
+			return
+		}
+		selected := i == e.Selected
+		value := "default"
+		rgb, isRGB := colorRGB(*color)
+		if isRGB {
+			value = rgb.Hex()
+		}
+		if selected && e.Editing {
+			value = string(e.Input) + "_"
+		}
+		text := fmt.Sprintf("  %-*s %-8s", themeEditorLabelWidth, colorName(i), value)
+		style := r.styles.Dim
+		if selected {
+			style = r.styles.Correct.Reverse(true)
+		}
+		r.drawClipped(x, line, areaWidth, text, style)
+		if !isRGB || len(text)+3 > areaWidth {
+			continue
+		}
+		r.drawString(x+len(text)+1, line, "  ", tcell.StyleDefault.Background(*color))
+
+		// the hsl, the channel left and right change is lit
+		h, s, l := rgb.HSL()
+		if selected {
+			h, s, l = e.hsl[0], e.hsl[1], e.hsl[2]
+		}
+		hx := x + len(text) + 5
+		for channel, part := range []string{
+			fmt.Sprintf("h %3.0f", h),
+			fmt.Sprintf("s %3.0f%%", math.Round(s*100)),
+			fmt.Sprintf("l %3.0f%%", math.Round(l*100)),
+		} {
+			if hx+len(part) > x+areaWidth {
+				break
+			}
+			style := r.styles.Dim
+			if selected && channel == e.Channel {
+				style = r.styles.Accent
+			}
+			r.drawString(hx, line, part, style)
+			hx += len(part) + 2
+		}
+	}
+	next()
+
+	if line, ok := next(); ok {
+		r.drawSample(x, line, areaWidth)
+	}
+	if line, ok := next(); ok {
+		r.drawKeysSample(x, line, areaWidth)
+	}
+	next()
+
+	warnings := lowContrast(e.Theme)
+	if len(warnings) == 0 {
+		if line, ok := next(); ok {
+			r.drawClipped(x, line, areaWidth, "contrast ok", r.styles.Dim)
+		}
+	}
+	for _, warning := range warnings {
+		line, ok := next()
+		if !ok {
+			break
+		}
+		r.drawClipped(x, line, areaWidth, "! "+warning.String(), r.styles.Error)
+	}
+}
+
+// a row of keys of the on-screen keyboard and a strip of the panel
+func (r *Renderer) drawKeysSample(x, y, width int) {
+	px := x
+	for _, part := range []struct {
+		text  string
+		style tcell.Style
+	}{
+		{"keys     ", r.styles.Dim},
+		{" q ", r.styles.Key},
+		{" ", r.styles.Base},
+		{" w ", r.styles.KeyActive},
+		{" ", r.styles.Base},
+		{" e ", r.styles.KeyError},
+		{"  ", r.styles.Base},
+		{" panel ", r.styles.Panel},
+	} {
+		r.drawClipped(px, y, width-(px-x), part.text, part.style)
+		px += len(part.text)
+	}
+}
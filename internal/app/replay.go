@@ -0,0 +1,80 @@
+package app
+
+import "time"
+
+// special runes in the key log for the two delete actions
+const (
+	logBackspace  = '\b'
+	logDeleteWord = 0x17
+)
+
+// one key press recorded during the test, used for replays
+type KeyEvent struct {
+	At   time.Duration
+	Rune rune
+}
+
+// ReplayState plays a recorded key log back in real time
+type ReplayState struct {
+	Events []KeyEvent
+	Next   int
+	Start  time.Time
+}
+
+// record a key press with its offset from the start of the test
+func (m *Model) logKey(r rune, now time.Time) {
+	if !m.Timer.Started {
+		return
+	}
+	m.keyLog = append(m.keyLog, KeyEvent{At: now.Sub(m.Timer.Start), Rune: r})
+}
+
+// StartReplay resets the model to the text of a run and plays its keys back,
+// a replay is never saved to the history or the best scores
+func (m *Model) StartReplay(options Options, seed int64, events []KeyEvent, now time.Time) {
+	m.Options = options
+	m.ResetWithSeed(seed)
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	m.Replay = &ReplayState{Events: events, Start: now}
+}
+
+// Replaying reports if the current test is a replay
+func (m *Model) Replaying() bool {
+	return m.Replay != nil
+}
+
+// apply every logged key that is due, returns true when something changed
+func (m *Model) stepReplay(now time.Time) bool {
+	replay := m.Replay
+	if replay == nil || m.Timer.Finished {
+		return false
+	}
+	changed := false
+	for replay.Next < len(replay.Events) && !m.Timer.Finished {
+		event := replay.Events[replay.Next]
+		at := replay.Start.Add(event.At)
+		if at.After(now) {
+			break
+		}
+		replay.Next++
+		m.applyLoggedKey(event.Rune, at)
+		changed = true
+	}
+	return changed
+}
+
+// apply one logged key the same way HandleKey would
+func (m *Model) applyLoggedKey(r rune, at time.Time) {
+	switch r {
+	case logBackspace:
+		m.Backspace(at)
+	case logDeleteWord:
+		m.BackspaceWord(at)
+	default:
+		m.registerKey(r, at)
+		if !m.Timer.Started {
+			m.StartTimer(at)
+		}
+		m.AddRune(r, at)
+	}
+}
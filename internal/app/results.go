@@ -6,9 +6,12 @@ type ResultsState struct {
 	Visible      bool
 	NetWPM       int
 	RawWPM       int
+	NetCPM       int
+	RawCPM       int
 	Accuracy     int
 	Consistency  int
 	BestWPM      int
+	BestCPM      int
 	BestAccuracy int
 	HasBaseline  bool
 	Improved     bool
@@ -24,6 +27,8 @@ func (m *Model) FinalizeResults(prevBest storage.BestScore, hasPrev bool) {
 		Visible:     true,
 		NetWPM:      m.Stats.WPM,
 		RawWPM:      m.Stats.RawWPM,
+		NetCPM:      m.Stats.CPM,
+		RawCPM:      m.Stats.RawCPM,
 		Accuracy:    m.Stats.Accuracy,
 		Consistency: m.history.StdDev(),
 		HasBaseline: hasPrev,
@@ -32,14 +37,19 @@ func (m *Model) FinalizeResults(prevBest storage.BestScore, hasPrev bool) {
 	if hasPrev {
 		if isBetter(m.Stats, prevBest) {
 			current.Improved = true
-			best = storage.BestScore{WPM: m.Stats.WPM, Accuracy: m.Stats.Accuracy}
+			best = storage.BestScore{WPM: m.Stats.WPM, CPM: m.Stats.CPM, Accuracy: m.Stats.Accuracy}
 		} else if isWorse(m.Stats, prevBest) {
 			current.Worse = true
 		}
 	} else {
-		best = storage.BestScore{WPM: m.Stats.WPM, Accuracy: m.Stats.Accuracy}
+		best = storage.BestScore{WPM: m.Stats.WPM, CPM: m.Stats.CPM, Accuracy: m.Stats.Accuracy}
 	}
 	current.BestWPM = best.WPM
+	current.BestCPM = best.CPM
+	// older best scores were saved without cpm
+	if current.BestCPM == 0 {
+		current.BestCPM = best.WPM * charsPerWord
+	}
 	current.BestAccuracy = best.Accuracy
 	m.Results = current
 }
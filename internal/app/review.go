@@ -10,7 +10,7 @@ func (m *Model) InitReviewStart() {
 		m.ReviewStart = 0
 		return
 	}
-	m.ReviewStart = defaultStartLine(lines, len(m.Text.Typed))
+	m.ReviewStart = defaultStartLine(lines, len(m.Text.Typed), m.Tuning.VisibleLines)
 }
 
 func (m *Model) ScrollReview(delta int) bool {
@@ -21,33 +21,14 @@ func (m *Model) ScrollReview(delta int) bool {
 	if len(lines) == 0 {
 		return false
 	}
-	maxStart := len(lines) - maxVisibleLines
-	if maxStart < 0 {
-		maxStart = 0
-	}
-	start := m.ReviewStart + delta
-	if start < 0 {
-		start = 0
-	}
-	if start > maxStart {
-		start = maxStart
-	}
-	if start == m.ReviewStart {
-		return false
-	}
-	m.ReviewStart = start
-	return true
+	return scrollBy(&m.ReviewStart, delta, len(lines), m.Tuning.VisibleLines)
 }
 
 func (m *Model) ReviewTop() bool {
 	if !m.Timer.Finished {
 		return false
 	}
-	if m.ReviewStart == 0 {
-		return false
-	}
-	m.ReviewStart = 0
-	return true
+	return scrollTop(&m.ReviewStart)
 }
 
 func (m *Model) ReviewBottom() bool {
@@ -58,13 +39,49 @@ func (m *Model) ReviewBottom() bool {
 	if len(lines) == 0 {
 		return false
 	}
-	maxStart := len(lines) - maxVisibleLines
-	if maxStart < 0 {
-		maxStart = 0
+	return scrollBottom(&m.ReviewStart, len(lines), m.Tuning.VisibleLines)
+}
+
+// scroll helpers shared by every list that shows a window of rows, start
+// is the first visible row and they return true when it moved
+
+func scrollBy(start *int, delta, total, visible int) bool {
+	next := *start + delta
+	if next > scrollMax(total, visible) {
+		next = scrollMax(total, visible)
 	}
-	if m.ReviewStart == maxStart {
+	if next < 0 {
+		next = 0
+	}
+	if next == *start {
+		return false
+	}
+	*start = next
+	return true
+}
+
+func scrollTop(start *int) bool {
+	if *start == 0 {
+		return false
+	}
+	*start = 0
+	return true
+}
+
+func scrollBottom(start *int, total, visible int) bool {
+	maxStart := scrollMax(total, visible)
+	if *start == maxStart {
 		return false
 	}
-	m.ReviewStart = maxStart
+	*start = maxStart
 	return true
 }
+
+// scrollMax returns the last valid start so the window stays full
+func scrollMax(total, visible int) int {
+	maxStart := total - visible
+	if maxStart < 0 {
+		maxStart = 0
+	}
+	return maxStart
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// the only word list for now
+const defaultLanguage = "english"
+
+// convert the options to the storage format used in the history
+func runOptionsFromOptions(options Options) storage.RunOptions {
+	run := storage.RunOptions{
+		Mode:        modeToString(options.Mode),
+		Punctuation: options.Punctuation,
+		Numbers:     options.Numbers,
+		Language:    defaultLanguage,
+		Formula:     formulaToString(options.Formula),
+	}
+	if options.Mode == ModeWords {
+		run.WordCount = options.WordCount
+	} else {
+		run.DurationSeconds = int(options.Duration.Seconds())
+	}
+	return run
+}
+
+// convert the stored run options back to test options
+func optionsFromRun(run storage.RunOptions) Options {
+	options := Options{
+		Mode:        modeFromString(run.Mode),
+		Punctuation: run.Punctuation,
+		Numbers:     run.Numbers,
+		Formula:     formulaFromString(run.Formula),
+		Duration:    60 * time.Second,
+		WordCount:   50,
+	}
+	if run.DurationSeconds > 0 {
+		options.Duration = time.Duration(run.DurationSeconds) * time.Second
+	}
+	if run.WordCount > 0 {
+		options.WordCount = run.WordCount
+	}
+	return options
+}
+
+// convert the stored key log back to replay events
+func eventsFromRun(run storage.Run) []KeyEvent {
+	events := make([]KeyEvent, len(run.Log))
+	for i, key := range run.Log {
+		events[i] = KeyEvent{At: time.Duration(key.At) * time.Millisecond, Rune: key.Rune}
+	}
+	return events
+}
+
+// build the history entry for the test that just finished
+func runFromModel(model *Model, now time.Time) storage.Run {
+	end := model.Timer.End
+	if end.IsZero() || end.After(now) {
+		end = now
+	}
+	options := runOptionsFromOptions(model.Options)
+	options.Preset = model.ActivePreset()
+	run := storage.Run{
+		Summary: storage.Summary{
+			Key:       model.ScoreKey(),
+			Options:   options,
+			Seed:      model.Seed,
+			StartedAt: model.Timer.Start.UnixMilli(),
+			EndedAt:   end.UnixMilli(),
+			Metrics: storage.Metrics{
+				WPM:         model.Stats.WPM,
+				RawWPM:      model.Stats.RawWPM,
+				CPM:         model.Stats.CPM,
+				RawCPM:      model.Stats.RawCPM,
+				Accuracy:    model.Stats.Accuracy,
+				Consistency: model.Results.Consistency,
+				Correct:     model.Stats.Correct,
+				Incorrect:   model.Stats.Incorrect,
+				Keystrokes:  model.Stats.Keystrokes,
+			},
+		},
+	}
+	if len(model.KeyStats) > 0 {
+		run.Keys = make(map[string]storage.KeyStat, len(model.KeyStats))
+		for r, stat := range model.KeyStats {
+			run.Keys[string(r)] = storage.KeyStat{Hits: stat.Hits, Misses: stat.Misses}
+		}
+	}
+	samples := model.history.Samples()
+	if len(samples) > 0 {
+		run.Samples = make([]storage.Sample, len(samples))
+		for i, sample := range samples {
+			run.Samples[i] = storage.Sample{
+				Second: sample.Second,
+				WPM:    sample.WPM,
+				Raw:    sample.Raw,
+				Errors: sample.Errors,
+			}
+		}
+	}
+	if len(model.keyLog) > 0 {
+		run.Log = make([]storage.Keystroke, len(model.keyLog))
+		for i, event := range model.keyLog {
+			run.Log[i] = storage.Keystroke{At: event.At.Milliseconds(), Rune: event.Rune}
+		}
+	}
+	return run
+}
@@ -0,0 +1,532 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/storage"
+	"github.com/yossefsabry/gotype/internal/themes"
+)
+
+// what a row of the settings screen holds, it decides the keys the row
+// takes
+type settingKind int
+
+const (
+	settingToggle settingKind = iota
+	settingChoice
+	settingNumber
+	settingColor
+	// set in the config file, only shown
+	settingInfo
+	settingAction
+)
+
+// one row of the settings screen. Every change goes into the model so it
+// shows right away and is saved with the preferences.
+type setting struct {
+	group string
+	label string
+	kind  settingKind
+	value func(m *Model) string
+	// change the value one step to the left or the right, enter on a toggle
+	// or a choice steps right and on an action runs it
+	step func(m *Model, delta int) bool
+	// apply typed text, numbers and colours only
+	set func(m *Model, text string) error
+	// the characters the typed text can have
+	accepts func(r rune) bool
+	// back to the default, nil when the row has none
+	reset func(m *Model) bool
+	// the style with the colour of a colour row as the foreground
+	swatch func(s Styles) tcell.Style
+}
+
+// state of the settings screen, Selected is the selected row and Start the
+// first visible line. Input is the text typed while Editing.
+type SettingsState struct {
+	Selected int
+	Start    int
+	Editing  bool
+	Input    []rune
+}
+
+// the steps of the number rows
+const (
+	durationStep  = 15 * time.Second
+	wordCountStep = 5
+)
+
+// the colours offered by left and right on a colour row, empty is the
+// colour of the theme
+var colorSwatches = []string{"", "#e06c75", "#e5c07b", "#98c379", "#56b6c2", "#61afef", "#c678dd", "#d19a66", "#f8f8f2"}
+
+var settings = []setting{
+	{group: "test", label: "mode", kind: settingChoice,
+		value: func(m *Model) string { return modeToString(m.Options.Mode) },
+		step: func(m *Model, delta int) bool {
+			if m.Options.Mode == ModeWords {
+				return m.setMode(ModeTime)
+			}
+			return m.setMode(ModeWords)
+		},
+		reset: func(m *Model) bool { return m.Options.Mode != ModeTime && m.setMode(ModeTime) }},
+	{group: "test", label: "duration", kind: settingNumber,
+		value: func(m *Model) string { return durationLabel(m.Options.Duration) },
+		step: func(m *Model, delta int) bool {
+			return m.setDuration(m.Options.Duration+time.Duration(delta)*durationStep) == nil
+		},
+		set: func(m *Model, text string) error {
+			duration, err := parseCustomDuration(text)
+			if err != nil {
+				return err
+			}
+			return m.setDuration(duration)
+		},
+		accepts: func(r rune) bool { return r >= '0' && r <= '9' || strings.ContainsRune("hms", r) },
+		reset:   func(m *Model) bool { return m.setDuration(defaultDuration) == nil }},
+	{group: "test", label: "word count", kind: settingNumber,
+		value: func(m *Model) string { return strconv.Itoa(m.Options.WordCount) },
+		step: func(m *Model, delta int) bool {
+			return m.setWordCount(m.Options.WordCount+delta*wordCountStep) == nil
+		},
+		set: func(m *Model, text string) error {
+			count, err := strconv.Atoi(text)
+			if err != nil {
+				return fmt.Errorf("invalid word count %q", text)
+			}
+			return m.setWordCount(count)
+		},
+		accepts: func(r rune) bool { return r >= '0' && r <= '9' },
+		reset:   func(m *Model) bool { return m.setWordCount(defaultWordCount) == nil }},
+	{group: "test", label: "punctuation", kind: settingToggle,
+		value: func(m *Model) string { return onOff(m.Options.Punctuation) },
+		step: func(m *Model, delta int) bool {
+			m.Options.Punctuation = !m.Options.Punctuation
+			m.Reset()
+			return true
+		},
+		reset: func(m *Model) bool {
+			if !m.Options.Punctuation {
+				return false
+			}
+			m.Options.Punctuation = false
+			m.Reset()
+			return true
+		}},
+	{group: "test", label: "numbers", kind: settingToggle,
+		value: func(m *Model) string { return onOff(m.Options.Numbers) },
+		step: func(m *Model, delta int) bool {
+			m.Options.Numbers = !m.Options.Numbers
+			m.Reset()
+			return true
+		},
+		reset: func(m *Model) bool {
+			if !m.Options.Numbers {
+				return false
+			}
+			m.Options.Numbers = false
+			m.Reset()
+			return true
+		}},
+	// the formula changes how the test is scored so a new one starts
+	{group: "test", label: "speed formula", kind: settingChoice,
+		value: func(m *Model) string { return formulaToString(m.Options.Formula) },
+		step: func(m *Model, delta int) bool {
+			m.Options.Formula = stepIn(formulaOrder, m.Options.Formula, delta)
+			m.Reset()
+			return true
+		},
+		reset: func(m *Model) bool {
+			if m.Options.Formula == FormulaPlain {
+				return false
+			}
+			m.Options.Formula = FormulaPlain
+			m.Reset()
+			return true
+		}},
+	{group: "test", label: "unit", kind: settingChoice,
+		value: func(m *Model) string { return unitToString(m.Unit) },
+		step: func(m *Model, delta int) bool {
+			m.Unit = stepIn(unitOrder, m.Unit, delta)
+			return true
+		},
+		reset: func(m *Model) bool {
+			changed := m.Unit != UnitWPM
+			m.Unit = UnitWPM
+			return changed
+		}},
+
+	{group: "appearance", label: "theme", kind: settingChoice,
+		value: func(m *Model) string {
+			// auto says which of the two it is drawing
+			if m.ThemeID == themes.AutoID {
+				return "auto: " + ThemeLabel(m.themeInUse())
+			}
+			return ThemeLabel(m.ThemeID)
+		},
+		step:  func(m *Model, delta int) bool { return m.cycleTheme(delta) },
+		reset: func(m *Model) bool { return m.SetTheme(DefaultThemeID()) }},
+	{group: "appearance", label: "accent colour", kind: settingColor,
+		value: func(m *Model) string { return colorLabel(m.Appearance.Accent) },
+		step: func(m *Model, delta int) bool {
+			m.Appearance.Accent = stepIn(colorSwatches, m.Appearance.Accent, delta)
+			return true
+		},
+		set: func(m *Model, text string) error {
+			color, err := parseColor(text)
+			if err == nil {
+				m.Appearance.Accent = color
+			}
+			return err
+		},
+		accepts: isColorRune,
+		reset: func(m *Model) bool {
+			changed := m.Appearance.Accent != ""
+			m.Appearance.Accent = ""
+			return changed
+		},
+		swatch: func(s Styles) tcell.Style { return s.Accent }},
+	{group: "appearance", label: "error colour", kind: settingColor,
+		value: func(m *Model) string { return colorLabel(m.Appearance.Error) },
+		step: func(m *Model, delta int) bool {
+			m.Appearance.Error = stepIn(colorSwatches, m.Appearance.Error, delta)
+			return true
+		},
+		set: func(m *Model, text string) error {
+			color, err := parseColor(text)
+			if err == nil {
+				m.Appearance.Error = color
+			}
+			return err
+		},
+		accepts: isColorRune,
+		reset: func(m *Model) bool {
+			changed := m.Appearance.Error != ""
+			m.Appearance.Error = ""
+			return changed
+		},
+		swatch: func(s Styles) tcell.Style { return s.Error }},
+	{group: "appearance", label: "error marks", kind: settingChoice,
+		value: func(m *Model) string { return errorMarkLabel(m.Appearance.ErrorMark) },
+		step: func(m *Model, delta int) bool {
+			m.Appearance.ErrorMark = stepIn(errorMarks, m.Appearance.ErrorMark, delta)
+			return true
+		},
+		reset: func(m *Model) bool {
+			changed := m.Appearance.ErrorMark != ""
+			m.Appearance.ErrorMark = ""
+			return changed
+		}},
+	{group: "appearance", label: "show typed letters", kind: settingToggle,
+		value: func(m *Model) string { return onOff(m.Appearance.ShowTyped) },
+		step: func(m *Model, delta int) bool {
+			m.Appearance.ShowTyped = !m.Appearance.ShowTyped
+			return true
+		},
+		reset: func(m *Model) bool {
+			changed := m.Appearance.ShowTyped
+			m.Appearance.ShowTyped = false
+			return changed
+		}},
+	{group: "appearance", label: "keyboard", kind: settingToggle,
+		value: func(m *Model) string { return onOff(!m.Appearance.HideKeyboard) },
+		step: func(m *Model, delta int) bool {
+			m.Appearance.HideKeyboard = !m.Appearance.HideKeyboard
+			return true
+		},
+		reset: func(m *Model) bool {
+			changed := m.Appearance.HideKeyboard
+			m.Appearance.HideKeyboard = false
+			return changed
+		}},
+	{group: "appearance", label: "live stats while typing", kind: settingToggle,
+		value: func(m *Model) string { return onOff(m.Appearance.LiveStats) },
+		step: func(m *Model, delta int) bool {
+			m.Appearance.LiveStats = !m.Appearance.LiveStats
+			return true
+		},
+		reset: func(m *Model) bool {
+			changed := m.Appearance.LiveStats
+			m.Appearance.LiveStats = false
+			return changed
+		}},
+
+	{group: "config file", label: "profile", kind: settingInfo,
+		value: func(m *Model) string { return m.Profile }},
+	{group: "config file", label: "keymap", kind: settingInfo,
+		value: func(m *Model) string { return m.Tuning.Keymap.Preset }},
+	{group: "config file", label: "visible lines", kind: settingInfo,
+		value: func(m *Model) string { return strconv.Itoa(m.Tuning.VisibleLines) }},
+	{group: "config file", label: "words per line", kind: settingInfo,
+		value: func(m *Model) string { return strconv.Itoa(m.Tuning.WordsPerLine) }},
+	{group: "config file", label: "plain text", kind: settingInfo,
+		value: func(m *Model) string { return onOff(m.Tuning.PlainText) }},
+	{group: "config file", label: "colours", kind: settingInfo,
+		value: func(m *Model) string {
+			if m.Tuning.Colors == "auto" && os.Getenv("NO_COLOR") != "" {
+				return "auto: none, NO_COLOR is set"
+			}
+			return m.Tuning.Colors
+		}},
+	{group: "config file", label: "light / dark theme", kind: settingInfo,
+		value: func(m *Model) string { return ThemeLabel(m.Tuning.LightTheme) + " / " + ThemeLabel(m.Tuning.DarkTheme) }},
+
+	{group: "", label: "reset everything to default", kind: settingAction,
+		value: func(m *Model) string { return "" },
+		step: func(m *Model, delta int) bool {
+			applyPreferences(m, defaultPreferences())
+			m.Reset()
+			m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+			return true
+		}},
+}
+
+// the preferences of a new model, what reset goes back to
+func defaultPreferences() storage.Preferences {
+	return storage.Preferences{
+		ThemeID:         DefaultThemeID(),
+		Mode:            modeToString(ModeTime),
+		DurationSeconds: int(defaultDuration / time.Second),
+		WordCount:       defaultWordCount,
+		Formula:         formulaToString(FormulaPlain),
+		Unit:            unitToString(UnitWPM),
+	}
+}
+
+// OpenSettings switches to the settings screen
+func (m *Model) OpenSettings() bool {
+	if m.focusActive() || m.Replaying() {
+		return false
+	}
+	m.CloseHistory()
+	m.CloseStats()
+	m.View = ViewSettings
+	m.closeMenus()
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	m.Settings = SettingsState{}
+	return true
+}
+
+// CloseSettings goes back to the typing screen
+func (m *Model) CloseSettings() bool {
+	if m.View != ViewSettings {
+		return false
+	}
+	m.View = ViewTyping
+	m.Settings = SettingsState{}
+	return true
+}
+
+// handle keys while the settings screen is open: up and down select a row,
+// left and right change it, enter toggles, edits or runs it and d puts the
+// default back
+func (m *Model) handleSettingsKey(event *tcell.EventKey, now time.Time) bool {
+	if m.Settings.Editing {
+		return m.handleSettingInput(event, now)
+	}
+	if m.boundTo(event, "settings") {
+		return m.CloseSettings()
+	}
+	row := settings[m.Settings.Selected]
+	switch event.Key() {
+	case tcell.KeyEsc:
+		return m.CloseSettings()
+	case tcell.KeyUp:
+		return m.selectSetting(-1)
+	case tcell.KeyDown:
+		return m.selectSetting(1)
+	case tcell.KeyLeft:
+		return row.kind != settingAction && row.step != nil && row.step(m, -1)
+	case tcell.KeyRight:
+		return row.kind != settingAction && row.step != nil && row.step(m, 1)
+	case tcell.KeyEnter:
+		switch row.kind {
+		case settingNumber, settingColor:
+			m.Settings.Editing = true
+			m.Settings.Input = m.Settings.Input[:0]
+			return true
+		case settingAction:
+			row.step(m, 1)
+			m.SetMessage("settings reset to default", now, m.Tuning.MessageTime)
+			return true
+		}
+		return row.step != nil && row.step(m, 1)
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'q':
+			return m.CloseSettings()
+		case 'k':
+			return m.selectSetting(-1)
+		case 'j':
+			return m.selectSetting(1)
+		case 'd':
+			if row.reset == nil {
+				return false
+			}
+			if row.reset(m) {
+				m.SetMessage(row.label+": default", now, m.Tuning.MessageTime)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// keys while a value is typed: enter applies it, a bad value stays in the
+// input with a warning and esc cancels
+func (m *Model) handleSettingInput(event *tcell.EventKey, now time.Time) bool {
+	s := &m.Settings
+	row := settings[s.Selected]
+	switch event.Key() {
+	case tcell.KeyEsc:
+		s.Editing = false
+		return true
+	case tcell.KeyEnter:
+		if err := row.set(m, string(s.Input)); err != nil {
+			m.SetMessage(err.Error(), now, m.Tuning.WarningTime)
+			return true
+		}
+		s.Editing = false
+		m.SetMessage(row.label+": "+row.value(m), now, m.Tuning.MessageTime)
+		return true
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(s.Input) > 0 {
+			s.Input = s.Input[:len(s.Input)-1]
+		}
+		return true
+	case tcell.KeyRune:
+		if row.accepts(event.Rune()) && len(s.Input) < 8 {
+			s.Input = append(s.Input, event.Rune())
+		}
+		return true
+	}
+	return false
+}
+
+// move the selection to the next row that can be changed, the screen
+// scrolls along
+func (m *Model) selectSetting(delta int) bool {
+	for i := m.Settings.Selected + delta; i >= 0 && i < len(settings); i += delta {
+		if settings[i].kind == settingInfo {
+			continue
+		}
+		m.Settings.Selected = i
+		lines := settingsLines()
+		line := slices.IndexFunc(lines, func(l settingsLine) bool { return l.index == i })
+		// the title of the group comes into view with its first row
+		top := line
+		if top > 0 && lines[top-1].title != "" {
+			top--
+		}
+		visible := settingsVisibleRows(m.Layout)
+		if top < m.Settings.Start {
+			m.Settings.Start = top
+		} else if line >= m.Settings.Start+visible {
+			m.Settings.Start = line - visible + 1
+		}
+		return true
+	}
+	return false
+}
+
+// one line of the settings screen, a group title or the row at index
+type settingsLine struct {
+	title string
+	index int
+}
+
+// the lines of the settings screen, every group starts with its title
+func settingsLines() []settingsLine {
+	var lines []settingsLine
+	group := ""
+	for i, row := range settings {
+		if row.group != group || i == 0 {
+			if i > 0 {
+				lines = append(lines, settingsLine{index: -1})
+			}
+			if row.group != "" {
+				lines = append(lines, settingsLine{title: row.group, index: -1})
+			}
+			group = row.group
+		}
+		lines = append(lines, settingsLine{index: i})
+	}
+	return lines
+}
+
+// settingsVisibleRows returns how many lines fit above the preview
+func settingsVisibleRows(layout Layout) int {
+	return max(layout.FooterY-layout.StatsY-3, 1)
+}
+
+// the value after value in values, it wraps at the ends and a value that
+// isn't there steps from the first
+func stepIn[T comparable](values []T, value T, delta int) T {
+	index := max(slices.Index(values, value), 0)
+	return values[(index+delta+len(values))%len(values)]
+}
+
+func (m *Model) setDuration(duration time.Duration) error {
+	if err := storage.ValidDuration(int(duration / time.Second)); err != nil {
+		return err
+	}
+	if m.Options.Duration != duration {
+		m.Options.Duration = duration
+		m.Reset()
+	}
+	return nil
+}
+
+func (m *Model) setWordCount(count int) error {
+	if err := storage.ValidWordCount(count); err != nil {
+		return err
+	}
+	if m.Options.WordCount != count {
+		m.Options.WordCount = count
+		m.Reset()
+	}
+	return nil
+}
+
+// a typed colour, the # can be left out
+func parseColor(text string) (string, error) {
+	color := strings.ToLower(text)
+	if !strings.HasPrefix(color, "#") {
+		color = "#" + color
+	}
+	if err := storage.ValidColor(color); err != nil {
+		return "", err
+	}
+	return color, nil
+}
+
+func isColorRune(r rune) bool {
+	return strings.ContainsRune("#0123456789abcdefABCDEF", r)
+}
+
+func colorLabel(color string) string {
+	if color == "" {
+		return "theme"
+	}
+	return color
+}
+
+func errorMarkLabel(mark string) string {
+	if mark == "" {
+		return "colour only"
+	}
+	return mark
+}
+
+func onOff(value bool) string {
+	if value {
+		return "on"
+	}
+	return "off"
+}
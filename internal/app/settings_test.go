@@ -0,0 +1,95 @@
+package app
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// select the row with label on the settings screen
+func selectSettingRow(t *testing.T, m *Model, label string, now time.Time) {
+	t.Helper()
+	index := slices.IndexFunc(settings, func(s setting) bool { return s.label == label })
+	for m.Settings.Selected < index {
+		pressKey(m, tcell.KeyDown, now)
+	}
+	if m.Settings.Selected != index {
+		t.Fatalf("selected %d, want %s", m.Settings.Selected, label)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	now := time.Now()
+	m := NewModel()
+	m.Layout.Recalculate(160, 40, m.Options.Mode, false)
+	pressKey(m, tcell.KeyF4, now)
+	if m.View != ViewSettings {
+		t.Fatal("f4 didn't open the settings")
+	}
+
+	// changes go into the model and so into the saved preferences
+	selectSettingRow(t, m, "word count", now)
+	pressKey(m, tcell.KeyRight, now)
+	if m.Options.WordCount != defaultWordCount+wordCountStep {
+		t.Fatalf("word count = %d", m.Options.WordCount)
+	}
+	selectSettingRow(t, m, "accent colour", now)
+	pressKey(m, tcell.KeyRight, now)
+	if prefs := preferencesFromModel(m); prefs.AccentColor != colorSwatches[1] {
+		t.Fatalf("accent colour = %q", prefs.AccentColor)
+	}
+
+	// a typed colour is checked, a bad one stays in the input
+	pressKey(m, tcell.KeyEnter, now)
+	for _, r := range "12345g" {
+		m.HandleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), now)
+	}
+	pressKey(m, tcell.KeyEnter, now)
+	if !m.Settings.Editing || m.UI.Message == "" {
+		t.Fatal("a short colour was accepted")
+	}
+	m.HandleKey(tcell.NewEventKey(tcell.KeyRune, 'F', tcell.ModNone), now)
+	pressKey(m, tcell.KeyEnter, now)
+	if m.Settings.Editing || m.Appearance.Accent != "#12345f" {
+		t.Fatalf("accent colour = %q", m.Appearance.Accent)
+	}
+	if got := appearanceTheme(ThemeByID(m.ThemeID), m.Appearance).Accent; got != tcell.GetColor("#12345f") {
+		t.Fatalf("theme accent = %v", got)
+	}
+
+	// d puts the default back
+	m.HandleKey(tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone), now)
+	if m.Appearance.Accent != "" {
+		t.Fatalf("accent colour = %q after reset", m.Appearance.Accent)
+	}
+
+	// mistakes can show by more than their colour
+	selectSettingRow(t, m, "error marks", now)
+	pressKey(m, tcell.KeyRight, now)
+	if prefs := preferencesFromModel(m); prefs.ErrorMark != "underline" {
+		t.Fatalf("error mark = %q", prefs.ErrorMark)
+	}
+
+	// the config file rows are skipped and the last one resets everything
+	selectSettingRow(t, m, "keyboard", now)
+	pressKey(m, tcell.KeyEnter, now)
+	pressKey(m, tcell.KeyDown, now)
+	pressKey(m, tcell.KeyDown, now)
+	if settings[m.Settings.Selected].kind != settingAction {
+		t.Fatalf("selected %s", settings[m.Settings.Selected].label)
+	}
+	if !m.Appearance.HideKeyboard {
+		t.Fatal("keyboard still shown")
+	}
+	pressKey(m, tcell.KeyEnter, now)
+	if prefs := preferencesFromModel(m); prefs != defaultPreferences() {
+		t.Fatalf("preferences after reset = %+v", prefs)
+	}
+
+	pressKey(m, tcell.KeyEsc, now)
+	if m.View != ViewTyping {
+		t.Fatal("esc didn't leave the settings")
+	}
+}
@@ -5,11 +5,20 @@ import (
 	"time"
 )
 
+// one sample per second of the test, kept for the saved history
+type Sample struct {
+	Second int
+	WPM    int
+	Raw    int
+	Errors int
+}
+
 type StatsHistory struct {
 	lastSampleSecond int64
 	count            int
 	sum              float64
 	sumSquares       float64
+	samples          []Sample
 }
 
 func (h *StatsHistory) Reset() {
@@ -17,9 +26,10 @@ func (h *StatsHistory) Reset() {
 	h.count = 0
 	h.sum = 0
 	h.sumSquares = 0
+	h.samples = h.samples[:0]
 }
 
-func (h *StatsHistory) Record(elapsed time.Duration, wpm int) {
+func (h *StatsHistory) Record(elapsed time.Duration, wpm, raw, errors int) {
 	if elapsed < 0 {
 		return
 	}
@@ -32,6 +42,12 @@ func (h *StatsHistory) Record(elapsed time.Duration, wpm int) {
 	h.count++
 	h.sum += value
 	h.sumSquares += value * value
+	h.samples = append(h.samples, Sample{Second: int(second), WPM: wpm, Raw: raw, Errors: errors})
+}
+
+// Samples returns the per-second samples recorded since the last reset
+func (h *StatsHistory) Samples() []Sample {
+	return h.samples
 }
 
 func (h *StatsHistory) StdDev() int {
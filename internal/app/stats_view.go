@@ -0,0 +1,105 @@
+package app
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/analytics"
+)
+
+// StatsSource hands out the cached progress report for a formula
+type StatsSource interface {
+	Report(formula string) analytics.Report
+}
+
+// height of the charts at the bottom of the stats screen
+const statsChartHeight = 5
+
+// state of the stats screen, start is the first visible score key row
+type DashboardState struct {
+	Report  analytics.Report
+	Formula Formula
+	Start   int
+}
+
+// OpenStats switches to the stats screen for the current formula
+func (m *Model) OpenStats(now time.Time) bool {
+	if m.focusActive() || m.Replaying() {
+		return false
+	}
+	if m.StatsSource == nil {
+		m.SetMessage("stats are not available", now, m.Tuning.MessageTime)
+		return true
+	}
+	m.CloseHistory()
+	m.View = ViewStats
+	m.closeMenus()
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	m.Dashboard.Formula = m.Options.Formula
+	m.loadDashboard()
+	return true
+}
+
+// CloseStats goes back to the typing screen
+func (m *Model) CloseStats() bool {
+	if m.View != ViewStats {
+		return false
+	}
+	m.View = ViewTyping
+	return true
+}
+
+// load the report for the formula picked on the stats screen
+func (m *Model) loadDashboard() {
+	m.Dashboard.Report = m.StatsSource.Report(formulaToString(m.Dashboard.Formula))
+	m.Dashboard.Start = 0
+}
+
+// statsVisibleRows returns how many score keys fit above the charts
+func statsVisibleRows(layout Layout) int {
+	rows := statsChartTitleY(layout) - 1 - (layout.StatsY + 4)
+	if rows < 1 {
+		rows = 1
+	}
+	return rows
+}
+
+// line with the chart titles, the charts are drawn right below it
+func statsChartTitleY(layout Layout) int {
+	return layout.FooterY - statsChartHeight - 2
+}
+
+// handle keys while the stats screen is open
+func (m *Model) handleStatsKey(event *tcell.EventKey) (bool, bool) {
+	d := &m.Dashboard
+	total := len(d.Report.Keys)
+	visible := statsVisibleRows(m.Layout)
+	switch event.Key() {
+	case tcell.KeyCtrlC:
+		return false, true
+	case tcell.KeyEsc, tcell.KeyF3:
+		return m.CloseStats(), false
+	case tcell.KeyUp:
+		return scrollBy(&d.Start, -1, total, visible), false
+	case tcell.KeyDown:
+		return scrollBy(&d.Start, 1, total, visible), false
+	case tcell.KeyPgUp:
+		return scrollBy(&d.Start, -visible, total, visible), false
+	case tcell.KeyPgDn:
+		return scrollBy(&d.Start, visible, total, visible), false
+	case tcell.KeyHome:
+		return scrollTop(&d.Start), false
+	case tcell.KeyEnd:
+		return scrollBottom(&d.Start, total, visible), false
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'q':
+			return m.CloseStats(), false
+		case 'f':
+			d.Formula = nextFormula(d.Formula)
+			m.loadDashboard()
+			return true, false
+		}
+	}
+	return false, false
+}
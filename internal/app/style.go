@@ -14,11 +14,17 @@ type Styles struct {
 	Key       tcell.Style
 	KeyActive tcell.Style
 	KeyError  tcell.Style
-	PanelBg   tcell.Color
+	// the mistyped letters, the error colour with the mark of the settings
+	Mistake tcell.Style
+	PanelBg tcell.Color
 }
 
-// create styles from theme colors
-func NewStyles(theme Theme) Styles {
+// create styles from theme colors, for a screen with count colours
+func NewStyles(theme Theme, count int) Styles {
+	if count == 0 {
+		return monochromeStyles()
+	}
+	theme = quantizeTheme(theme, count)
 	base := tcell.StyleDefault.Background(theme.Background).Foreground(theme.Text)
 	panel := tcell.StyleDefault.Background(theme.Panel).Foreground(theme.Text)
 	return Styles{
@@ -32,10 +38,60 @@ func NewStyles(theme Theme) Styles {
 		Key:       tcell.StyleDefault.Background(theme.KeyBackground).Foreground(theme.KeyText),
 		KeyActive: tcell.StyleDefault.Background(theme.KeyActiveBg).Foreground(theme.KeyActiveText),
 		KeyError:  tcell.StyleDefault.Background(theme.KeyBackground).Foreground(theme.Error),
+		Mistake:   base.Foreground(theme.Error),
 		PanelBg:   theme.Panel,
 	}
 }
 
+// the styles without colours, the terminal's text with the attributes
+// telling the parts apart: faint for what is left to type, underlined
+// mistakes and the cursor and the lit key in reverse
+func monochromeStyles() Styles {
+	plain := tcell.StyleDefault
+	return Styles{
+		Base:      plain,
+		Panel:     plain,
+		Dim:       plain.Dim(true),
+		Accent:    plain.Bold(true),
+		Correct:   plain,
+		Error:     plain.Underline(true),
+		Cursor:    plain.Reverse(true),
+		Key:       plain,
+		KeyActive: plain.Reverse(true),
+		KeyError:  plain.Underline(true),
+		Mistake:   plain.Underline(true),
+		PanelBg:   tcell.ColorDefault,
+	}
+}
+
+// the ways of the settings to mark a mistyped letter besides its colour,
+// empty is the colour alone
+var errorMarks = []string{"", "underline", "reverse"}
+
+// the style of the mistyped letters with mark over it
+func markMistake(style tcell.Style, mark string) tcell.Style {
+	switch mark {
+	case "underline":
+		return style.Underline(true)
+	case "reverse":
+		return style.Reverse(true)
+	}
+	return style
+}
+
+// the theme with the colours of the settings over it, the accent lights
+// the keys too
+func appearanceTheme(theme Theme, appearance Appearance) Theme {
+	if appearance.Accent != "" {
+		theme.Accent = tcell.GetColor(appearance.Accent)
+		theme.KeyActiveBg = theme.Accent
+	}
+	if appearance.Error != "" {
+		theme.Error = tcell.GetColor(appearance.Error)
+	}
+	return theme
+}
+
 // convert hex too tcell color
 // for remapping the colors too the nearest colors that is supported by the terminal
 func hexColor(value int32) tcell.Color {
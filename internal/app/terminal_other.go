@@ -0,0 +1,12 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos)
+
+package app
+
+import "github.com/gdamore/tcell/v2"
+
+// openScreen opens tcell's screen, the console can't be asked about its
+// background so COLORFGBG is all the auto theme has
+func openScreen() (tcell.Screen, *terminalTty, error) {
+	screen, err := tcell.NewScreen()
+	return screen, nil, err
+}
@@ -0,0 +1,23 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+
+package app
+
+import "github.com/gdamore/tcell/v2"
+
+// openScreen opens the terminal through a tty that passes on what the
+// terminal says about its background, without a tty it is tcell's own
+// screen and no terminal
+func openScreen() (tcell.Screen, *terminalTty, error) {
+	tty, err := tcell.NewDevTty()
+	if err != nil {
+		screen, err := tcell.NewScreen()
+		return screen, nil, err
+	}
+	terminal := newTerminalTty(tty)
+	screen, err := tcell.NewTerminfoScreenFromTty(terminal)
+	if err != nil {
+		tty.Close()
+		return nil, nil, err
+	}
+	return screen, terminal, nil
+}
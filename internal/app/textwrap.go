@@ -5,13 +5,8 @@ type Line struct {
 	End   int
 }
 
-const (
-	maxWordsPerLine = 10
-	maxVisibleLines = 3
-)
-
 // making a pipline for making max words per line and max visiable line
-func buildLines(target []rune, width int) []Line {
+func buildLines(target []rune, width, wordsPerLine int) []Line {
 	if width <= 0 || len(target) == 0 {
 		return nil
 	}
@@ -19,14 +14,14 @@ func buildLines(target []rune, width int) []Line {
 	if len(words) == 0 {
 		return nil
 	}
-	lines := make([]Line, 0, len(words)/maxWordsPerLine+1)
+	lines := make([]Line, 0, len(words)/wordsPerLine+1)
 	index := 0
 	for index < len(words) {
 		lineStart := words[index].Start
 		lineEnd := words[index].End
 		lineWords := 0
 		lineLen := 0
-		for index < len(words) && lineWords < maxWordsPerLine {
+		for index < len(words) && lineWords < wordsPerLine {
 			word := words[index]
 			wordLen := word.End - word.Start
 			addLen := wordLen
@@ -94,7 +89,7 @@ func lineIndexFor(lines []Line, index int) int {
 }
 
 // defaultStartLine calculates the starting line index for rendering based on the cursor position.
-func defaultStartLine(lines []Line, cursorIndex int) int {
+func defaultStartLine(lines []Line, cursorIndex, visible int) int {
 	if len(lines) == 0 {
 		return 0
 	}
@@ -103,7 +98,7 @@ func defaultStartLine(lines []Line, cursorIndex int) int {
 	if activeLine > 1 {
 		startLine = activeLine - 1
 	}
-	maxStart := len(lines) - maxVisibleLines
+	maxStart := len(lines) - visible
 	if maxStart < 0 {
 		maxStart = 0
 	}
@@ -9,9 +9,10 @@ func BenchmarkBuildLines(b *testing.B) {
 	gen := NewGenerator(rand.NewSource(1))
 	target := gen.Build(200, Options{})
 	width := 60
+	wordsPerLine := defaultTuning().WordsPerLine
 	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = buildLines(target, width)
+		_ = buildLines(target, width, wordsPerLine)
 	}
 }
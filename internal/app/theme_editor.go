@@ -0,0 +1,312 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/themes"
+)
+
+// the region of the theme menu that opens the editor
+const editThemeRegion = "theme:+"
+
+// state of the theme editor. Theme is drawn while it is open and Original
+// is the theme it started from, Base is the base of the file it saves.
+// Selected is the colour row and Channel the part of it left and right
+// change: the hue, the saturation or the lightness.
+type ThemeEditor struct {
+	Theme    Theme
+	Original Theme
+	Base     string
+	Selected int
+	Channel  int
+	// a hex colour or the id to save as is typed
+	Editing bool
+	Naming  bool
+	Input   []rune
+	// the hsl of the selected colour, kept so the hue survives a gray
+	hsl [3]float64
+}
+
+// the names of the hsl channels and how far one step moves them
+var (
+	hslChannels = []string{"h", "s", "l"}
+	hslSteps    = [3]float64{5, 0.02, 0.02}
+)
+
+// the pairs of colours that are read one on the other and the contrast
+// they want, 4.5 for the text and 3 for the rest like WCAG AA
+var contrastChecks = []struct {
+	fg, bg int
+	min    float64
+}{
+	{colorText, colorBackground, 4.5},
+	{colorDim, colorBackground, 3},
+	{colorAccent, colorBackground, 3},
+	{colorError, colorBackground, 3},
+	{colorCursorText, colorAccent, 4.5},
+	{colorKeyText, colorKeyBackground, 4.5},
+	{colorKeyActiveText, colorKeyActiveBg, 4.5},
+}
+
+// a pair of colours of a theme below the contrast it wants
+type contrastWarning struct {
+	fg, bg   int
+	contrast float64
+	min      float64
+}
+
+func (w contrastWarning) String() string {
+	return fmt.Sprintf("%s on %s %.1f:1, want %g:1", colorName(w.fg), colorName(w.bg), w.contrast, w.min)
+}
+
+// the pairs of theme that are hard to read, the terminal's own colours
+// can't be checked
+func lowContrast(theme Theme) []contrastWarning {
+	colors := theme.colors()
+	var warnings []contrastWarning
+	for _, check := range contrastChecks {
+		fg, okFg := colorRGB(*colors[check.fg])
+		bg, okBg := colorRGB(*colors[check.bg])
+		if !okFg || !okBg {
+			continue
+		}
+		if contrast := themes.Contrast(fg, bg); contrast < check.min {
+			warnings = append(warnings, contrastWarning{check.fg, check.bg, contrast, check.min})
+		}
+	}
+	return warnings
+}
+
+// the colour by its place in Theme.colors, the key of the theme file with
+// spaces
+func colorName(index int) string {
+	return strings.ReplaceAll(themes.ColorKeys[index], "_", " ")
+}
+
+// OpenThemeEditor switches to the theme editor with the colours of the
+// theme that is drawn
+func (m *Model) OpenThemeEditor() bool {
+	if m.focusActive() || m.Replaying() {
+		return false
+	}
+	m.CloseHistory()
+	m.CloseStats()
+	m.CloseSettings()
+	theme := ThemeByID(m.themeInUse())
+	base := theme.ID
+	if theme.User {
+		base = theme.Base
+	}
+	m.Editor = ThemeEditor{Theme: theme, Original: theme, Base: base}
+	m.Editor.selectColor(0)
+	m.View = ViewThemeEditor
+	m.closeMenus()
+	m.Layout.Recalculate(m.Layout.Width, m.Layout.Height, m.Options.Mode, m.focusActive())
+	return true
+}
+
+// CloseThemeEditor goes back to the typing screen, the edits are dropped
+func (m *Model) CloseThemeEditor() bool {
+	if m.View != ViewThemeEditor {
+		return false
+	}
+	m.View = ViewTyping
+	m.Editor = ThemeEditor{}
+	return true
+}
+
+// handle keys while the theme editor is open: up and down select a colour,
+// tab the channel and left and right change it, enter types a hex colour,
+// d puts the colour back and s saves the theme
+func (m *Model) handleThemeEditorKey(event *tcell.EventKey, now time.Time) bool {
+	e := &m.Editor
+	if e.Editing || e.Naming {
+		return m.handleThemeEditorInput(event, now)
+	}
+	if m.boundTo(event, "theme-editor") {
+		return m.CloseThemeEditor()
+	}
+	count := len(themes.ColorKeys)
+	switch event.Key() {
+	case tcell.KeyEsc:
+		return m.CloseThemeEditor()
+	case tcell.KeyUp:
+		e.selectColor((e.Selected - 1 + count) % count)
+		return true
+	case tcell.KeyDown:
+		e.selectColor((e.Selected + 1) % count)
+		return true
+	case tcell.KeyTab:
+		e.Channel = (e.Channel + 1) % len(hslChannels)
+		return true
+	case tcell.KeyBacktab:
+		e.Channel = (e.Channel + len(hslChannels) - 1) % len(hslChannels)
+		return true
+	case tcell.KeyLeft:
+		return m.stepEditorColor(-1, now)
+	case tcell.KeyRight:
+		return m.stepEditorColor(1, now)
+	case tcell.KeyEnter:
+		e.Editing = true
+		e.Input = e.Input[:0]
+		return true
+	case tcell.KeyRune:
+		switch event.Rune() {
+		case 'q':
+			return m.CloseThemeEditor()
+		case 'k':
+			e.selectColor((e.Selected - 1 + count) % count)
+			return true
+		case 'j':
+			e.selectColor((e.Selected + 1) % count)
+			return true
+		case 'd':
+			e.setColor(*e.Original.colors()[e.Selected])
+			m.SetMessage(colorName(e.Selected)+": back to "+e.Original.Label, now, m.Tuning.MessageTime)
+			return true
+		case 's':
+			e.Naming = true
+			e.Input = []rune(e.saveName())
+			return true
+		}
+	}
+	return false
+}
+
+// keys while a hex colour or the name to save as is typed: enter applies
+// it, a bad value stays in the input with a warning and esc cancels
+func (m *Model) handleThemeEditorInput(event *tcell.EventKey, now time.Time) bool {
+	e := &m.Editor
+	switch event.Key() {
+	case tcell.KeyEsc:
+		e.Editing, e.Naming = false, false
+		return true
+	case tcell.KeyEnter:
+		if e.Naming {
+			return m.saveEditedTheme(string(e.Input), now)
+		}
+		color, err := parseColor(string(e.Input))
+		if err != nil {
+			m.SetMessage(err.Error(), now, m.Tuning.WarningTime)
+			return true
+		}
+		e.setColor(tcell.GetColor(color))
+		e.Editing = false
+		return true
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(e.Input) > 0 {
+			e.Input = e.Input[:len(e.Input)-1]
+		}
+		return true
+	case tcell.KeyRune:
+		r := event.Rune()
+		switch {
+		case e.Naming && len(e.Input) < 32 && themes.ValidID(string(r)) == nil:
+			e.Input = append(e.Input, r)
+		case e.Editing && len(e.Input) < 7 && isColorRune(r):
+			e.Input = append(e.Input, r)
+		}
+		return true
+	}
+	return false
+}
+
+// select the colour at index, its hsl is where the steps start
+func (e *ThemeEditor) selectColor(index int) {
+	e.Selected = index
+	if rgb, ok := colorRGB(*e.Theme.colors()[index]); ok {
+		e.hsl[0], e.hsl[1], e.hsl[2] = rgb.HSL()
+	}
+}
+
+// set the selected colour
+func (e *ThemeEditor) setColor(color tcell.Color) {
+	*e.Theme.colors()[e.Selected] = color
+	e.selectColor(e.Selected)
+}
+
+// move the channel of the selected colour one step, the hue goes round
+// and the others stop at their ends
+func (m *Model) stepEditorColor(delta int, now time.Time) bool {
+	e := &m.Editor
+	color := e.Theme.colors()[e.Selected]
+	if _, ok := colorRGB(*color); !ok {
+		m.SetMessage(colorName(e.Selected)+" is the terminal's, <enter> a hex colour", now, m.Tuning.MessageTime)
+		return true
+	}
+	hsl := e.hsl
+	hsl[e.Channel] += float64(delta) * hslSteps[e.Channel]
+	if e.Channel == 0 {
+		hsl[0] = math.Mod(hsl[0]+360, 360)
+	} else {
+		hsl[e.Channel] = min(max(hsl[e.Channel], 0), 1)
+	}
+	rgb := themes.FromHSL(hsl[0], hsl[1], hsl[2])
+	*color = tcell.NewRGBColor(int32(rgb.R), int32(rgb.G), int32(rgb.B))
+	// the hsl goes on from where it was, not from the rounded colour
+	e.hsl = hsl
+	return true
+}
+
+// the name the theme is saved as unless another is typed: a user theme
+// over its own file, a built-in one as a copy
+func (e *ThemeEditor) saveName() string {
+	if e.Original.User {
+		return e.Original.ID
+	}
+	name := e.Original.ID + "-custom"
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// check the name and hand the theme file to the app, which writes it and
+// switches to the theme
+func (m *Model) saveEditedTheme(id string, now time.Time) bool {
+	e := &m.Editor
+	err := themes.ValidID(id)
+	switch {
+	case err != nil:
+	case slices.ContainsFunc(builtinThemes, func(t Theme) bool { return t.ID == id }):
+		err = fmt.Errorf("%q is a built-in theme, pick another name", id)
+	case id != e.Original.ID && slices.ContainsFunc(ThemeOptions(), func(t Theme) bool { return t.ID == id }):
+		err = fmt.Errorf("a theme %q exists, pick another name", id)
+	}
+	if err != nil {
+		m.SetMessage(err.Error(), now, m.Tuning.WarningTime)
+		return true
+	}
+	file := themes.File{ID: id, Label: id, Base: e.Base}
+	if e.Original.User && id == e.Original.ID {
+		file.Label = e.Original.Label
+	}
+	// a file can't start from itself
+	if file.Base == id {
+		file.Base = ""
+	}
+	colors := e.Theme.colors()
+	for i, field := range file.Colors() {
+		if rgb, ok := colorRGB(*colors[i]); ok {
+			*field = rgb.Hex()
+		}
+	}
+	m.pendingTheme = &file
+	e.Naming = false
+	return true
+}
+
+// TakeThemeFile returns the theme file the editor saved since the last call
+func (m *Model) TakeThemeFile() (themes.File, bool) {
+	file := m.pendingTheme
+	m.pendingTheme = nil
+	if file == nil {
+		return themes.File{}, false
+	}
+	return *file, true
+}
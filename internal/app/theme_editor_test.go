@@ -0,0 +1,118 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/themes"
+)
+
+func TestThemePreview(t *testing.T) {
+	now := time.Now()
+	m := NewModel()
+	m.Layout.Recalculate(200, 40, m.Options.Mode, false)
+	m.toggleThemeMenu()
+	m.focusMenu()
+	current := m.ThemeID
+
+	// the focus of the menu shows its theme until esc
+	pressKey(m, tcell.KeyRight, now)
+	if m.Preview == "" || m.themeInUse() != m.Preview || m.ThemeID != current {
+		t.Fatalf("preview %q, drawn %q, theme %q", m.Preview, m.themeInUse(), m.ThemeID)
+	}
+	pressKey(m, tcell.KeyEsc, now)
+	if m.Preview != "" || m.themeInUse() != current {
+		t.Fatalf("esc left the preview %q", m.Preview)
+	}
+
+	// and so does the mouse over it
+	region := m.Layout.MenuRegions[len(m.Layout.MenuRegions)-2]
+	if !m.HandleHover(region.X, region.Y) || m.Preview != strings.TrimPrefix(region.ID, "theme:") {
+		t.Fatalf("hover on %s previews %q", region.ID, m.Preview)
+	}
+	m.closeMenus()
+	if m.themeInUse() != current {
+		t.Fatalf("closed menu draws %q", m.themeInUse())
+	}
+}
+
+func TestThemeEditor(t *testing.T) {
+	now := time.Now()
+	dir := t.TempDir()
+	LoadThemes(dir)
+	t.Cleanup(func() { LoadThemes("") })
+	m := NewModel()
+	m.Layout.Recalculate(160, 40, m.Options.Mode, false)
+	if !m.runAction(editThemeRegion, now) || m.View != ViewThemeEditor {
+		t.Fatal("the editor didn't open")
+	}
+	original := m.Editor.Theme
+
+	// text in the background colour can't be read
+	for m.Editor.Selected != colorText {
+		pressKey(m, tcell.KeyDown, now)
+	}
+	background, _ := colorRGB(original.Background)
+	pressKey(m, tcell.KeyEnter, now)
+	for _, r := range background.Hex() {
+		m.HandleKey(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone), now)
+	}
+	pressKey(m, tcell.KeyEnter, now)
+	warnings := lowContrast(m.Editor.Theme)
+	if m.Editor.Editing || m.Editor.Theme.Text != original.Background ||
+		len(warnings) == 0 || !strings.HasPrefix(warnings[0].String(), "text on background 1.0:1") {
+		t.Fatalf("text = %v, warnings %v", m.Editor.Theme.Text, warnings)
+	}
+	if m.drawnTheme() != m.Editor.Theme {
+		t.Fatal("the edits aren't drawn")
+	}
+	m.HandleKey(tcell.NewEventKey(tcell.KeyRune, 'd', tcell.ModNone), now)
+	if m.Editor.Theme.Text != original.Text {
+		t.Fatal("d didn't put the colour back")
+	}
+
+	// the lightness moves in steps
+	pressKey(m, tcell.KeyTab, now)
+	pressKey(m, tcell.KeyTab, now)
+	pressKey(m, tcell.KeyLeft, now)
+	before, _ := colorRGB(original.Text)
+	after, _ := colorRGB(m.Editor.Theme.Text)
+	_, _, was := before.HSL()
+	if _, _, l := after.HSL(); l >= was {
+		t.Fatalf("text %s after a step down from %s", after.Hex(), before.Hex())
+	}
+
+	// a built-in theme is saved as a copy next to it
+	m.HandleKey(tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone), now)
+	pressKey(m, tcell.KeyEnter, now)
+	file, ok := m.TakeThemeFile()
+	if want := original.ID + "-custom"; !ok || file.ID != want || file.Base != original.ID || file.Text != after.Hex() {
+		t.Fatalf("file = %+v, want %s", file, want)
+	}
+	// without a config path there is nowhere to save, the editor stays open
+	(&App{model: m}).saveTheme(file, now)
+	if m.View != ViewThemeEditor || !strings.Contains(m.UI.Message, "no themes directory") {
+		t.Fatalf("saved without a directory: view %v, %q", m.View, m.UI.Message)
+	}
+	a := &App{model: m, themesDir: dir}
+	a.saveTheme(file, now)
+	if _, err := os.Stat(filepath.Join(dir, file.ID+themes.Ext)); err != nil {
+		t.Fatal(err)
+	}
+	if m.View != ViewTyping || m.ThemeID != file.ID || ThemeByID(file.ID).Text != m.drawnTheme().Text {
+		t.Fatalf("view %v, theme %q", m.View, m.ThemeID)
+	}
+
+	// a built-in id is taken
+	m.OpenThemeEditor()
+	m.HandleKey(tcell.NewEventKey(tcell.KeyRune, 's', tcell.ModNone), now)
+	m.Editor.Input = []rune(original.ID)
+	pressKey(m, tcell.KeyEnter, now)
+	if _, ok := m.TakeThemeFile(); ok || !strings.Contains(m.UI.Message, "built-in") {
+		t.Fatalf("saved over %s: %q", original.ID, m.UI.Message)
+	}
+}
@@ -1,6 +1,9 @@
 package app
 
-import "github.com/gdamore/tcell/v2"
+import (
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/themes"
+)
 
 // template for theme option
 type Theme struct {
@@ -17,10 +20,14 @@ type Theme struct {
 	KeyText       tcell.Color
 	KeyActiveBg   tcell.Color
 	KeyActiveText tcell.Color
+	// read from a file in the themes directory, Base is the base of the
+	// file
+	User bool
+	Base string
 }
 
-// all theme options
-var themeOptions = []Theme{
+// the themes gotype comes with
+var builtinThemes = []Theme{
 	{
 		ID:            "rose-pine",
 		Label:         "rose-pine",
@@ -111,8 +118,83 @@ var themeOptions = []Theme{
 		KeyActiveBg:   hexColor(0x7fd1b9),
 		KeyActiveText: hexColor(0x0f1f1b),
 	},
+	// high contrast: the text and everything read on the background
+	// keep 7:1 (WCAG AAA)
+	{
+		ID:            "high-contrast-dark",
+		Label:         "high contrast dark",
+		Background:    hexColor(0x000000),
+		Panel:         hexColor(0x1a1a1a),
+		Text:          hexColor(0xffffff),
+		Dim:           hexColor(0xa0a0a0),
+		Accent:        hexColor(0xffd700),
+		Error:         hexColor(0xff7070),
+		CursorText:    hexColor(0x000000),
+		KeyBackground: hexColor(0x262626),
+		KeyText:       hexColor(0xffffff),
+		KeyActiveBg:   hexColor(0xffd700),
+		KeyActiveText: hexColor(0x000000),
+	},
+	{
+		ID:            "high-contrast-light",
+		Label:         "high contrast light",
+		Background:    hexColor(0xffffff),
+		Panel:         hexColor(0xebebeb),
+		Text:          hexColor(0x000000),
+		Dim:           hexColor(0x555555),
+		Accent:        hexColor(0x0040c0),
+		Error:         hexColor(0xb00020),
+		CursorText:    hexColor(0xffffff),
+		KeyBackground: hexColor(0xe0e0e0),
+		KeyText:       hexColor(0x000000),
+		KeyActiveBg:   hexColor(0x0040c0),
+		KeyActiveText: hexColor(0xffffff),
+	},
+	// colour-blind safe: blue and orange from the Okabe-Ito palette, which
+	// stay apart with every kind of colour blindness, in place of green
+	// and red
+	{
+		ID:            "colorblind-dark",
+		Label:         "colour-blind dark",
+		Background:    hexColor(0x1b1d23),
+		Panel:         hexColor(0x22252c),
+		Text:          hexColor(0xe8e8e8),
+		Dim:           hexColor(0x8a8f98),
+		Accent:        hexColor(0x56b4e9),
+		Error:         hexColor(0xe69f00),
+		CursorText:    hexColor(0x1b1d23),
+		KeyBackground: hexColor(0x2a2e36),
+		KeyText:       hexColor(0xe8e8e8),
+		KeyActiveBg:   hexColor(0x56b4e9),
+		KeyActiveText: hexColor(0x1b1d23),
+	},
+	{
+		ID:            "colorblind-light",
+		Label:         "colour-blind light",
+		Background:    hexColor(0xfafafa),
+		Panel:         hexColor(0xececec),
+		Text:          hexColor(0x1f1f1f),
+		Dim:           hexColor(0x6b6b6b),
+		Accent:        hexColor(0x0072b2),
+		Error:         hexColor(0xd55e00),
+		CursorText:    hexColor(0xfafafa),
+		KeyBackground: hexColor(0xdedede),
+		KeyText:       hexColor(0x1f1f1f),
+		KeyActiveBg:   hexColor(0x0072b2),
+		KeyActiveText: hexColor(0xfafafa),
+	},
+	// no colours of its own, the model draws the light or the dark theme
+	// of the config in its place
+	{
+		ID:    themes.AutoID,
+		Label: "auto",
+	},
 }
 
+// all theme options, the built-in ones and then the user themes, see
+// LoadThemes
+var themeOptions = builtinThemes
+
 const themeRegionPrefix = "theme:"
 
 // return all themes
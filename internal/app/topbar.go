@@ -0,0 +1,149 @@
+package app
+
+import (
+	"slices"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// keyboard focus on the top bar, Menu moves it to the menu row under the
+// bar. Index is the focused region of the row.
+type BarFocus struct {
+	Active bool
+	Menu   bool
+	Index  int
+	// the focused button of the bar while the menu row has the focus
+	button int
+}
+
+// move the keyboard focus to the top bar or back to the test, the bar is
+// hidden while a test runs
+func (m *Model) focusTopBar() bool {
+	if m.Bar.Active {
+		m.Bar = BarFocus{}
+		return true
+	}
+	if m.focusActive() {
+		return false
+	}
+	m.Bar = BarFocus{Active: true}
+	return true
+}
+
+// the regions of the focused row
+func (m *Model) barRegions() []Region {
+	if m.Bar.Menu {
+		return m.Layout.MenuRegions
+	}
+	return m.Layout.Regions
+}
+
+// keys while the top bar has the focus: arrows move, enter runs the
+// focused action and esc or the top bar key goes back to the test. Other
+// keys are not taken.
+func (m *Model) handleBarKey(event *tcell.EventKey, now time.Time) bool {
+	if m.boundTo(event, "top-bar") {
+		m.Bar = BarFocus{}
+		return true
+	}
+	switch event.Key() {
+	case tcell.KeyEsc:
+		m.Bar = BarFocus{}
+		return true
+	case tcell.KeyLeft:
+		m.moveBar(-1)
+		return true
+	case tcell.KeyRight:
+		m.moveBar(1)
+		return true
+	case tcell.KeyDown:
+		if !m.Bar.Menu && m.Layout.MenuOpen && len(m.Layout.MenuRegions) > 0 {
+			m.focusMenu()
+		}
+		return true
+	case tcell.KeyUp:
+		if m.Bar.Menu {
+			m.Bar = BarFocus{Active: true, Index: m.Bar.button}
+		}
+		return true
+	case tcell.KeyEnter:
+		regions := m.barRegions()
+		if len(regions) == 0 {
+			return true
+		}
+		menuOpen := m.Layout.MenuOpen
+		m.runAction(regions[m.Bar.Index].ID, now)
+		switch {
+		// typing a name or a length needs the keys
+		case m.EditingCustom || m.NamingPreset:
+			m.Bar = BarFocus{}
+		// a menu that opened gets the focus
+		case m.Layout.MenuOpen && !menuOpen && !m.Bar.Menu:
+			m.focusMenu()
+		// and gives it back to its button when it closes
+		case !m.Layout.MenuOpen && m.Bar.Menu:
+			m.Bar = BarFocus{Active: true, Index: m.Bar.button}
+		}
+		m.clampBar()
+		return true
+	}
+	return false
+}
+
+// move the focus to the menu row, the theme menu starts on the current
+// theme
+func (m *Model) focusMenu() {
+	m.Bar = BarFocus{Active: true, Menu: true, button: m.Bar.Index}
+	if m.ThemeMenu {
+		current := ThemeRegionID(m.ThemeID)
+		m.Bar.Index = max(slices.IndexFunc(m.Layout.MenuRegions, func(r Region) bool { return r.ID == current }), 0)
+	}
+}
+
+// the theme the focus of the theme menu is on is shown until it is picked,
+// esc or leaving the menu row goes back to the current one
+func (m *Model) syncBarPreview() {
+	id := ""
+	if regions := m.barRegions(); m.Bar.Active && m.Bar.Menu && m.ThemeMenu && m.Bar.Index < len(regions) {
+		id = regions[m.Bar.Index].ID
+	}
+	m.previewRegion(id)
+}
+
+// preview the theme of a theme menu region, any other region shows the
+// current theme again. It reports if the drawn theme changed.
+func (m *Model) previewRegion(id string) bool {
+	preview, ok := ThemeIDFromRegion(id)
+	if !ok || id == editThemeRegion || preview == m.ThemeID {
+		preview = ""
+	}
+	if m.Preview == preview {
+		return false
+	}
+	m.Preview = preview
+	return true
+}
+
+// move the focus along the row, it wraps at the ends
+func (m *Model) moveBar(step int) {
+	count := len(m.barRegions())
+	if count == 0 {
+		return
+	}
+	m.Bar.Index = (m.Bar.Index + step + count) % count
+}
+
+// keep the focus on a region after the row changed
+func (m *Model) clampBar() {
+	count := len(m.barRegions())
+	if m.Bar.Index >= count {
+		m.Bar.Index = max(count-1, 0)
+	}
+}
+
+// BarFocused tells if the region at index of the bar or the menu row has
+// the keyboard focus
+func (m *Model) BarFocused(menu bool, index int) bool {
+	return m.Bar.Active && m.Bar.Menu == menu && m.Bar.Index == index
+}
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/themes"
+)
+
+// LoadThemes reads the user themes in dir and puts them after the built-in
+// ones, broken files are left out and named in the error
+func LoadThemes(dir string) error {
+	themeOptions = builtinThemes
+	if dir == "" {
+		return nil
+	}
+	files, err := themes.Load(dir)
+	user, resolveErr := resolveThemes(files)
+	themeOptions = slices.Concat(builtinThemes, user)
+	return errors.Join(err, resolveErr)
+}
+
+// the themes of the files over their bases, a base can be a built-in theme
+// or another file. Files with a missing base, a base loop or the id of a
+// built-in theme are left out.
+func resolveThemes(files []themes.File) ([]Theme, error) {
+	isBuiltin := func(id string) bool {
+		return slices.ContainsFunc(builtinThemes, func(t Theme) bool { return t.ID == id })
+	}
+	byID := map[string]themes.File{}
+	for _, file := range files {
+		if !isBuiltin(file.ID) {
+			byID[file.ID] = file
+		}
+	}
+	resolved := map[string]Theme{}
+	var resolve func(id string, seen []string) (Theme, error)
+	resolve = func(id string, seen []string) (Theme, error) {
+		if theme, ok := resolved[id]; ok {
+			return theme, nil
+		}
+		file, ok := byID[id]
+		if !ok {
+			if index := slices.IndexFunc(builtinThemes, func(t Theme) bool { return t.ID == id }); index >= 0 {
+				return builtinThemes[index], nil
+			}
+			return Theme{}, fmt.Errorf("unknown base theme %q", id)
+		}
+		if slices.Contains(seen, id) {
+			return Theme{}, fmt.Errorf("base themes loop: %s", strings.Join(append(seen, id), " -> "))
+		}
+		base := DefaultThemeID()
+		if file.Base != "" {
+			base = file.Base
+		}
+		if base == themes.AutoID {
+			return Theme{}, fmt.Errorf("%q has no colours to start from, pick a theme as the base", base)
+		}
+		theme, err := resolve(base, append(seen, id))
+		if err != nil {
+			return Theme{}, err
+		}
+		theme.ID = file.ID
+		theme.Label = file.ID
+		if file.Label != "" {
+			theme.Label = file.Label
+		}
+		theme.User = true
+		theme.Base = file.Base
+		colors := theme.colors()
+		for i, color := range file.Colors() {
+			if *color != "" {
+				*colors[i] = tcell.GetColor(*color)
+			}
+		}
+		resolved[id] = theme
+		return theme, nil
+	}
+
+	var user []Theme
+	var errs []error
+	for _, file := range files {
+		if isBuiltin(file.ID) {
+			errs = append(errs, fmt.Errorf("%s%s: %q is a built-in theme, pick another file name", file.ID, themes.Ext, file.ID))
+			continue
+		}
+		theme, err := resolve(file.ID, nil)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s%s: %w", file.ID, themes.Ext, err))
+			continue
+		}
+		user = append(user, theme)
+	}
+	return user, errors.Join(errs...)
+}
+
+// the colours of the theme in the order of themes.ColorKeys
+func (t *Theme) colors() []*tcell.Color {
+	return []*tcell.Color{
+		&t.Background, &t.Panel, &t.Text, &t.Dim, &t.Accent, &t.Error,
+		&t.CursorText, &t.KeyBackground, &t.KeyText, &t.KeyActiveBg, &t.KeyActiveText,
+	}
+}
@@ -0,0 +1,94 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+func TestUserThemes(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"night.toml":  "label = \"night owl\"\nbase = \"forest\"\naccent = \"#82aaff\"\n",
+		"darker.toml": "base = \"night\"\nbackground = \"#000000\"\n",
+		"forest.toml": "accent = \"#ffffff\"\n",
+		"orphan.toml": "base = \"nowhere\"\n",
+		"loop-a.toml": "base = \"loop-b\"\n",
+		"loop-b.toml": "base = \"loop-a\"\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := LoadThemes(dir)
+	t.Cleanup(func() { LoadThemes("") })
+	for _, want := range []string{
+		`forest.toml: "forest" is a built-in theme`,
+		`orphan.toml: unknown base theme "nowhere"`,
+		`loop-a.toml: base themes loop: loop-a -> loop-b -> loop-a`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error %v, want %q", err, want)
+		}
+	}
+	if len(ThemeOptions()) != len(builtinThemes)+2 {
+		t.Fatalf("%d themes", len(ThemeOptions()))
+	}
+
+	// missing colours come from the base, through other user themes too
+	forest := ThemeByID("forest")
+	darker := ThemeByID("darker")
+	if darker.ID != "darker" || darker.Label != "darker" || !darker.User ||
+		darker.Accent != tcell.GetColor("#82aaff") || darker.Background != tcell.GetColor("#000000") ||
+		darker.Text != forest.Text {
+		t.Fatalf("darker = %+v", darker)
+	}
+
+	// the saved id of a user theme loads again
+	m := NewModel()
+	applyPreferences(m, storage.Preferences{ThemeID: "night"})
+	if m.ThemeID != "night" || ThemeLabel(m.ThemeID) != "night owl" {
+		t.Fatalf("theme = %q", m.ThemeID)
+	}
+}
+
+func TestThemesImport(t *testing.T) {
+	testConfigDir(t)
+	path := filepath.Join(t.TempDir(), "serika_dark.css")
+	css := ":root { --bg-color: #323437; --main-color: #e2b714; --sub-color: #646669; --text-color: #d1d0c5; }"
+	if err := os.WriteFile(path, []byte(css), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) (int, string) {
+		var stdout, stderr bytes.Buffer
+		code := Main(args, &stdout, &stderr)
+		return code, stdout.String() + stderr.String()
+	}
+	if code, output := run("themes", "import", path); code != ExitOK || !strings.Contains(output, "imported serika-dark") {
+		t.Fatalf("import = %d %q", code, output)
+	}
+	if code, output := run("themes", "import", path); code != ExitError || !strings.Contains(output, "--force replaces it") {
+		t.Fatalf("second import = %d %q", code, output)
+	}
+	// the labels line up on the longest id
+	code, output := run("themes")
+	column := func(prefix, label string) int {
+		for _, line := range strings.Split(output, "\n") {
+			if strings.HasPrefix(line, prefix) {
+				return strings.Index(line, label)
+			}
+		}
+		return -1
+	}
+	if at := column("  serika-dark ", "serika_dark (user)"); code != ExitOK || at < 0 || at != column("  high-contrast-light ", "high contrast light") {
+		t.Fatalf("themes = %d %q", code, output)
+	}
+	if code, output := run("themes", "import", "--name", "forest", path); code != ExitError || !strings.Contains(output, "a built-in theme has this id") {
+		t.Fatalf("import as forest = %d %q", code, output)
+	}
+}
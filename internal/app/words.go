@@ -10,6 +10,9 @@ type Generator struct {
 	words []string
 	punct []string
 	rnd   *rand.Rand
+	// a text to type word by word instead of random words, see UseText
+	text []string
+	next int
 }
 
 func NewGenerator(source rand.Source) *Generator {
@@ -20,6 +23,25 @@ func NewGenerator(source rand.Source) *Generator {
 	}
 }
 
+// Reseed restarts the random sequence so the same seed always builds the
+// same text for the same options
+func (g *Generator) Reseed(seed int64) {
+	g.rnd = rand.New(rand.NewSource(seed))
+	g.next = 0
+}
+
+// UseText makes every test type these words in order, starting over when
+// they run out, the punctuation and numbers options don't apply
+func (g *Generator) UseText(words []string) {
+	g.text = words
+	g.next = 0
+}
+
+// CustomText reports if the words come from UseText
+func (g *Generator) CustomText() bool {
+	return len(g.text) > 0
+}
+
 func (g *Generator) Build(count int, opts Options) []rune {
 	if count <= 0 {
 		return nil
@@ -46,6 +68,11 @@ func (g *Generator) Extend(target []rune, count int, opts Options) []rune {
 }
 
 func (g *Generator) nextWord(opts Options) string {
+	if len(g.text) > 0 {
+		word := g.text[g.next%len(g.text)]
+		g.next++
+		return word
+	}
 	if opts.Numbers && g.rnd.Intn(10) == 0 {
 		return strconv.Itoa(g.rnd.Intn(9999) + 1)
 	}
@@ -0,0 +1,272 @@
+// Package config reads the gotype config file, config.toml in the gotype
+// config directory. Every setting is optional and has a default, the
+// environment (GOTYPE_<NAME>) overrides the file and the command line
+// flags (--<name>) override both:
+//
+//	profile = "default"
+//	backend = "json"
+//
+//	[display]
+//	visible_lines = 3
+//	words_per_line = 10
+//	plain_text = true
+//	key_highlight = "450ms"
+//	message_time = "2s"
+//	warning_time = "10s"
+//	colors = "auto"
+//	light_theme = "light"
+//	dark_theme = "rose-pine"
+//
+//	[timing]
+//	tick = "80ms"
+//	watch = "1s"
+//
+//	[selectors]
+//	durations = ["30s", "60s", "10m", "30m"]
+//	word_counts = [10, 25, 50, 100]
+//
+//	[keys]
+//	preset = "default"
+//
+//	[keys.bind]
+//	"ctrl+r" = "restart"
+//
+// The durations and the word counts are paired into the selector buttons
+// of the top bar, the first duration shares a button with the first word
+// count and so on.
+//
+// The keys start from a keymap preset and bind changes single chords, see
+// the keymap package for the chords and the actions.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yossefsabry/gotype/internal/keymap"
+	"github.com/yossefsabry/gotype/internal/storage"
+	"github.com/yossefsabry/gotype/internal/themes"
+)
+
+// PathEnv points at another config file, like --config
+const PathEnv = "GOTYPE_CONFIG"
+
+// Config is everything the config file can set
+type Config struct {
+	Profile   string    `toml:"profile"`
+	Backend   string    `toml:"backend"`
+	Display   Display   `toml:"display"`
+	Timing    Timing    `toml:"timing"`
+	Selectors Selectors `toml:"selectors"`
+	Keys      Keys      `toml:"keys"`
+}
+
+// how the test is drawn
+type Display struct {
+	// lines of text shown while typing
+	VisibleLines int `toml:"visible_lines"`
+	WordsPerLine int `toml:"words_per_line"`
+	// draw the text as normal characters instead of big blocks
+	PlainText bool `toml:"plain_text"`
+	// how long the last key stays lit on the keyboard
+	KeyHighlight Duration `toml:"key_highlight"`
+	// how long footer messages and warnings stay
+	MessageTime Duration `toml:"message_time"`
+	WarningTime Duration `toml:"warning_time"`
+	// the colours of the terminal, see ColorModes
+	Colors string `toml:"colors"`
+	// the themes of the auto theme on a light and a dark terminal
+	LightTheme string `toml:"light_theme"`
+	DarkTheme  string `toml:"dark_theme"`
+}
+
+// ColorModes are the values of display.colors: auto asks the terminal (and
+// honours NO_COLOR), none draws without colours and the others draw with
+// that many colours whatever it says
+var ColorModes = []string{"auto", "truecolor", "256", "16", "8", "none"}
+
+// how often the app wakes up
+type Timing struct {
+	// redraw interval of the timer and the live stats
+	Tick Duration `toml:"tick"`
+	// how often to look for changes by other windows and to this file
+	Watch Duration `toml:"watch"`
+}
+
+// the selector buttons of the top bar
+type Selectors struct {
+	Durations  []Duration `toml:"durations"`
+	WordCounts []int      `toml:"word_counts"`
+}
+
+// the keymap, a preset and the chords bound over it
+type Keys struct {
+	Preset string            `toml:"preset"`
+	Bind   map[string]string `toml:"bind"`
+}
+
+// Duration is a time.Duration written as "80ms" or "10m" in the file
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", text)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// String is time.Duration's without the zero units, 10m instead of 10m0s
+func (d Duration) String() string {
+	s := time.Duration(d).String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// Default is the config without a file, the values gotype always used
+func Default() Config {
+	return Config{
+		Profile: storage.DefaultProfile,
+		Backend: "json",
+		Display: Display{
+			VisibleLines: 3,
+			WordsPerLine: 10,
+			PlainText:    true,
+			KeyHighlight: Duration(450 * time.Millisecond),
+			MessageTime:  Duration(2 * time.Second),
+			WarningTime:  Duration(10 * time.Second),
+			Colors:       "auto",
+			LightTheme:   "light",
+			DarkTheme:    "rose-pine",
+		},
+		Timing: Timing{
+			Tick:  Duration(80 * time.Millisecond),
+			Watch: Duration(time.Second),
+		},
+		Selectors: Selectors{
+			Durations: []Duration{
+				Duration(30 * time.Second),
+				Duration(60 * time.Second),
+				Duration(10 * time.Minute),
+				Duration(30 * time.Minute),
+			},
+			WordCounts: []int{10, 25, 50, 100},
+		},
+		Keys: Keys{Preset: keymap.DefaultPreset},
+	}
+}
+
+// DefaultPath is config.toml in the gotype config directory
+func DefaultPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "gotype", "config.toml"), nil
+}
+
+// Validate checks every value is usable, the error names the setting
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: "+format, append([]any{key}, args...)...))
+		}
+	}
+	if err := storage.ValidProfileName(c.Profile); err != nil {
+		errs = append(errs, fmt.Errorf("profile: %w", err))
+	}
+	check(slices.Contains(storage.Backends, c.Backend), "backend",
+		"unknown backend %q (want %s)", c.Backend, strings.Join(storage.Backends, " or "))
+	checkRange(check, "display.visible_lines", c.Display.VisibleLines, 1, 20)
+	checkRange(check, "display.words_per_line", c.Display.WordsPerLine, 1, 50)
+	checkDuration(check, "display.key_highlight", c.Display.KeyHighlight, 0, 5*time.Second)
+	checkDuration(check, "display.message_time", c.Display.MessageTime, 100*time.Millisecond, time.Minute)
+	checkDuration(check, "display.warning_time", c.Display.WarningTime, 100*time.Millisecond, 5*time.Minute)
+	check(slices.Contains(ColorModes, c.Display.Colors), "display.colors",
+		"unknown colour mode %q (want %s)", c.Display.Colors, strings.Join(ColorModes, ", "))
+	// user themes are loaded after the config, an unknown id falls back
+	// to the first theme like a saved one does
+	checkTheme := func(key, id string) {
+		if err := themes.ValidID(id); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+		check(id != themes.AutoID, key, "%q picks between these two, use another theme", id)
+	}
+	checkTheme("display.light_theme", c.Display.LightTheme)
+	checkTheme("display.dark_theme", c.Display.DarkTheme)
+	checkDuration(check, "timing.tick", c.Timing.Tick, 10*time.Millisecond, time.Second)
+	checkDuration(check, "timing.watch", c.Timing.Watch, 100*time.Millisecond, time.Minute)
+
+	durations, counts := c.Selectors.Durations, c.Selectors.WordCounts
+	check(len(durations) >= 1 && len(durations) <= 8, "selectors.durations",
+		"needs 1 to 8 values, got %d", len(durations))
+	check(len(counts) == len(durations), "selectors.word_counts",
+		"needs one value per duration (%d), got %d", len(durations), len(counts))
+	for i, duration := range durations {
+		key := fmt.Sprintf("selectors.durations[%d]", i)
+		checkDuration(check, key, duration, time.Second, storage.MaxDurationSeconds*time.Second)
+		check(time.Duration(duration)%time.Second == 0, key, "must be whole seconds, got %s", time.Duration(duration))
+		check(!slices.Contains(durations[:i], duration), key, "%s is listed twice", time.Duration(duration))
+	}
+	for i, count := range counts {
+		checkRange(check, fmt.Sprintf("selectors.word_counts[%d]", i), count, 1, storage.MaxWordCount)
+	}
+	// every bad binding is its own error
+	if _, err := keymap.Build(c.Keys.Preset, c.Keys.Bind); err != nil {
+		for _, err := range unjoin(err) {
+			errs = append(errs, fmt.Errorf("keys: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// the errors of errors.Join one by one
+func unjoin(err error) []error {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
+func checkRange(check func(bool, string, string, ...any), key string, value, low, high int) {
+	check(value >= low && value <= high, key, "must be between %d and %d, got %d", low, high, value)
+}
+
+func checkDuration(check func(bool, string, string, ...any), key string, value Duration, low, high time.Duration) {
+	d := time.Duration(value)
+	check(d >= low && d <= high, key, "must be between %s and %s, got %s", low, high, d)
+}
+
+// decode reads the file at path over c, unknown settings are errors so a
+// typo doesn't go unnoticed
+func decode(path string, c *Config) error {
+	meta, err := toml.DecodeFile(path, c)
+	if err != nil {
+		return err
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return fmt.Errorf("unknown setting %s", strings.Join(keys, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testLoader(path string, env map[string]string) *Loader {
+	return &Loader{Path: path, Getenv: func(name string) string { return env[name] }}
+}
+
+func TestLoadDefaultsWithoutFile(t *testing.T) {
+	cfg, err := testLoader(filepath.Join(t.TempDir(), "config.toml"), nil).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Display.VisibleLines != 3 || time.Duration(cfg.Timing.Tick) != 80*time.Millisecond ||
+		len(cfg.Selectors.Durations) != 4 || cfg.Backend != "json" {
+		t.Fatalf("defaults = %+v", cfg)
+	}
+}
+
+func TestLoadOverrides(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	writeConfig(t, path, `
+profile = "sam"
+
+[display]
+visible_lines = 5
+words_per_line = 8
+
+[timing]
+tick = "50ms"
+
+[selectors]
+durations = ["15s", "2m"]
+word_counts = [5, 40]
+`)
+	env := map[string]string{"GOTYPE_VISIBLE_LINES": "4", "GOTYPE_TICK": "100ms"}
+	loader := testLoader(path, env)
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader.RegisterFlags(flags)
+	if err := flags.Parse([]string{"--tick", "40ms"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the environment beats the file and the flags beat both
+	if cfg.Profile != "sam" || cfg.Display.WordsPerLine != 8 || cfg.Display.VisibleLines != 4 ||
+		time.Duration(cfg.Timing.Tick) != 40*time.Millisecond {
+		t.Fatalf("config = %+v", cfg)
+	}
+	if len(cfg.Selectors.Durations) != 2 || time.Duration(cfg.Selectors.Durations[1]) != 2*time.Minute {
+		t.Fatalf("selectors = %+v", cfg.Selectors)
+	}
+	// untouched settings keep their defaults
+	if time.Duration(cfg.Display.KeyHighlight) != 450*time.Millisecond {
+		t.Fatalf("key highlight = %v", cfg.Display.KeyHighlight)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	for name, tc := range map[string]struct {
+		file string
+		env  map[string]string
+		want string
+	}{
+		"unknown key":   {file: "[display]\nvisible_line = 4\n", want: "unknown setting display.visible_line"},
+		"syntax":        {file: "[display\n", want: "toml: line 2"},
+		"bad duration":  {file: "[timing]\ntick = \"fast\"\n", want: `invalid duration "fast"`},
+		"range":         {file: "[display]\nvisible_lines = 0\n", want: "display.visible_lines: must be between 1 and 20, got 0"},
+		"pairs":         {file: "[selectors]\ndurations = [\"30s\"]\n", want: "selectors.word_counts: needs one value per duration (1), got 4"},
+		"backend":       {file: "backend = \"sqlite\"\n", want: `unknown backend "sqlite"`},
+		"env":           {env: map[string]string{"GOTYPE_PLAIN_TEXT": "maybe"}, want: `GOTYPE_PLAIN_TEXT: invalid boolean "maybe"`},
+		"env validated": {env: map[string]string{"GOTYPE_DURATIONS": "30s,30s", "GOTYPE_WORD_COUNTS": "1,2"}, want: "30s is listed twice"},
+		"binding":       {file: "[keys.bind]\n\"ctrl+x\" = \"explode\"\n", want: `keys: "ctrl+x": unknown action "explode"`},
+		"keymap":        {env: map[string]string{"GOTYPE_KEYMAP": "emacs"}, want: `keys: unknown keymap preset "emacs"`},
+		"colors":        {env: map[string]string{"GOTYPE_COLORS": "4"}, want: `display.colors: unknown colour mode "4"`},
+		"auto theme":    {file: "[display]\ndark_theme = \"auto\"\n", want: `display.dark_theme: "auto" picks between these two`},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name+".toml")
+			if tc.file != "" {
+				writeConfig(t, path, tc.file)
+			}
+			_, err := testLoader(path, tc.env).Load()
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Fatalf("error = %v, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestChanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	loader := testLoader(path, nil)
+	if _, err := loader.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if loader.Changed() {
+		t.Fatal("changed without a file")
+	}
+	writeConfig(t, path, "[display]\nvisible_lines = 6\n")
+	if !loader.Changed() {
+		t.Fatal("new file not noticed")
+	}
+	cfg, err := loader.Load()
+	if err != nil || cfg.Display.VisibleLines != 6 || loader.Changed() {
+		t.Fatalf("reload: %+v %v", cfg.Display, err)
+	}
+}
@@ -0,0 +1,251 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting is a value the environment and the flags can override, name is
+// the flag (with - for _) and GOTYPE_<NAME> is the variable
+type setting struct {
+	name  string
+	usage string
+	set   func(c *Config, value string) error
+}
+
+var settings = []setting{
+	{"profile", "profile to use", func(c *Config, value string) error {
+		c.Profile = value
+		return nil
+	}},
+	{"backend", "storage backend: json or bolt", func(c *Config, value string) error {
+		c.Backend = value
+		return nil
+	}},
+	{"visible_lines", "lines of text shown while typing", intSetting(func(c *Config) *int { return &c.Display.VisibleLines })},
+	{"words_per_line", "words on one line of text", intSetting(func(c *Config) *int { return &c.Display.WordsPerLine })},
+	{"plain_text", "draw the text as normal characters", boolSetting(func(c *Config) *bool { return &c.Display.PlainText })},
+	{"key_highlight", "how long the last key stays lit", durationSetting(func(c *Config) *Duration { return &c.Display.KeyHighlight })},
+	{"message_time", "how long footer messages stay", durationSetting(func(c *Config) *Duration { return &c.Display.MessageTime })},
+	{"warning_time", "how long footer warnings stay", durationSetting(func(c *Config) *Duration { return &c.Display.WarningTime })},
+	{"colors", "colours of the terminal: auto, truecolor, 256, 16, 8 or none", func(c *Config, value string) error {
+		c.Display.Colors = value
+		return nil
+	}},
+	{"light_theme", "theme of the auto theme on a light terminal", func(c *Config, value string) error {
+		c.Display.LightTheme = value
+		return nil
+	}},
+	{"dark_theme", "theme of the auto theme on a dark terminal", func(c *Config, value string) error {
+		c.Display.DarkTheme = value
+		return nil
+	}},
+	{"tick", "redraw interval of the timer", durationSetting(func(c *Config) *Duration { return &c.Timing.Tick })},
+	{"watch", "how often to look for changes by other windows", durationSetting(func(c *Config) *Duration { return &c.Timing.Watch })},
+	{"keymap", "keymap preset: default, monkeytype or vim-ish", func(c *Config, value string) error {
+		c.Keys.Preset = value
+		return nil
+	}},
+	{"durations", "comma separated selector durations", func(c *Config, value string) error {
+		var durations []Duration
+		for _, field := range splitList(value) {
+			var d Duration
+			if err := d.UnmarshalText([]byte(field)); err != nil {
+				return err
+			}
+			durations = append(durations, d)
+		}
+		c.Selectors.Durations = durations
+		return nil
+	}},
+	{"word_counts", "comma separated selector word counts", func(c *Config, value string) error {
+		var counts []int
+		for _, field := range splitList(value) {
+			count, err := strconv.Atoi(field)
+			if err != nil {
+				return fmt.Errorf("invalid number %q", field)
+			}
+			counts = append(counts, count)
+		}
+		c.Selectors.WordCounts = counts
+		return nil
+	}},
+}
+
+func intSetting(field func(*Config) *int) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		*field(c) = n
+		return nil
+	}
+}
+
+func boolSetting(field func(*Config) *bool) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		*field(c) = b
+		return nil
+	}
+}
+
+func durationSetting(field func(*Config) *Duration) func(*Config, string) error {
+	return func(c *Config, value string) error {
+		return field(c).UnmarshalText([]byte(value))
+	}
+}
+
+func splitList(value string) []string {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func envName(name string) string {
+	return "GOTYPE_" + strings.ToUpper(name)
+}
+
+func flagName(name string) string {
+	return strings.ReplaceAll(name, "_", "-")
+}
+
+// Loader builds the config from the file, the environment and the flags,
+// and is kept around to reload the file when it changes
+type Loader struct {
+	// Path is the config file, a missing file means the defaults
+	Path string
+	// Getenv reads the environment, tests replace it
+	Getenv func(string) string
+	// flag values by setting name, in the order they were given
+	flags []override
+	// the file as it was last loaded
+	stamp stamp
+}
+
+type override struct {
+	name, value string
+}
+
+// what Changed compares, a missing file has the zero stamp
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewLoader reads the file named by GOTYPE_CONFIG or the default one
+func NewLoader() *Loader {
+	loader := &Loader{Getenv: os.Getenv}
+	if path := os.Getenv(PathEnv); path != "" {
+		loader.Path = path
+	} else if path, err := DefaultPath(); err == nil {
+		loader.Path = path
+	}
+	return loader
+}
+
+// RegisterFlags adds --config and a flag for every setting to flags
+func (l *Loader) RegisterFlags(flags *flag.FlagSet) {
+	flags.Func("config", "config file (default "+l.Path+")", func(value string) error {
+		l.Path = value
+		return nil
+	})
+	for _, s := range settings {
+		flags.Func(flagName(s.name), s.usage, func(value string) error {
+			// check the value now so a typo is reported by the flag parser
+			scratch := Default()
+			if err := s.set(&scratch, value); err != nil {
+				return err
+			}
+			l.flags = append(l.flags, override{s.name, value})
+			return nil
+		})
+	}
+}
+
+// Load reads the defaults, then the file, the environment and the flags,
+// and validates the result
+func (l *Loader) Load() (Config, error) {
+	cfg := Default()
+	l.stamp = stampOf(l.Path)
+	if l.Path != "" {
+		if _, err := os.Stat(l.Path); err == nil {
+			if err := decode(l.Path, &cfg); err != nil {
+				return Config{}, fmt.Errorf("%s: %w", l.Path, err)
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return Config{}, err
+		}
+	}
+	getenv := l.Getenv
+	if getenv == nil {
+		getenv = os.Getenv
+	}
+	for _, s := range settings {
+		if value := getenv(envName(s.name)); value != "" {
+			if err := s.set(&cfg, value); err != nil {
+				return Config{}, fmt.Errorf("%s: %w", envName(s.name), err)
+			}
+		}
+	}
+	for _, flag := range l.flags {
+		for _, s := range settings {
+			if s.name == flag.name {
+				if err := s.set(&cfg, flag.value); err != nil {
+					return Config{}, &InvalidError{fmt.Errorf("--%s: %w", flagName(s.name), err)}
+				}
+			}
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, &InvalidError{fmt.Errorf("config: %w", err)}
+	}
+	return cfg, nil
+}
+
+// InvalidError is a flag value that does not parse or a config that does
+// not validate, as opposed to a config file that cannot be read
+type InvalidError struct {
+	Err error
+}
+
+func (e *InvalidError) Error() string { return e.Err.Error() }
+
+func (e *InvalidError) Unwrap() error { return e.Err }
+
+// ThemesDir is the themes directory next to the config file, empty without
+// a config file
+func (l *Loader) ThemesDir() string {
+	if l.Path == "" {
+		return ""
+	}
+	return filepath.Join(filepath.Dir(l.Path), "themes")
+}
+
+// Changed reports if the file was written, created or removed since the
+// last Load
+func (l *Loader) Changed() bool {
+	return l.Path != "" && stampOf(l.Path) != l.stamp
+}
+
+func stampOf(path string) stamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{modTime: info.ModTime(), size: info.Size()}
+}
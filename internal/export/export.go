@@ -0,0 +1,164 @@
+// Package export writes the run history in formats other tools can read.
+//
+// Three formats are supported:
+//
+//   - jsonl: one run per line, the same objects as the history file
+//     including per-key stats ("keys"), per-second samples ("samples")
+//     and the key log ("log").
+//   - json: a single object {"format": "gotype-history", "version": 1,
+//     "runs": [...]} holding the same run objects as jsonl.
+//   - csv: one row per run with the options and metrics only, see
+//     csvHeader for the columns. Times are RFC 3339 in UTC.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// Version of the json export, bumped when fields are renamed or removed
+const Version = 1
+
+// Formats lists the supported format names
+var Formats = []string{"csv", "json", "jsonl"}
+
+// columns of the csv export
+var csvHeader = []string{
+	"id", "key", "started_at", "ended_at", "mode", "duration_seconds",
+	"word_count", "punctuation", "numbers", "language", "formula", "seed",
+	"wpm", "raw_wpm", "cpm", "raw_cpm", "accuracy", "consistency",
+	"correct", "incorrect", "keystrokes",
+}
+
+// Source is the history the runs are read from
+type Source interface {
+	Each(keep func(storage.Summary) bool, fn func(storage.Run) error) error
+}
+
+// Filter picks which runs are exported, zero values keep everything
+type Filter struct {
+	Since time.Time
+	Key   string
+}
+
+// keep reports if a run passes the filter
+func (f Filter) keep(run storage.Summary) bool {
+	if !f.Since.IsZero() && run.StartedAt < f.Since.UnixMilli() {
+		return false
+	}
+	if f.Key != "" && run.Key != f.Key {
+		return false
+	}
+	return true
+}
+
+// Write streams the runs that pass the filter in the given format
+func Write(w io.Writer, format string, source Source, filter Filter) error {
+	switch format {
+	case "jsonl":
+		return writeJSONL(w, source, filter)
+	case "json":
+		return writeJSON(w, source, filter)
+	case "csv":
+		return writeCSV(w, source, filter)
+	}
+	return fmt.Errorf("unknown export format %q (want csv, json or jsonl)", format)
+}
+
+func writeJSONL(w io.Writer, source Source, filter Filter) error {
+	encoder := json.NewEncoder(w)
+	return source.Each(filter.keep, func(run storage.Run) error {
+		return encoder.Encode(run)
+	})
+}
+
+// the runs are written one by one so the whole history is never in memory
+func writeJSON(w io.Writer, source Source, filter Filter) error {
+	if _, err := fmt.Fprintf(w, "{\"format\":\"gotype-history\",\"version\":%d,\"runs\":[", Version); err != nil {
+		return err
+	}
+	first := true
+	err := source.Each(filter.keep, func(run storage.Run) error {
+		raw, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		if !first {
+			if _, err := io.WriteString(w, ",\n"); err != nil {
+				return err
+			}
+		} else if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+		first = false
+		_, err = w.Write(raw)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n]}\n")
+	return err
+}
+
+func writeCSV(w io.Writer, source Source, filter Filter) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	err := source.Each(filter.keep, func(run storage.Run) error {
+		options := run.Options
+		metrics := run.Metrics
+		return writer.Write([]string{
+			run.ID,
+			run.Key,
+			formatTime(run.StartedAt),
+			formatTime(run.EndedAt),
+			options.Mode,
+			strconv.Itoa(options.DurationSeconds),
+			strconv.Itoa(options.WordCount),
+			strconv.FormatBool(options.Punctuation),
+			strconv.FormatBool(options.Numbers),
+			options.Language,
+			options.Formula,
+			strconv.FormatInt(run.Seed, 10),
+			strconv.Itoa(metrics.WPM),
+			strconv.Itoa(metrics.RawWPM),
+			strconv.Itoa(metrics.CPM),
+			strconv.Itoa(metrics.RawCPM),
+			strconv.Itoa(metrics.Accuracy),
+			strconv.Itoa(metrics.Consistency),
+			strconv.Itoa(metrics.Correct),
+			strconv.Itoa(metrics.Incorrect),
+			strconv.Itoa(metrics.Keystrokes),
+		})
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// unix milliseconds to RFC 3339 in UTC
+func formatTime(ms int64) string {
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339)
+}
+
+// ParseSince accepts a date (2006-01-02, local midnight) or a RFC 3339 time
+func ParseSince(value string) (time.Time, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q (want YYYY-MM-DD or RFC 3339)", value)
+	}
+	return t, nil
+}
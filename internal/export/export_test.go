@@ -0,0 +1,74 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+type fakeSource []storage.Run
+
+func (f fakeSource) Each(keep func(storage.Summary) bool, fn func(storage.Run) error) error {
+	for _, run := range f {
+		if keep(run.Summary) {
+			if err := fn(run); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func testRuns() fakeSource {
+	day := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	return fakeSource{
+		{Summary: storage.Summary{ID: "a", Key: "k1", StartedAt: day.UnixMilli()}},
+		{
+			Summary: storage.Summary{ID: "b", Key: "k2", StartedAt: day.AddDate(0, 0, 2).UnixMilli()},
+			Samples: []storage.Sample{{Second: 1, WPM: 40}},
+		},
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	filter := Filter{Since: time.Date(2026, 10, 2, 0, 0, 0, 0, time.UTC)}
+	if err := Write(&buf, "json", testRuns(), filter); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Version int           `json:"version"`
+		Runs    []storage.Run `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("invalid json: %v\n%s", err, buf.String())
+	}
+	if out.Version != Version || len(out.Runs) != 1 || len(out.Runs[0].Samples) != 1 {
+		t.Fatalf("unexpected export %+v", out)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, "csv", testRuns(), Filter{Key: "k1"}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(buf.String())).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1][0] != "a" || len(rows[1]) != len(csvHeader) {
+		t.Fatalf("unexpected rows %v", rows)
+	}
+}
+
+func TestWriteUnknownFormat(t *testing.T) {
+	if err := Write(&bytes.Buffer{}, "xml", testRuns(), Filter{}); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,180 @@
+// Package importer reads results exported by other typing tests.
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// prefix of the run ids created from monkeytype results, the rest of the id
+// is the monkeytype _id so importing the same file twice finds duplicates
+const MonkeytypeIDPrefix = "monkeytype-"
+
+// Result of parsing an export, runs have no score key yet because that is
+// decided by the app from the options
+type Result struct {
+	Runs        []storage.Run
+	Unsupported map[string]int
+}
+
+// UnsupportedList returns the unsupported reasons with their counts, sorted
+func (r Result) UnsupportedList() []string {
+	list := make([]string, 0, len(r.Unsupported))
+	for reason, count := range r.Unsupported {
+		list = append(list, fmt.Sprintf("%s (%d)", reason, count))
+	}
+	sort.Strings(list)
+	return list
+}
+
+// columns that have to be in the header
+var monkeytypeRequired = []string{
+	"_id", "wpm", "acc", "rawWpm", "mode", "mode2", "testDuration",
+	"punctuation", "numbers", "language", "timestamp",
+}
+
+// ParseMonkeytype reads the results csv from the monkeytype account page.
+// Only time and words tests in plain english without funbox can be
+// mapped, everything else is counted in Unsupported.
+func ParseMonkeytype(r io.Reader) (Result, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return Result{}, errors.New("empty monkeytype export")
+		}
+		return Result{}, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))] = i
+	}
+	for _, name := range monkeytypeRequired {
+		if _, ok := columns[name]; !ok {
+			return Result{}, fmt.Errorf("not a monkeytype results export: missing column %q", name)
+		}
+	}
+	result := Result{Unsupported: map[string]int{}}
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return result, err
+		}
+		field := func(name string) string {
+			index, ok := columns[name]
+			if !ok || index >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[index])
+		}
+		run, reason, err := monkeytypeRun(field)
+		if err != nil {
+			return result, fmt.Errorf("line %d: %w", line, err)
+		}
+		if reason != "" {
+			result.Unsupported[reason]++
+			continue
+		}
+		result.Runs = append(result.Runs, run)
+	}
+	return result, nil
+}
+
+// map one monkeytype row onto a run, reason is set when it can't be mapped
+func monkeytypeRun(field func(string) string) (storage.Run, string, error) {
+	mode := field("mode")
+	if mode != "time" && mode != "words" {
+		return storage.Run{}, "mode " + mode, nil
+	}
+	if language := field("language"); language != "english" {
+		return storage.Run{}, "language " + language, nil
+	}
+	if funbox := field("funbox"); funbox != "" && funbox != "none" {
+		return storage.Run{}, "funbox " + funbox, nil
+	}
+	amount, err := strconv.Atoi(field("mode2"))
+	if err != nil || amount <= 0 {
+		return storage.Run{}, fmt.Sprintf("%s %s", mode, field("mode2")), nil
+	}
+	wpm, err := parseFloat(field("wpm"))
+	if err != nil {
+		return storage.Run{}, "", fmt.Errorf("wpm: %w", err)
+	}
+	raw, err := parseFloat(field("rawWpm"))
+	if err != nil {
+		return storage.Run{}, "", fmt.Errorf("rawWpm: %w", err)
+	}
+	accuracy, err := parseFloat(field("acc"))
+	if err != nil {
+		return storage.Run{}, "", fmt.Errorf("acc: %w", err)
+	}
+	seconds, err := parseFloat(field("testDuration"))
+	if err != nil {
+		return storage.Run{}, "", fmt.Errorf("testDuration: %w", err)
+	}
+	timestamp, err := strconv.ParseInt(field("timestamp"), 10, 64)
+	if err != nil {
+		return storage.Run{}, "", fmt.Errorf("timestamp: %w", err)
+	}
+	options := storage.RunOptions{
+		Mode:        mode,
+		Punctuation: parseBool(field("punctuation")),
+		Numbers:     parseBool(field("numbers")),
+		Language:    "english",
+		Formula:     "monkeytype",
+	}
+	if mode == "time" {
+		options.DurationSeconds = amount
+	} else {
+		options.WordCount = amount
+	}
+	// char stats are correct;incorrect;extra;missed
+	correct, incorrect := 0, 0
+	if parts := strings.Split(field("charStats"), ";"); len(parts) >= 2 {
+		correct, _ = strconv.Atoi(parts[0])
+		incorrect, _ = strconv.Atoi(parts[1])
+		if len(parts) >= 3 {
+			extra, _ := strconv.Atoi(parts[2])
+			incorrect += extra
+		}
+	}
+	run := storage.Run{Summary: storage.Summary{
+		ID:        MonkeytypeIDPrefix + field("_id"),
+		Options:   options,
+		StartedAt: timestamp - int64(seconds*1000),
+		EndedAt:   timestamp,
+		Metrics: storage.Metrics{
+			WPM:       int(math.Round(wpm)),
+			RawWPM:    int(math.Round(raw)),
+			CPM:       int(math.Round(wpm * 5)),
+			RawCPM:    int(math.Round(raw * 5)),
+			Accuracy:  int(math.Round(accuracy)),
+			Correct:   correct,
+			Incorrect: incorrect,
+		},
+	}}
+	return run, "", nil
+}
+
+func parseFloat(value string) (float64, error) {
+	return strconv.ParseFloat(value, 64)
+}
+
+// monkeytype writes booleans as true/false, older exports used 1/0
+func parseBool(value string) bool {
+	return value == "true" || value == "1"
+}
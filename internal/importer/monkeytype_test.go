@@ -0,0 +1,42 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+)
+
+const monkeytypeCSV = `_id,isPb,wpm,acc,rawWpm,consistency,charStats,mode,mode2,quoteLength,restartCount,testDuration,afkDuration,incompleteTestSeconds,punctuation,numbers,language,funbox,difficulty,lazyMode,blindMode,bailedOut,tags,timestamp
+a1,true,84.5,96.2,88.1,78.3,"420;12;2;1",time,60,-1,0,60,0,0,false,true,english,none,normal,false,false,false,,1700000060000
+a2,false,70,98,71,80,"300;5;0;0",words,25,-1,0,21.5,0,0,true,false,english,none,normal,false,false,false,"tag1,tag2",1700000100000
+a3,false,60,95,61,70,"200;5;0;0",quote,,1,0,30,0,0,false,false,english,none,normal,false,false,false,,1700000200000
+a4,false,60,95,61,70,"200;5;0;0",time,30,-1,0,30,0,0,false,false,english_1k,none,normal,false,false,false,,1700000300000
+`
+
+func TestParseMonkeytype(t *testing.T) {
+	result, err := ParseMonkeytype(strings.NewReader(monkeytypeCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Runs) != 2 {
+		t.Fatalf("runs = %d, want 2", len(result.Runs))
+	}
+	first := result.Runs[0]
+	if first.ID != "monkeytype-a1" || first.Metrics.WPM != 85 || first.Metrics.Incorrect != 14 {
+		t.Fatalf("unexpected first run %+v", first)
+	}
+	if first.Options.DurationSeconds != 60 || !first.Options.Numbers || first.StartedAt != 1700000000000 {
+		t.Fatalf("unexpected first options %+v", first.Summary)
+	}
+	if second := result.Runs[1]; second.Options.WordCount != 25 || !second.Options.Punctuation {
+		t.Fatalf("unexpected second options %+v", second.Options)
+	}
+	if result.Unsupported["mode quote"] != 1 || result.Unsupported["language english_1k"] != 1 {
+		t.Fatalf("unexpected unsupported %v", result.Unsupported)
+	}
+}
+
+func TestParseMonkeytypeWrongFile(t *testing.T) {
+	if _, err := ParseMonkeytype(strings.NewReader("a,b\n1,2\n")); err == nil {
+		t.Fatal("expected an error for a csv without monkeytype columns")
+	}
+}
@@ -0,0 +1,321 @@
+// Package keymap maps key chords to the names of gotype actions. A chord
+// is written like ctrl+w, alt+backspace, shift+tab, f2 or a single
+// character like r or G. A keymap starts from one of the Presets and the
+// config file changes single bindings:
+//
+//	[keys]
+//	preset = "vim-ish"
+//
+//	[keys.bind]
+//	"ctrl+r" = "restart"
+//	esc = "none"
+//
+// Plain characters only act when the test is over, while typing they are
+// typed. Ctrl+C always quits and can't be bound.
+package keymap
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Chord is a key with its modifiers in the canonical form, ctrl+alt+shift+
+// before the key name
+type Chord string
+
+// Unbound is the action that removes the binding of a chord
+const Unbound = "none"
+
+// the chord that always quits
+const quitChord = Chord("ctrl+c")
+
+// named keys and their tcell keys
+var namedKeys = map[string]tcell.Key{
+	"esc":       tcell.KeyEsc,
+	"tab":       tcell.KeyTab,
+	"enter":     tcell.KeyEnter,
+	"backspace": tcell.KeyBackspace2,
+	"delete":    tcell.KeyDelete,
+	"insert":    tcell.KeyInsert,
+	"up":        tcell.KeyUp,
+	"down":      tcell.KeyDown,
+	"left":      tcell.KeyLeft,
+	"right":     tcell.KeyRight,
+	"home":      tcell.KeyHome,
+	"end":       tcell.KeyEnd,
+	"pgup":      tcell.KeyPgUp,
+	"pgdn":      tcell.KeyPgDn,
+	"f1":        tcell.KeyF1,
+	"f2":        tcell.KeyF2,
+	"f3":        tcell.KeyF3,
+	"f4":        tcell.KeyF4,
+	"f5":        tcell.KeyF5,
+	"f6":        tcell.KeyF6,
+	"f7":        tcell.KeyF7,
+	"f8":        tcell.KeyF8,
+	"f9":        tcell.KeyF9,
+	"f10":       tcell.KeyF10,
+	"f11":       tcell.KeyF11,
+	"f12":       tcell.KeyF12,
+}
+
+// other spellings of the named keys
+var keyAliases = map[string]string{
+	"escape":   "esc",
+	"return":   "enter",
+	"del":      "delete",
+	"pageup":   "pgup",
+	"pagedown": "pgdn",
+	"bs":       "backspace",
+	"spacebar": "space",
+}
+
+// the ctrl letters terminals can't tell from a named key
+var ctrlSameAs = map[rune]string{'i': "tab", 'm': "enter", 'h': "backspace"}
+
+// the name of a tcell key, backspace has two codes
+var keyNames = func() map[tcell.Key]string {
+	names := map[tcell.Key]string{tcell.KeyBackspace: "backspace"}
+	for name, key := range namedKeys {
+		names[key] = name
+	}
+	return names
+}()
+
+func chord(mods tcell.ModMask, name string) Chord {
+	var b strings.Builder
+	if mods&tcell.ModCtrl != 0 {
+		b.WriteString("ctrl+")
+	}
+	if mods&(tcell.ModAlt|tcell.ModMeta) != 0 {
+		b.WriteString("alt+")
+	}
+	if mods&tcell.ModShift != 0 {
+		b.WriteString("shift+")
+	}
+	b.WriteString(name)
+	return Chord(b.String())
+}
+
+// ChordOf is the chord of a key event, empty for keys that have no name
+func ChordOf(event *tcell.EventKey) Chord {
+	mods := event.Modifiers()
+	key := event.Key()
+	switch {
+	case key == tcell.KeyRune:
+		// shift is part of the character
+		name := string(event.Rune())
+		if event.Rune() == ' ' {
+			name = "space"
+		}
+		return chord(mods&(tcell.ModAlt|tcell.ModMeta), name)
+	case key == tcell.KeyBacktab:
+		return chord(mods|tcell.ModShift, "tab")
+	// tab, enter and backspace share their codes with ctrl+i, ctrl+m and
+	// ctrl+h, terminals send them for both
+	case key >= tcell.KeyCtrlA && key <= tcell.KeyCtrlZ &&
+		key != tcell.KeyTab && key != tcell.KeyEnter && key != tcell.KeyBackspace:
+		return chord(mods|tcell.ModCtrl, string(rune('a'+key-tcell.KeyCtrlA)))
+	}
+	name, ok := keyNames[key]
+	if !ok {
+		return ""
+	}
+	return chord(mods, name)
+}
+
+// ParseChord reads a chord as written in the config, the modifiers and
+// named keys in any case
+func ParseChord(text string) (Chord, error) {
+	parts := strings.Split(text, "+")
+	key := parts[len(parts)-1]
+	mods := parts[:len(parts)-1]
+	// a + key ends the chord in ++, like ctrl++
+	if key == "" && len(parts) > 1 {
+		key, mods = "+", parts[:len(parts)-2]
+	}
+	var mask tcell.ModMask
+	for _, mod := range mods {
+		switch strings.ToLower(strings.TrimSpace(mod)) {
+		case "ctrl", "control":
+			mask |= tcell.ModCtrl
+		case "alt", "meta", "option":
+			mask |= tcell.ModAlt
+		case "shift":
+			mask |= tcell.ModShift
+		default:
+			return "", fmt.Errorf("unknown modifier %q in %q", mod, text)
+		}
+	}
+	if runes := []rune(key); len(runes) == 1 && key != " " {
+		r := runes[0]
+		switch {
+		case mask&tcell.ModCtrl != 0:
+			if r = unicode.ToLower(r); r < 'a' || r > 'z' {
+				return "", fmt.Errorf("%q: ctrl only goes with the letters a to z", text)
+			}
+			if same, ok := ctrlSameAs[r]; ok {
+				return "", fmt.Errorf("%q: terminals send it as %s, bind %s", text, same, same)
+			}
+		case mask&tcell.ModShift != 0:
+			return "", fmt.Errorf("%q: write the shifted character instead of shift+", text)
+		}
+		return chord(mask, string(r)), nil
+	}
+	name := strings.ToLower(strings.TrimSpace(key))
+	if alias, ok := keyAliases[name]; ok {
+		name = alias
+	}
+	if _, ok := namedKeys[name]; !ok && name != "space" {
+		return "", fmt.Errorf("unknown key %q in %q", key, text)
+	}
+	if name == "space" && mask&(tcell.ModCtrl|tcell.ModShift) != 0 {
+		return "", fmt.Errorf("%q: only alt goes with space", text)
+	}
+	return chord(mask, name), nil
+}
+
+// Plain tells if the chord is a character without ctrl or alt, those are
+// typed while a test runs
+func (c Chord) Plain() bool {
+	return c == "space" || len([]rune(string(c))) == 1
+}
+
+// Binding is one chord and the action it runs
+type Binding struct {
+	Chord  Chord
+	Action string
+}
+
+// Keymap gives the action bound to a chord
+type Keymap struct {
+	// the preset it started from
+	Preset  string
+	actions map[Chord]string
+}
+
+// Action is the action bound to a chord
+func (k Keymap) Action(c Chord) (string, bool) {
+	action, ok := k.actions[c]
+	return action, ok
+}
+
+// Chords lists the chords bound to an action, sorted so the same keymap
+// always shows the same first chord
+func (k Keymap) Chords(action string) []Chord {
+	var chords []Chord
+	for c, a := range k.actions {
+		if a == action {
+			chords = append(chords, c)
+		}
+	}
+	sortChords(chords)
+	return chords
+}
+
+// Bindings lists every binding in the order of Actions
+func (k Keymap) Bindings() []Binding {
+	var bindings []Binding
+	for _, action := range Actions {
+		for _, c := range k.Chords(action) {
+			bindings = append(bindings, Binding{c, action})
+		}
+	}
+	return bindings
+}
+
+// named keys before characters, then the shorter chords first
+func sortChords(chords []Chord) {
+	sort.Slice(chords, func(i, j int) bool {
+		pi, pj := chords[i].Plain(), chords[j].Plain()
+		if pi != pj {
+			return !pi
+		}
+		if len(chords[i]) != len(chords[j]) {
+			return len(chords[i]) < len(chords[j])
+		}
+		return chords[i] < chords[j]
+	})
+}
+
+// Build makes the keymap of a preset with the bindings of the config over
+// it. The error lists every unknown chord or action, the chords that are
+// written differently but bound twice and the actions of the preset a
+// binding took the last key of.
+func Build(preset string, bind map[string]string) (Keymap, error) {
+	base, ok := Presets[preset]
+	if !ok {
+		return Keymap{}, fmt.Errorf("unknown keymap preset %q (want %s)", preset, strings.Join(PresetNames(), ", "))
+	}
+	keymap := Keymap{Preset: preset, actions: map[Chord]string{}}
+	for text, action := range base {
+		keymap.actions[Chord(text)] = action
+	}
+
+	var errs []error
+	// sorted so the errors come in the same order every time
+	texts := make([]string, 0, len(bind))
+	for text := range bind {
+		texts = append(texts, text)
+	}
+	sort.Strings(texts)
+	seen := map[Chord]string{}
+	// the bindings that took a key of the preset from another action
+	taken := map[string][]string{}
+	for _, text := range texts {
+		action := bind[text]
+		c, err := ParseChord(text)
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+			continue
+		case c == quitChord:
+			errs = append(errs, fmt.Errorf("%q: ctrl+c always quits", text))
+			continue
+		case action != Unbound && !slices.Contains(Actions, action):
+			errs = append(errs, fmt.Errorf("%q: unknown action %q", text, action))
+			continue
+		}
+		if other, ok := seen[c]; ok && bind[other] != action {
+			errs = append(errs, fmt.Errorf("%q and %q are the same key %s, bound to %s and %s",
+				other, text, c, bind[other], action))
+			continue
+		}
+		seen[c] = text
+		if old, ok := keymap.actions[c]; ok && old != action && action != Unbound {
+			taken[old] = append(taken[old], text)
+		}
+		if action == Unbound {
+			delete(keymap.actions, c)
+		} else {
+			keymap.actions[c] = action
+		}
+	}
+	// unbinding a key is asked for, losing an action to a rebind is not.
+	// Quit is never lost, ctrl+c is left for it.
+	for _, action := range Actions {
+		if texts := taken[action]; len(texts) > 0 && action != "quit" && len(keymap.Chords(action)) == 0 {
+			errs = append(errs, fmt.Errorf("%q takes the only key of %s, bind %s to another key",
+				strings.Join(texts, `", "`), action, action))
+		}
+	}
+	return keymap, errors.Join(errs...)
+}
+
+// PresetNames lists the presets, default first
+func PresetNames() []string {
+	names := make([]string, 0, len(Presets))
+	for name := range Presets {
+		if name != DefaultPreset {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultPreset}, names...)
+}
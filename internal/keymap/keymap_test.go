@@ -0,0 +1,102 @@
+package keymap
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestPresetsAreValid(t *testing.T) {
+	for name, bindings := range Presets {
+		for text, action := range bindings {
+			c, err := ParseChord(text)
+			if err != nil || string(c) != text {
+				t.Errorf("%s: %q parses to %q, %v", name, text, c, err)
+			}
+			if !slices.Contains(Actions, action) {
+				t.Errorf("%s: %q is bound to unknown action %q", name, text, action)
+			}
+		}
+		if _, err := Build(name, nil); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestChordOf(t *testing.T) {
+	for _, tc := range []struct {
+		event *tcell.EventKey
+		chord Chord
+	}{
+		{tcell.NewEventKey(tcell.KeyCtrlW, 0, tcell.ModCtrl), "ctrl+w"},
+		{tcell.NewEventKey(tcell.KeyCtrlW, 0, tcell.ModNone), "ctrl+w"},
+		{tcell.NewEventKey(tcell.KeyTab, 0, tcell.ModNone), "tab"},
+		{tcell.NewEventKey(tcell.KeyBacktab, 0, tcell.ModNone), "shift+tab"},
+		{tcell.NewEventKey(tcell.KeyBackspace, 0, tcell.ModCtrl), "ctrl+backspace"},
+		{tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModAlt), "alt+backspace"},
+		{tcell.NewEventKey(tcell.KeyRune, 'G', tcell.ModShift), "G"},
+		{tcell.NewEventKey(tcell.KeyRune, ' ', tcell.ModNone), "space"},
+		{tcell.NewEventKey(tcell.KeyF2, 0, tcell.ModNone), "f2"},
+	} {
+		if c := ChordOf(tc.event); c != tc.chord {
+			t.Errorf("%s = %q, want %q", tc.event.Name(), c, tc.chord)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	keymap, err := Build("vim-ish", map[string]string{"Ctrl+R": "restart", "esc": Unbound})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if action, _ := keymap.Action("ctrl+r"); action != "restart" {
+		t.Errorf("ctrl+r = %q", action)
+	}
+	if _, ok := keymap.Action("esc"); ok {
+		t.Error("esc still bound")
+	}
+	if chords := keymap.Chords("restart"); chords[0] != "tab" {
+		t.Errorf("restart chords = %v", chords)
+	}
+
+	_, err = Build("default", map[string]string{
+		"ctrl+x":    "explode",
+		"ctrl+c":    "restart",
+		"hyper+x":   "quit",
+		"ctrl+i":    "quit",
+		"Alt+Enter": "stats",
+		"alt+enter": "history",
+	})
+	for _, want := range []string{
+		`"ctrl+x": unknown action "explode"`,
+		`"ctrl+c": ctrl+c always quits`,
+		`unknown modifier "hyper"`,
+		`"ctrl+i": terminals send it as tab`,
+		`"Alt+Enter" and "alt+enter" are the same key alt+enter`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error %v, want %q", err, want)
+		}
+	}
+	// a rebind can't leave an action of the preset without a key
+	_, err = Build("default", map[string]string{"f2": "stats", "esc": "palette"})
+	if err == nil || !strings.Contains(err.Error(), `"f2" takes the only key of history`) || strings.Contains(err.Error(), "quit") {
+		t.Errorf("taken keys: %v", err)
+	}
+	// swapped keys and an action bound elsewhere keep a key each
+	if _, err := Build("default", map[string]string{"f2": "stats", "f3": "history"}); err != nil {
+		t.Errorf("swapped keys: %v", err)
+	}
+	if _, err := Build("default", map[string]string{"f2": "stats", "ctrl+y": "history"}); err != nil {
+		t.Errorf("history bound elsewhere: %v", err)
+	}
+	if _, err := Build("default", map[string]string{"f2": Unbound}); err != nil {
+		t.Errorf("unbound on purpose: %v", err)
+	}
+
+	if _, err := Build("emacs", nil); err == nil || !strings.Contains(err.Error(), "default, monkeytype, vim-ish") {
+		t.Errorf("unknown preset: %v", err)
+	}
+}
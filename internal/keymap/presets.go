@@ -0,0 +1,112 @@
+package keymap
+
+// Actions are the names a chord can be bound to, in the order the help
+// lists them
+var Actions = []string{
+	"quit",
+	"restart",
+	"repeat",
+	"delete-word",
+	"palette",
+	"top-bar",
+	"help",
+	"history",
+	"stats",
+	"settings",
+	"review-up",
+	"review-down",
+	"review-page-up",
+	"review-page-down",
+	"review-top",
+	"review-bottom",
+	"toggle-punctuation",
+	"toggle-numbers",
+	"time-mode",
+	"words-mode",
+	"custom-length",
+	"next-formula",
+	"switch-unit",
+	"presets",
+	"save-preset",
+	"themes",
+	"next-theme",
+	"previous-theme",
+	"theme-editor",
+	"profiles",
+}
+
+// DefaultPreset is the keymap gotype always had
+const DefaultPreset = "default"
+
+// Presets are the keymaps to start from, by name
+var Presets = map[string]map[string]string{
+	DefaultPreset: {
+		"esc":            "quit",
+		"tab":            "restart",
+		"ctrl+w":         "delete-word",
+		"ctrl+backspace": "delete-word",
+		"ctrl+p":         "palette",
+		"ctrl+t":         "top-bar",
+		"f1":             "help",
+		"f2":             "history",
+		"f3":             "stats",
+		"f4":             "settings",
+		"up":             "review-up",
+		"down":           "review-down",
+		"pgup":           "review-page-up",
+		"pgdn":           "review-page-down",
+		"home":           "review-top",
+		"end":            "review-bottom",
+		"r":              "restart",
+		"R":              "restart",
+		"?":              "help",
+	},
+	// the keys of monkeytype: esc opens the command line, tab restarts and
+	// ctrl or alt backspace deletes a word
+	"monkeytype": {
+		"esc":            "palette",
+		"ctrl+shift+p":   "palette",
+		"ctrl+q":         "quit",
+		"tab":            "restart",
+		"shift+tab":      "repeat",
+		"ctrl+backspace": "delete-word",
+		"alt+backspace":  "delete-word",
+		"ctrl+w":         "delete-word",
+		"ctrl+t":         "top-bar",
+		"f1":             "help",
+		"f2":             "history",
+		"f3":             "stats",
+		"f4":             "settings",
+		"up":             "review-up",
+		"down":           "review-down",
+		"pgup":           "review-page-up",
+		"pgdn":           "review-page-down",
+		"home":           "review-top",
+		"end":            "review-bottom",
+		"?":              "help",
+	},
+	// esc leaves for the top bar like normal mode, j and k scroll the
+	// results and : opens the palette once the test is over
+	"vim-ish": {
+		"esc":    "top-bar",
+		"ctrl+q": "quit",
+		"tab":    "restart",
+		"ctrl+w": "delete-word",
+		"ctrl+p": "palette",
+		"ctrl+d": "review-page-down",
+		"ctrl+u": "review-page-up",
+		"f1":     "help",
+		"f2":     "history",
+		"f3":     "stats",
+		"f4":     "settings",
+		"j":      "review-down",
+		"k":      "review-up",
+		"g":      "review-top",
+		"G":      "review-bottom",
+		"r":      "restart",
+		".":      "repeat",
+		":":      "palette",
+		"q":      "quit",
+		"?":      "help",
+	},
+}
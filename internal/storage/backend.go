@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+)
+
+// Backend is where gotype keeps the preferences, the best scores, the run
+// history and the per-key stats. The json files are the default, the
+// database suits very large histories and the memory one is for tests.
+type Backend interface {
+	// Load returns the preferences and best scores
+	Load() (Data, error)
+	// Save merges data with what is stored (see Merge) and returns the result
+	Save(data Data) (Data, error)
+
+	// Runs returns the summaries of all runs, oldest first
+	Runs() []Summary
+	Get(id string) (Run, error)
+	// Each reads the full runs accepted by keep, oldest first
+	Each(keep func(Summary) bool, fn func(Run) error) error
+	Append(run Run) (string, error)
+	Delete(id string) error
+	Has(id string) bool
+	Len() int
+	// Fingerprint changes whenever the runs change
+	Fingerprint() string
+
+	// KeyStats returns the hits and misses per key summed over every run
+	KeyStats() (map[string]KeyStat, error)
+
+	// Refresh picks up what other gotype processes wrote and reports if
+	// the state or the runs changed
+	Refresh() (bool, error)
+	Close() error
+}
+
+// Backends lists the names Open accepts
+var Backends = []string{"json", "bolt"}
+
+// Open opens the named backend in the profile directory dir, creating the
+// directory for a new profile
+func Open(name, dir string) (Backend, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	switch name {
+	case "json":
+		return OpenJSON(StatePath(dir))
+	case "bolt":
+		return OpenBolt(BoltPath(dir))
+	}
+	return nil, fmt.Errorf("unknown storage backend %q (want json or bolt)", name)
+}
+
+// Copy moves the state and every run from src to dst, runs dst already
+// has are skipped so a copy can be repeated. It returns the number of runs
+// copied.
+func Copy(dst, src Backend) (int, error) {
+	data, err := src.Load()
+	if err != nil {
+		return 0, err
+	}
+	if _, err := dst.Save(data); err != nil {
+		return 0, err
+	}
+	copied := 0
+	err = src.Each(func(run Summary) bool { return !dst.Has(run.ID) }, func(run Run) error {
+		if _, err := dst.Append(run); err != nil {
+			return err
+		}
+		copied++
+		return nil
+	})
+	return copied, err
+}
+
+// sum the per-key stats of the runs, for backends without a running total
+func sumKeyStats(each func(keep func(Summary) bool, fn func(Run) error) error) (map[string]KeyStat, error) {
+	totals := map[string]KeyStat{}
+	err := each(nil, func(run Run) error {
+		for key, stat := range run.Keys {
+			total := totals[key]
+			total.Hits += stat.Hits
+			total.Misses += stat.Misses
+			totals[key] = total
+		}
+		return nil
+	})
+	return totals, err
+}
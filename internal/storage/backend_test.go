@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// every backend has to behave the same
+func testBackends(t *testing.T) map[string]func(dir string) Backend {
+	return map[string]func(dir string) Backend{
+		"json": func(dir string) Backend {
+			backend, err := OpenJSON(filepath.Join(dir, "state.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return backend
+		},
+		"bolt": func(dir string) Backend {
+			backend, err := OpenBolt(BoltPath(dir))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return backend
+		},
+		"memory": func(string) Backend { return NewMemory() },
+	}
+}
+
+func TestBackends(t *testing.T) {
+	for name, open := range testBackends(t) {
+		t.Run(name, func(t *testing.T) {
+			backend := open(t.TempDir())
+			defer backend.Close()
+
+			saved, err := backend.Save(Data{
+				Preferences: Preferences{Mode: "words"},
+				BestScores:  map[string]BestScore{"a": {WPM: 50}},
+			})
+			if err != nil || saved.Version != CurrentVersion {
+				t.Fatalf("save: %+v %v", saved, err)
+			}
+			if _, err := backend.Save(Data{BestScores: map[string]BestScore{"a": {WPM: 40}}}); err != nil {
+				t.Fatal(err)
+			}
+			data, err := backend.Load()
+			if err != nil || data.BestScores["a"].WPM != 50 {
+				t.Fatalf("load: %+v %v", data, err)
+			}
+
+			first := testRun("1", "a", 50)
+			first.StartedAt = 2000
+			first.Keys = map[string]KeyStat{"e": {Hits: 3, Misses: 1}}
+			second := testRun("2", "b", 60)
+			second.StartedAt = 1000
+			second.Keys = map[string]KeyStat{"e": {Hits: 2}}
+			for _, run := range []Run{first, second} {
+				if _, err := backend.Append(run); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// appending an id twice keeps both runs
+			if id, err := backend.Append(first); err != nil || id == "1" {
+				t.Fatalf("duplicate id %q: %v", id, err)
+			}
+			if backend.Len() != 3 || !backend.Has("2") {
+				t.Fatalf("len = %d", backend.Len())
+			}
+			keys, err := backend.KeyStats()
+			if err != nil || keys["e"] != (KeyStat{Hits: 8, Misses: 2}) {
+				t.Fatalf("key stats = %v %v", keys, err)
+			}
+
+			before := backend.Fingerprint()
+			if err := backend.Delete("1"); err != nil {
+				t.Fatal(err)
+			}
+			if err := backend.Delete("1"); !errors.Is(err, ErrRunNotFound) {
+				t.Fatalf("second delete: %v", err)
+			}
+			if backend.Fingerprint() == before {
+				t.Fatal("fingerprint did not change")
+			}
+			run, err := backend.Get("2")
+			if err != nil || run.Metrics.WPM != 60 {
+				t.Fatalf("get: %+v %v", run.Summary, err)
+			}
+			count := 0
+			err = backend.Each(func(s Summary) bool { return s.Key == "a" }, func(Run) error {
+				count++
+				return nil
+			})
+			if err != nil || count != 1 {
+				t.Fatalf("each kept %d runs: %v", count, err)
+			}
+		})
+	}
+}
+
+func TestCopyBetweenBackends(t *testing.T) {
+	dir := t.TempDir()
+	src := NewMemory()
+	if _, err := src.Save(Data{BestScores: map[string]BestScore{"a": {WPM: 70}}}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"1", "2"} {
+		if _, err := src.Append(testRun(id, "a", 70)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dst, err := OpenBolt(BoltPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []int{2, 0} {
+		copied, err := Copy(dst, src)
+		if err != nil || copied != want {
+			t.Fatalf("copied %d runs, want %d: %v", copied, want, err)
+		}
+	}
+	// a second handle on the same file sees everything, and a write of one
+	// handle shows up in the other
+	reopened, err := OpenBolt(BoltPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	data, err := reopened.Load()
+	if err != nil || reopened.Len() != 2 || data.BestScores["a"].WPM != 70 {
+		t.Fatalf("reopened: %d runs, %+v %v", reopened.Len(), data, err)
+	}
+	if changed, err := reopened.Refresh(); changed || err != nil {
+		t.Fatalf("refresh without a write: %v %v", changed, err)
+	}
+	if _, err := dst.Append(testRun("3", "a", 70)); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := reopened.Refresh(); !changed || err != nil || reopened.Len() != 3 {
+		t.Fatalf("refresh after a write: %v %v, %d runs", changed, err, reopened.Len())
+	}
+	// a save of its own is not a change
+	if _, err := reopened.Save(Data{}); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := reopened.Refresh(); changed || err != nil {
+		t.Fatalf("refresh after its own save: %v %v", changed, err)
+	}
+}
+
+func TestBoltSaveRepairsBrokenState(t *testing.T) {
+	backend, err := OpenBolt(BoltPath(t.TempDir()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+	broken := []byte(`{"version":2,"best_scores":{"a":{"wpm":50},"b":{"wpm":"x"}}}`)
+	err = backend.update(func(tx *bolt.Tx) error {
+		return tx.Bucket(stateBucket).Put(stateKey, broken)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := backend.Save(Data{BestScores: map[string]BestScore{"c": {WPM: 60}}})
+	if err != nil || len(saved.BestScores) != 2 || saved.BestScores["a"].WPM != 50 {
+		t.Fatalf("saved %+v %v", saved.BestScores, err)
+	}
+	// the broken state is kept next to the repaired one
+	kept := 0
+	err = backend.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(stateBucket).ForEach(func(key, value []byte) error {
+			if bytes.Equal(value, broken) {
+				kept++
+			}
+			return nil
+		})
+	})
+	if err != nil || kept != 1 {
+		t.Fatalf("kept %d broken states: %v", kept, err)
+	}
+}
+
+func TestRefreshSeesOnlyOtherSaves(t *testing.T) {
+	for name, open := range testBackends(t) {
+		if name == "memory" {
+			continue
+		}
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			first, second := open(dir), open(dir)
+			defer first.Close()
+			defer second.Close()
+			if _, err := first.Load(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := first.Save(Data{PreferencesAt: 1}); err != nil {
+				t.Fatal(err)
+			}
+			if changed, err := first.Refresh(); changed || err != nil {
+				t.Fatalf("refresh after its own save: %v %v", changed, err)
+			}
+			if _, err := second.Save(Data{PreferencesAt: 2}); err != nil {
+				t.Fatal(err)
+			}
+			// its own save after the other one's doesn't hide it
+			if _, err := first.Save(Data{PreferencesAt: 3}); err != nil {
+				t.Fatal(err)
+			}
+			if changed, err := first.Refresh(); !changed || err != nil {
+				t.Fatalf("refresh after a save of the other: %v %v", changed, err)
+			}
+			if changed, err := first.Refresh(); changed || err != nil {
+				t.Fatalf("second refresh: %v %v", changed, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,478 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// buckets of the database
+var (
+	// "data" holds the state as the same json as state.json
+	stateBucket = []byte("state")
+	// run id to the full run as json
+	runsBucket = []byte("runs")
+	// run id to the summary, read on open so listing never loads full runs
+	summaryBucket = []byte("summaries")
+	// key to the KeyStat totals over every run
+	keysBucket = []byte("keys")
+	// "generation" counts the writes to the runs and "state_generation"
+	// the writes to the state, to notice writes by other processes
+	metaBucket = []byte("meta")
+)
+
+var (
+	stateKey           = []byte("data")
+	generationKey      = []byte("generation")
+	stateGenerationKey = []byte("state_generation")
+)
+
+// boltTimeout is how long to wait for another process that has the
+// database open
+const boltTimeout = 5 * time.Second
+
+// ErrBoltInUse is returned when another gotype kept the database open for
+// longer than boltTimeout
+var ErrBoltInUse = errors.New("the database is busy in another gotype")
+
+// BoltBackend keeps everything in one bbolt database file. The database
+// is only opened for each read or write, so several gotype windows and
+// the commands can share it, and the run summaries are kept in memory.
+type BoltBackend struct {
+	mu         sync.Mutex
+	path       string
+	runs       []Summary
+	byID       map[string]int
+	generation uint64
+	stateSeen  uint64
+	// modification time of the file after the last look, Refresh only
+	// opens the database when it changed
+	modSeen time.Time
+}
+
+// BoltPath is the database file in the gotype config directory
+func BoltPath(dir string) string {
+	return filepath.Join(dir, "gotype.db")
+}
+
+// OpenBolt opens or creates the database at path
+func OpenBolt(path string) (*BoltBackend, error) {
+	b := &BoltBackend{path: path}
+	err := b.update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{stateBucket, runsBucket, summaryBucket, keysBucket, metaBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return b.sync(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// open the database for one transaction, read-only opens share the file
+// with each other
+func (b *BoltBackend) open(readOnly bool) (*bolt.DB, error) {
+	db, err := bolt.Open(b.path, 0o644, &bolt.Options{Timeout: boltTimeout, ReadOnly: readOnly})
+	if errors.Is(err, bolt.ErrTimeout) {
+		err = ErrBoltInUse
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", b.path, err)
+	}
+	return db, nil
+}
+
+// run fn in a write transaction
+func (b *BoltBackend) update(fn func(tx *bolt.Tx) error) error {
+	db, err := b.open(false)
+	if err != nil {
+		return err
+	}
+	if err := db.Update(fn); err != nil {
+		db.Close()
+		return err
+	}
+	return db.Close()
+}
+
+// run fn in a read transaction
+func (b *BoltBackend) view(fn func(tx *bolt.Tx) error) error {
+	db, err := b.open(true)
+	if err != nil {
+		return err
+	}
+	if err := db.View(fn); err != nil {
+		db.Close()
+		return err
+	}
+	return db.Close()
+}
+
+// sync reloads the summaries when another process wrote since the last
+// look, the caller holds b.mu or is the only user
+func (b *BoltBackend) sync(tx *bolt.Tx) error {
+	generation := readGeneration(tx)
+	if generation == b.generation && b.byID != nil {
+		return nil
+	}
+	runs := []Summary{}
+	err := tx.Bucket(summaryBucket).ForEach(func(_, raw []byte) error {
+		var summary Summary
+		if err := json.Unmarshal(raw, &summary); err != nil {
+			return err
+		}
+		runs = append(runs, summary)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].StartedAt < runs[j].StartedAt })
+	b.runs = runs
+	b.reindex()
+	b.generation = generation
+	return nil
+}
+
+func (b *BoltBackend) reindex() {
+	b.byID = make(map[string]int, len(b.runs))
+	for i, run := range b.runs {
+		b.byID[run.ID] = i
+	}
+}
+
+// count one more write to the runs, in the same transaction as the write
+func (b *BoltBackend) bump(tx *bolt.Tx) error {
+	b.generation = readCounter(tx, generationKey) + 1
+	return writeCounter(tx, generationKey, b.generation)
+}
+
+func readGeneration(tx *bolt.Tx) uint64 {
+	return readCounter(tx, generationKey)
+}
+
+func readCounter(tx *bolt.Tx, key []byte) uint64 {
+	value := tx.Bucket(metaBucket).Get(key)
+	if len(value) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(value)
+}
+
+func writeCounter(tx *bolt.Tx, key []byte, count uint64) error {
+	value := make([]byte, 8)
+	binary.BigEndian.PutUint64(value, count)
+	return tx.Bucket(metaBucket).Put(key, value)
+}
+
+// decode the stored state, through the same migrations as state.json
+func decodeState(raw []byte) (Data, error) {
+	if raw == nil {
+		return Data{Version: CurrentVersion, BestScores: map[string]BestScore{}}, nil
+	}
+	raw, _, err := migrate(raw)
+	if err != nil {
+		return Data{}, err
+	}
+	var data Data
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return Data{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if data.BestScores == nil {
+		data.BestScores = map[string]BestScore{}
+	}
+	return data, nil
+}
+
+func (b *BoltBackend) Load() (Data, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var data Data
+	err := b.view(func(tx *bolt.Tx) error {
+		var err error
+		data, err = decodeState(tx.Bucket(stateBucket).Get(stateKey))
+		b.stateSeen = readCounter(tx, stateGenerationKey)
+		return err
+	})
+	return data, err
+}
+
+func (b *BoltBackend) Save(data Data) (Data, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var before uint64
+	err := b.update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(stateBucket)
+		raw := bucket.Get(stateKey)
+		stored, err := decodeState(raw)
+		if errors.Is(err, ErrCorrupt) {
+			// the broken state is kept next to the repaired one and what
+			// still decodes is merged, like a broken state.json
+			quarantine := string(stateKey) + ".corrupt-" + time.Now().Format("20060102-150405")
+			if err := bucket.Put([]byte(quarantine), bytes.Clone(raw)); err != nil {
+				return err
+			}
+			stored, _, err = salvage(raw)
+		}
+		if err != nil {
+			return err
+		}
+		data = Merge(stored, data)
+		data.Version = CurrentVersion
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put(stateKey, encoded); err != nil {
+			return err
+		}
+		before = readCounter(tx, stateGenerationKey)
+		return writeCounter(tx, stateGenerationKey, before+1)
+	})
+	if err != nil {
+		return Data{}, err
+	}
+	// our own save is no change to report, unless another process saved
+	// since the last look
+	if before == b.stateSeen {
+		b.stateSeen = before + 1
+	}
+	return data, nil
+}
+
+func (b *BoltBackend) Runs() []Summary {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Summary(nil), b.runs...)
+}
+
+func (b *BoltBackend) Get(id string) (Run, error) {
+	var run Run
+	err := b.view(func(tx *bolt.Tx) error {
+		raw := tx.Bucket(runsBucket).Get([]byte(id))
+		if raw == nil {
+			return ErrRunNotFound
+		}
+		return json.Unmarshal(raw, &run)
+	})
+	return run, err
+}
+
+func (b *BoltBackend) Each(keep func(Summary) bool, fn func(Run) error) error {
+	b.mu.Lock()
+	ids := make([]string, 0, len(b.runs))
+	for _, summary := range b.runs {
+		if keep == nil || keep(summary) {
+			ids = append(ids, summary.ID)
+		}
+	}
+	b.mu.Unlock()
+	if len(ids) == 0 {
+		return nil
+	}
+	return b.view(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(runsBucket)
+		for _, id := range ids {
+			raw := bucket.Get([]byte(id))
+			if raw == nil {
+				// deleted by another process since the summaries were read
+				continue
+			}
+			var run Run
+			if err := json.Unmarshal(raw, &run); err != nil {
+				return err
+			}
+			if err := fn(run); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *BoltBackend) Append(run Run) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.update(func(tx *bolt.Tx) error {
+		if err := b.sync(tx); err != nil {
+			return err
+		}
+		if run.ID == "" {
+			run.ID = newRunID(run.Summary)
+		}
+		for {
+			if _, ok := b.byID[run.ID]; !ok {
+				break
+			}
+			run.ID += "x"
+		}
+		raw, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		summary, err := json.Marshal(run.Summary)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(runsBucket).Put([]byte(run.ID), raw); err != nil {
+			return err
+		}
+		if err := tx.Bucket(summaryBucket).Put([]byte(run.ID), summary); err != nil {
+			return err
+		}
+		if err := addKeyStats(tx, run.Keys, 1); err != nil {
+			return err
+		}
+		return b.bump(tx)
+	})
+	if err != nil {
+		// the transaction was rolled back, read the summaries again
+		b.byID = nil
+		return "", err
+	}
+	b.runs = append(b.runs, run.Summary)
+	b.byID[run.ID] = len(b.runs) - 1
+	return run.ID, nil
+}
+
+func (b *BoltBackend) Delete(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.update(func(tx *bolt.Tx) error {
+		if err := b.sync(tx); err != nil {
+			return err
+		}
+		raw := tx.Bucket(runsBucket).Get([]byte(id))
+		if raw == nil {
+			return ErrRunNotFound
+		}
+		var run Run
+		if err := json.Unmarshal(raw, &run); err != nil {
+			return err
+		}
+		if err := tx.Bucket(runsBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+		if err := tx.Bucket(summaryBucket).Delete([]byte(id)); err != nil {
+			return err
+		}
+		if err := addKeyStats(tx, run.Keys, -1); err != nil {
+			return err
+		}
+		return b.bump(tx)
+	})
+	if err != nil {
+		b.byID = nil
+		return err
+	}
+	if index, ok := b.byID[id]; ok {
+		b.runs = append(b.runs[:index], b.runs[index+1:]...)
+		b.reindex()
+	}
+	return nil
+}
+
+// add (sign 1) or remove (sign -1) the key stats of one run from the totals
+func addKeyStats(tx *bolt.Tx, keys map[string]KeyStat, sign int) error {
+	bucket := tx.Bucket(keysBucket)
+	for key, stat := range keys {
+		var total KeyStat
+		if raw := bucket.Get([]byte(key)); raw != nil {
+			if err := json.Unmarshal(raw, &total); err != nil {
+				return err
+			}
+		}
+		total.Hits += sign * stat.Hits
+		total.Misses += sign * stat.Misses
+		raw, err := json.Marshal(total)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put([]byte(key), raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *BoltBackend) Has(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, ok := b.byID[id]
+	return ok
+}
+
+func (b *BoltBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.runs)
+}
+
+func (b *BoltBackend) Fingerprint() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strconv.FormatUint(b.generation, 10) + ":" + strconv.Itoa(len(b.runs))
+}
+
+func (b *BoltBackend) KeyStats() (map[string]KeyStat, error) {
+	totals := map[string]KeyStat{}
+	err := b.view(func(tx *bolt.Tx) error {
+		return tx.Bucket(keysBucket).ForEach(func(key, raw []byte) error {
+			var stat KeyStat
+			if err := json.Unmarshal(raw, &stat); err != nil {
+				return err
+			}
+			if stat.Hits != 0 || stat.Misses != 0 {
+				totals[string(key)] = stat
+			}
+			return nil
+		})
+	})
+	return totals, err
+}
+
+// Refresh reads the summaries again when another process changed the runs
+// and reports if the runs or the state changed since the last look. The
+// database is only opened when the file changed, and the look is skipped
+// while a read or write of this process is running instead of waiting.
+func (b *BoltBackend) Refresh() (bool, error) {
+	if !b.mu.TryLock() {
+		return false, nil
+	}
+	defer b.mu.Unlock()
+	modified := modTime(b.path)
+	if modified.Equal(b.modSeen) {
+		return false, nil
+	}
+	b.modSeen = modified
+	before := b.generation
+	stateChanged := false
+	err := b.view(func(tx *bolt.Tx) error {
+		if seen := readCounter(tx, stateGenerationKey); seen != b.stateSeen {
+			b.stateSeen = seen
+			stateChanged = true
+		}
+		return b.sync(tx)
+	})
+	if err != nil {
+		return false, err
+	}
+	return stateChanged || b.generation != before, nil
+}
+
+// the database is never held open
+func (b *BoltBackend) Close() error {
+	return nil
+}
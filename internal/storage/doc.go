@@ -1,2 +1,8 @@
-// Package storage provides JSON persistence for user preferences and scores.
+// Package storage persists user preferences, best scores and the history
+// of finished runs behind the Backend interface: json files (state.json and
+// history.jsonl, the default), a bbolt database for large histories and an
+// in-memory store for tests.
+//
+// The state carries a schema version, older states are upgraded on load by
+// the migrations in migrate.go and states from a newer version are refused.
 package storage
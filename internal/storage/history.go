@@ -0,0 +1,642 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// ErrRunNotFound is returned when a run id is not in the history
+var ErrRunNotFound = errors.New("storage: run not found")
+
+// compaction only runs when at least this many lines are dead
+const compactThreshold = 64
+
+// History is an append-only JSON Lines file with one run per line. Deletes
+// are written as tombstone lines and dropped when the file is compacted.
+// The summaries of all live runs are kept in memory and cached in an index
+// file next to the history, so opening only has to read the lines that were
+// added since the index was written. Writes take a file lock and first read
+// what other gotype processes appended, so several windows can share it.
+type History struct {
+	mu         sync.Mutex
+	path       string
+	entries    []indexEntry
+	byID       map[string]int
+	byKey      map[string][]int
+	size       int64
+	tombstones int
+	dirty      bool
+	// the file the entries were read from, to notice a rewrite by another
+	// process
+	info os.FileInfo
+}
+
+// one line of the history file, either a run or a tombstone
+type historyLine struct {
+	Deleted string `json:"deleted,omitempty"`
+	Summary
+}
+
+// cached position and summary of one live run
+type indexEntry struct {
+	Summary
+	Offset int64 `json:"offset"`
+	Length int   `json:"length"`
+}
+
+// content of the index file
+type historyIndex struct {
+	Size       int64        `json:"size"`
+	Tombstones int          `json:"tombstones"`
+	Entries    []indexEntry `json:"entries"`
+}
+
+// OpenHistory loads the history at path, a missing file is an empty history
+func OpenHistory(path string) (*History, error) {
+	h := &History{path: path}
+	unlock, err := readLock(path)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			h.reindex()
+			return h, nil
+		}
+		return nil, err
+	}
+	from := int64(0)
+	if index, ok := h.readIndex(info.Size()); ok {
+		h.entries = index.Entries
+		h.tombstones = index.Tombstones
+		from = index.Size
+	}
+	h.reindex()
+	if err := h.scan(from); err != nil {
+		return nil, err
+	}
+	h.info = info
+	return h, nil
+}
+
+// Refresh picks up the runs and deletes other processes wrote since the
+// last look, it reports if anything changed
+func (h *History) Refresh() (bool, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	unlock, err := readLock(h.path)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	size, count := h.size, len(h.entries)
+	if err := h.catchUp(); err != nil {
+		return false, err
+	}
+	return h.size != size || len(h.entries) != count, nil
+}
+
+// catchUp reads the lines appended since the last scan, a file that was
+// replaced (compacted by another process) is read again from the start.
+// The caller holds both locks.
+func (h *History) catchUp() error {
+	info, err := os.Stat(h.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if (h.info != nil && !os.SameFile(h.info, info)) || info.Size() < h.size {
+		h.entries = nil
+		h.tombstones = 0
+		h.size = 0
+		h.reindex()
+	}
+	h.info = info
+	if info.Size() == h.size {
+		return nil
+	}
+	return h.scan(h.size)
+}
+
+// index file path for the history
+func (h *History) indexPath() string {
+	return h.path + ".idx"
+}
+
+// readIndex loads the index and checks it still matches the history file
+func (h *History) readIndex(fileSize int64) (historyIndex, bool) {
+	raw, err := os.ReadFile(h.indexPath())
+	if err != nil {
+		return historyIndex{}, false
+	}
+	var index historyIndex
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return historyIndex{}, false
+	}
+	// the file was rewritten or truncated behind our back
+	if index.Size > fileSize {
+		return historyIndex{}, false
+	}
+	if index.Size > 0 {
+		file, err := os.Open(h.path)
+		if err != nil {
+			return historyIndex{}, false
+		}
+		defer file.Close()
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, index.Size-1); err != nil || last[0] != '\n' {
+			return historyIndex{}, false
+		}
+	}
+	return index, true
+}
+
+// scan reads the lines from the given offset to the end of the file. It
+// stops before a partly written line at the end (a crash while appending),
+// size stays at its start and the next append writes over it.
+func (h *History) scan(from int64) error {
+	file, err := os.Open(h.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if _, err := file.Seek(from, io.SeekStart); err != nil {
+		return err
+	}
+	reader := bufio.NewReaderSize(file, 64*1024)
+	offset := from
+	for {
+		raw, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		h.applyLine(raw, offset)
+		offset += int64(len(raw))
+	}
+	h.dropRemoved()
+	if offset != from {
+		h.dirty = true
+	}
+	h.size = offset
+	return nil
+}
+
+// applyLine adds the run or applies the tombstone found in one line
+func (h *History) applyLine(raw []byte, offset int64) {
+	var line historyLine
+	if err := json.Unmarshal(raw, &line); err != nil || (line.Deleted == "" && line.ID == "") {
+		// broken lines are treated like tombstones so compaction drops them
+		h.tombstones++
+		return
+	}
+	if line.Deleted != "" {
+		h.tombstones++
+		h.remove(line.Deleted)
+		return
+	}
+	if _, ok := h.byID[line.ID]; ok {
+		h.tombstones++
+		return
+	}
+	h.add(indexEntry{Summary: line.Summary, Offset: offset, Length: len(raw)})
+}
+
+// rebuild the lookup maps from the entries
+func (h *History) reindex() {
+	h.byID = make(map[string]int, len(h.entries))
+	h.byKey = make(map[string][]int)
+	for i, entry := range h.entries {
+		h.byID[entry.ID] = i
+		h.byKey[entry.Key] = append(h.byKey[entry.Key], i)
+	}
+}
+
+// add one entry at the end and keep the maps in sync
+func (h *History) add(entry indexEntry) {
+	index := len(h.entries)
+	h.entries = append(h.entries, entry)
+	h.byID[entry.ID] = index
+	h.byKey[entry.Key] = append(h.byKey[entry.Key], index)
+}
+
+// remove one entry by id, returns false if it is not there. The entry is
+// only blanked, dropRemoved takes the blanked entries out so a scan over
+// many tombstones rebuilds the maps once.
+func (h *History) remove(id string) bool {
+	index, ok := h.byID[id]
+	if !ok {
+		return false
+	}
+	delete(h.byID, id)
+	h.entries[index].ID = ""
+	return true
+}
+
+// take out the entries blanked by remove and rebuild the maps
+func (h *History) dropRemoved() {
+	count := len(h.entries)
+	h.entries = slices.DeleteFunc(h.entries, func(entry indexEntry) bool { return entry.ID == "" })
+	if len(h.entries) != count {
+		h.reindex()
+	}
+}
+
+// Append writes a finished run at the end of the history, a missing id is
+// generated from the start time and the seed
+func (h *History) Append(run Run) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	unlock, err := lockFile(h.path)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	if err := h.catchUp(); err != nil {
+		return "", err
+	}
+	if run.ID == "" {
+		run.ID = newRunID(run.Summary)
+	}
+	for {
+		if _, ok := h.byID[run.ID]; !ok {
+			break
+		}
+		run.ID += "x"
+	}
+	raw, err := json.Marshal(run)
+	if err != nil {
+		return "", err
+	}
+	offset, err := h.appendLine(raw)
+	if err != nil {
+		return "", err
+	}
+	h.add(indexEntry{Summary: run.Summary, Offset: offset, Length: len(raw) + 1})
+	return run.ID, nil
+}
+
+// Delete drops a run from the history by writing a tombstone
+func (h *History) Delete(id string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	unlock, err := lockFile(h.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := h.catchUp(); err != nil {
+		return err
+	}
+	if _, ok := h.byID[id]; !ok {
+		return ErrRunNotFound
+	}
+	raw, err := json.Marshal(struct {
+		Deleted string `json:"deleted"`
+	}{Deleted: id})
+	if err != nil {
+		return err
+	}
+	if _, err := h.appendLine(raw); err != nil {
+		return err
+	}
+	h.remove(id)
+	h.dropRemoved()
+	h.tombstones++
+	return nil
+}
+
+// appendLine writes one line at the end of the file and returns its offset
+func (h *History) appendLine(raw []byte) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(h.path), 0o755); err != nil {
+		return 0, err
+	}
+	file, err := os.OpenFile(h.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		file.Close()
+		return 0, err
+	}
+	// scan stopped before a line cut off by a crash, the caller holds the
+	// lock so nobody is still writing it
+	if offset > h.size {
+		if err := file.Truncate(h.size); err != nil {
+			file.Close()
+			return 0, err
+		}
+		offset = h.size
+	}
+	if _, err := file.Write(append(raw, '\n')); err != nil {
+		// drop a partial line so a retry starts on a clean line
+		_ = file.Truncate(offset)
+		file.Close()
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+	h.size = offset + int64(len(raw)) + 1
+	h.dirty = true
+	return offset, nil
+}
+
+// Get reads the full run with per-key stats and samples from disk
+func (h *History) Get(id string) (Run, error) {
+	file, entries, err := h.openEntries(func() []indexEntry {
+		if index, ok := h.byID[id]; ok {
+			return []indexEntry{h.entries[index]}
+		}
+		return nil
+	})
+	if err != nil || len(entries) == 0 {
+		if err == nil {
+			err = ErrRunNotFound
+		}
+		return Run{}, err
+	}
+	defer file.Close()
+	raw := make([]byte, entries[0].Length)
+	if _, err := file.ReadAt(raw, entries[0].Offset); err != nil {
+		return Run{}, err
+	}
+	var run Run
+	if err := json.Unmarshal(raw, &run); err != nil {
+		return Run{}, err
+	}
+	return run, nil
+}
+
+// Each reads the full runs accepted by keep from disk, oldest first, and
+// stops at the first error returned by fn
+func (h *History) Each(keep func(Summary) bool, fn func(Run) error) error {
+	file, entries, err := h.openEntries(func() []indexEntry {
+		entries := make([]indexEntry, 0, len(h.entries))
+		for _, entry := range h.entries {
+			if keep == nil || keep(entry.Summary) {
+				entries = append(entries, entry)
+			}
+		}
+		return entries
+	})
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+	defer file.Close()
+	var raw []byte
+	for _, entry := range entries {
+		if cap(raw) < entry.Length {
+			raw = make([]byte, entry.Length)
+		}
+		raw = raw[:entry.Length]
+		if _, err := file.ReadAt(raw, entry.Offset); err != nil {
+			return err
+		}
+		var run Run
+		if err := json.Unmarshal(raw, &run); err != nil {
+			return err
+		}
+		if err := fn(run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// openEntries catches up with the file and opens it under both locks, pick
+// chooses the entries to read. Their offsets stay good in the opened file
+// after the locks are let go: other processes only append, and a compaction
+// writes a new file in its place.
+func (h *History) openEntries(pick func() []indexEntry) (*os.File, []indexEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	unlock, err := readLock(h.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer unlock()
+	if err := h.catchUp(); err != nil {
+		return nil, nil, err
+	}
+	entries := pick()
+	if len(entries) == 0 {
+		return nil, nil, nil
+	}
+	file, err := os.Open(h.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, entries, nil
+}
+
+// Runs returns the summaries of all live runs, oldest first
+func (h *History) Runs() []Summary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	runs := make([]Summary, len(h.entries))
+	for i, entry := range h.entries {
+		runs[i] = entry.Summary
+	}
+	return runs
+}
+
+// ByKey returns the summaries for one score key, oldest first
+func (h *History) ByKey(key string) []Summary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	indexes := h.byKey[key]
+	runs := make([]Summary, len(indexes))
+	for i, index := range indexes {
+		runs[i] = h.entries[index].Summary
+	}
+	return runs
+}
+
+// Keys returns all score keys that have at least one run, sorted
+func (h *History) Keys() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	keys := make([]string, 0, len(h.byKey))
+	for key, indexes := range h.byKey {
+		if len(indexes) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Has reports if a live run with the id is in the history
+func (h *History) Has(id string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, ok := h.byID[id]
+	return ok
+}
+
+// Len returns the number of live runs
+func (h *History) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.entries)
+}
+
+// Fingerprint changes whenever the history changes, it is used as the key
+// for caches built from the runs
+func (h *History) Fingerprint() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return strconv.FormatInt(h.size, 10) + ":" + strconv.Itoa(len(h.entries)) + ":" +
+		strconv.Itoa(h.tombstones)
+}
+
+// Compact rewrites the history without tombstones and broken lines
+func (h *History) Compact() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	unlock, err := lockFile(h.path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := h.catchUp(); err != nil {
+		return err
+	}
+	return h.compact()
+}
+
+func (h *History) compact() error {
+	if h.tombstones == 0 {
+		return nil
+	}
+	src, err := os.Open(h.path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.CreateTemp(filepath.Dir(h.path), "gotype-history-*.jsonl")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dst.Name())
+	writer := bufio.NewWriterSize(dst, 64*1024)
+	entries := make([]indexEntry, len(h.entries))
+	offset := int64(0)
+	for i, entry := range h.entries {
+		raw := make([]byte, entry.Length)
+		if _, err := src.ReadAt(raw, entry.Offset); err != nil {
+			dst.Close()
+			return err
+		}
+		if _, err := writer.Write(raw); err != nil {
+			dst.Close()
+			return err
+		}
+		entry.Offset = offset
+		entries[i] = entry
+		offset += int64(entry.Length)
+	}
+	if err := writer.Flush(); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(dst.Name(), h.path); err != nil {
+		return err
+	}
+	if info, err := os.Stat(h.path); err == nil {
+		h.info = info
+	}
+	h.entries = entries
+	h.size = offset
+	h.tombstones = 0
+	h.dirty = true
+	return h.writeIndex()
+}
+
+// writeIndex saves the summaries and offsets so the next open is fast
+func (h *History) writeIndex() error {
+	index := historyIndex{Size: h.size, Tombstones: h.tombstones, Entries: h.entries}
+	if err := writeJSONFile(h.indexPath(), index); err != nil {
+		return err
+	}
+	h.dirty = false
+	return nil
+}
+
+// Close compacts the history when enough lines are dead and writes the index
+func (h *History) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	unlock, err := lockFile(h.path)
+	// a history that can't be written was only read, its index stays
+	if errors.Is(err, fs.ErrPermission) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	// the index has to cover what other processes wrote too
+	if err := h.catchUp(); err != nil {
+		return err
+	}
+	if h.tombstones >= compactThreshold && h.tombstones > len(h.entries)/4 {
+		if err := h.compact(); err != nil {
+			return err
+		}
+	}
+	if !h.dirty {
+		return nil
+	}
+	if err := h.writeIndex(); err != nil && !errors.Is(err, fs.ErrPermission) {
+		return err
+	}
+	return nil
+}
+
+// build a short id from the start time and the seed
+func newRunID(summary Summary) string {
+	return strconv.FormatInt(summary.StartedAt, 36) + "-" +
+		strconv.FormatInt(summary.Seed&0xffffff, 36)
+}
+
+// write a value as json next to the target and rename it into place
+func writeJSONFile(path string, value any) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(dir, "gotype-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if err := json.NewEncoder(file).Encode(value); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func testRun(id, key string, wpm int) Run {
+	return Run{
+		Summary: Summary{ID: id, Key: key, Metrics: Metrics{WPM: wpm}},
+		Samples: []Sample{{Second: 1, WPM: wpm}},
+	}
+}
+
+func TestHistoryAppendReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	history, err := OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range []string{"a", "b", "a"} {
+		if _, err := history.Append(testRun(string(rune('1'+i)), key, 50+i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := history.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// one more line written after the index, it has to be picked up
+	history, err = OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := history.Append(testRun("4", "b", 70)); err != nil {
+		t.Fatal(err)
+	}
+	history, err = OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := history.Len(); got != 4 {
+		t.Fatalf("len = %d, want 4", got)
+	}
+	if got := len(history.ByKey("a")); got != 2 {
+		t.Fatalf("runs for a = %d, want 2", got)
+	}
+	run, err := history.Get("4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Metrics.WPM != 70 || len(run.Samples) != 1 {
+		t.Fatalf("unexpected run %+v", run)
+	}
+}
+
+func TestHistoryDeleteCompact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	history, err := OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"1", "2", "3"} {
+		if _, err := history.Append(testRun(id, "a", 60)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := history.Delete("2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := history.Delete("2"); err != ErrRunNotFound {
+		t.Fatalf("second delete err = %v, want ErrRunNotFound", err)
+	}
+	if err := history.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := history.Get("3"); err != nil {
+		t.Fatalf("get after compact: %v", err)
+	}
+	history, err = OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	runs := history.Runs()
+	if len(runs) != 2 || runs[0].ID != "1" || runs[1].ID != "3" {
+		t.Fatalf("unexpected runs %+v", runs)
+	}
+}
+
+func TestHistoryPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	history, err := OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := history.Append(testRun("1", "a", 60)); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`{"id":"2","ke`)
+	file.Close()
+	before, _ := os.Stat(path)
+	history, err = OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// reading leaves the file alone, the next append drops the cut line
+	if after, _ := os.Stat(path); history.Len() != 1 || after.Size() != before.Size() {
+		t.Fatalf("len = %d, size %d -> %d", history.Len(), before.Size(), after.Size())
+	}
+	if _, err := history.Append(testRun("3", "a", 60)); err != nil {
+		t.Fatal(err)
+	}
+	history, err = OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := history.Len(); got != 2 {
+		t.Fatalf("len = %d, want 2", got)
+	}
+}
+
+func TestHistorySharedBetweenProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	first, err := OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.Append(testRun("1", "a", 50)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := second.Append(testRun("2", "a", 60)); err != nil {
+		t.Fatal(err)
+	}
+	// the second one read the first run before appending its own
+	if second.Len() != 2 {
+		t.Fatalf("second sees %d runs, want 2", second.Len())
+	}
+	if changed, err := first.Refresh(); err != nil || !changed || first.Len() != 2 {
+		t.Fatalf("refresh = %v %v, %d runs", changed, err, first.Len())
+	}
+	if err := second.Delete("1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	// the file was rewritten under the first one, it has to read it again
+	if _, err := first.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	run, err := first.Get("2")
+	if err != nil || first.Len() != 1 || run.Metrics.WPM != 60 {
+		t.Fatalf("after compaction: %d runs, %+v %v", first.Len(), run.Summary, err)
+	}
+	if err := first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Close(); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := OpenHistory(path)
+	if err != nil || reopened.Len() != 1 {
+		t.Fatalf("reopened with %d runs: %v", reopened.Len(), err)
+	}
+}
+
+func TestHistoryReadsAfterCompactionElsewhere(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	first, err := OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := OpenHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range []string{"1", "2", "3", "4", "5"} {
+		if _, err := first.Append(testRun(id, "a", 50+i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := second.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"1", "2", "4"} {
+		if err := second.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// the first one reads the tombstones in one scan
+	if changed, err := first.Refresh(); err != nil || !changed || first.Len() != 2 || len(first.ByKey("a")) != 2 {
+		t.Fatalf("refresh = %v %v, %+v", changed, err, first.Runs())
+	}
+	if _, err := second.Append(testRun("6", "a", 60)); err != nil {
+		t.Fatal(err)
+	}
+	if err := second.Compact(); err != nil {
+		t.Fatal(err)
+	}
+	// no Refresh: the offsets the first one had are gone with the old file
+	run, err := first.Get("5")
+	if err != nil || run.ID != "5" || run.Metrics.WPM != 54 {
+		t.Fatalf("get = %+v, %v", run.Summary, err)
+	}
+	if _, err := first.Get("1"); err != ErrRunNotFound {
+		t.Fatalf("get of a deleted run: %v", err)
+	}
+	var ids []string
+	err = first.Each(nil, func(run Run) error {
+		ids = append(ids, run.ID)
+		return nil
+	})
+	if err != nil || !slices.Equal(ids, []string{"3", "5", "6"}) || len(first.ByKey("a")) != 3 {
+		t.Fatalf("each = %v, %v", ids, err)
+	}
+}
@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"os"
+	"time"
+)
+
+// JSONBackend keeps the state in state.json and the runs in the
+// history.jsonl file next to it
+type JSONBackend struct {
+	*History
+	path      string
+	stateSeen time.Time
+}
+
+// OpenJSON opens the json files for the state file at path
+func OpenJSON(path string) (*JSONBackend, error) {
+	history, err := OpenHistory(HistoryPath(path))
+	if err != nil {
+		return nil, err
+	}
+	return &JSONBackend{History: history, path: path}, nil
+}
+
+// Path is the state file
+func (b *JSONBackend) Path() string {
+	return b.path
+}
+
+func (b *JSONBackend) Load() (Data, error) {
+	b.stateSeen = modTime(b.path)
+	return Load(b.path)
+}
+
+func (b *JSONBackend) Save(data Data) (Data, error) {
+	var times stateTimes
+	merged, err := saveMerged(b.path, data, &times)
+	if err != nil {
+		return Data{}, err
+	}
+	// this process's own write is no news for Refresh, a write by another
+	// one since the last look still is
+	if times.before.Equal(b.stateSeen) {
+		b.stateSeen = times.after
+	}
+	return merged, nil
+}
+
+func (b *JSONBackend) KeyStats() (map[string]KeyStat, error) {
+	return sumKeyStats(b.Each)
+}
+
+// Refresh reads new history lines and checks if the state file was written
+// since it was last loaded
+func (b *JSONBackend) Refresh() (bool, error) {
+	changed, err := b.History.Refresh()
+	if err != nil {
+		return false, err
+	}
+	if seen := modTime(b.path); !seen.Equal(b.stateSeen) {
+		b.stateSeen = seen
+		changed = true
+	}
+	return changed, nil
+}
+
+// modification time of a file, zero when it is missing
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// lockPath is the file the advisory lock for path is taken on, the data
+// file itself is replaced on every save so it can't hold the lock
+func lockPath(path string) string {
+	return path + ".lock"
+}
+
+// lockFile takes an exclusive advisory lock for path, waiting while another
+// gotype holds it, and returns the function that releases it
+func lockFile(path string) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return acquireLock(lockPath(path))
+}
+
+// readLock is lockFile for a reader. A reader never changes the data, so
+// where the lock can't be taken for lack of permission (a read-only file or
+// directory) it reads without it.
+func readLock(path string) (func(), error) {
+	unlock, err := lockFile(path)
+	if errors.Is(err, fs.ErrPermission) {
+		return func() {}, nil
+	}
+	return unlock, err
+}
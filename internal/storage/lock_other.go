@@ -0,0 +1,42 @@
+//go:build !unix
+
+package storage
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// without flock the lock is a file that only one process can create, one
+// left behind by a crash is taken over once it is older than staleLock
+const (
+	lockRetry = 10 * time.Millisecond
+	lockWait  = 5 * time.Second
+	staleLock = 10 * time.Second
+)
+
+// ErrLocked is returned when another gotype holds the lock for too long
+var ErrLocked = errors.New("state file is locked by another gotype")
+
+func acquireLock(name string) (func(), error) {
+	deadline := time.Now().Add(lockWait)
+	for {
+		file, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+		if err == nil {
+			file.Close()
+			return func() { _ = os.Remove(name) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(name); err == nil && time.Since(info.ModTime()) > staleLock {
+			_ = os.Remove(name)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, ErrLocked
+		}
+		time.Sleep(lockRetry)
+	}
+}
@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// MemoryBackend keeps everything in memory, for tests
+type MemoryBackend struct {
+	mu      sync.Mutex
+	data    Data
+	runs    []Run
+	changes int
+}
+
+// NewMemory creates an empty memory backend
+func NewMemory() *MemoryBackend {
+	return &MemoryBackend{data: Data{Version: CurrentVersion, BestScores: map[string]BestScore{}}}
+}
+
+func (b *MemoryBackend) Load() (Data, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	data := b.data
+	data.BestScores = maps.Clone(b.data.BestScores)
+	data.Presets = slices.Clone(b.data.Presets)
+	return data, nil
+}
+
+func (b *MemoryBackend) Save(data Data) (Data, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = Merge(b.data, data)
+	b.data.Version = CurrentVersion
+	saved := b.data
+	saved.BestScores = maps.Clone(b.data.BestScores)
+	saved.Presets = slices.Clone(b.data.Presets)
+	return saved, nil
+}
+
+func (b *MemoryBackend) Runs() []Summary {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	runs := make([]Summary, len(b.runs))
+	for i, run := range b.runs {
+		runs[i] = run.Summary
+	}
+	return runs
+}
+
+func (b *MemoryBackend) Get(id string) (Run, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if index := b.find(id); index >= 0 {
+		return b.runs[index], nil
+	}
+	return Run{}, ErrRunNotFound
+}
+
+func (b *MemoryBackend) Each(keep func(Summary) bool, fn func(Run) error) error {
+	b.mu.Lock()
+	runs := make([]Run, 0, len(b.runs))
+	for _, run := range b.runs {
+		if keep == nil || keep(run.Summary) {
+			runs = append(runs, run)
+		}
+	}
+	b.mu.Unlock()
+	for _, run := range runs {
+		if err := fn(run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBackend) Append(run Run) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if run.ID == "" {
+		run.ID = newRunID(run.Summary)
+	}
+	for b.find(run.ID) >= 0 {
+		run.ID += "x"
+	}
+	b.runs = append(b.runs, run)
+	b.changes++
+	return run.ID, nil
+}
+
+func (b *MemoryBackend) Delete(id string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	index := b.find(id)
+	if index < 0 {
+		return ErrRunNotFound
+	}
+	b.runs = append(b.runs[:index], b.runs[index+1:]...)
+	b.changes++
+	return nil
+}
+
+func (b *MemoryBackend) Has(id string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.find(id) >= 0
+}
+
+func (b *MemoryBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.runs)
+}
+
+func (b *MemoryBackend) Fingerprint() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strconv.Itoa(b.changes)
+}
+
+func (b *MemoryBackend) KeyStats() (map[string]KeyStat, error) {
+	return sumKeyStats(b.Each)
+}
+
+// nothing else can write to memory
+func (b *MemoryBackend) Refresh() (bool, error) {
+	return false, nil
+}
+
+func (b *MemoryBackend) Close() error {
+	return nil
+}
+
+// index of a run by id, -1 when it is missing
+func (b *MemoryBackend) find(id string) int {
+	for i, run := range b.runs {
+		if run.ID == id {
+			return i
+		}
+	}
+	return -1
+}
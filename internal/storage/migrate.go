@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CurrentVersion is the schema version of the state file written by this
+// build. Bump it together with a new entry in migrations whenever the shape
+// of Data changes in a way older files need to be upgraded for.
+const CurrentVersion = 2
+
+// ErrNewerVersion is returned for state files written by a newer gotype,
+// they are never overwritten
+var ErrNewerVersion = errors.New("state file was written by a newer version of gotype")
+
+// a migration upgrades the decoded json of one version to the next one
+type migration func(state map[string]any) error
+
+// migrations[i] upgrades a file from version i to version i+1
+var migrations = []migration{
+	migrateV0,
+	migrateV1,
+}
+
+// migrate decodes the raw state file and upgrades it to CurrentVersion,
+// returns the upgraded json and the version the file had
+func migrate(raw []byte) ([]byte, int, error) {
+	var state map[string]any
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, 0, err
+	}
+	if state == nil {
+		state = map[string]any{}
+	}
+	version, err := stateVersion(state)
+	if err != nil {
+		return nil, 0, err
+	}
+	if version > CurrentVersion {
+		return nil, version, fmt.Errorf("%w (file version %d, supported up to %d)",
+			ErrNewerVersion, version, CurrentVersion)
+	}
+	if version == CurrentVersion {
+		return raw, version, nil
+	}
+	for v := version; v < CurrentVersion; v++ {
+		if err := migrations[v](state); err != nil {
+			return nil, version, fmt.Errorf("migrate state from version %d: %w", v, err)
+		}
+	}
+	state["version"] = CurrentVersion
+	upgraded, err := json.Marshal(state)
+	if err != nil {
+		return nil, version, err
+	}
+	return upgraded, version, nil
+}
+
+// read the version field, files from before versioning have none
+func stateVersion(state map[string]any) (int, error) {
+	value, ok := state["version"]
+	if !ok || value == nil {
+		return 0, nil
+	}
+	number, ok := value.(float64)
+	if !ok || number < 0 || number != float64(int(number)) {
+		return 0, fmt.Errorf("invalid state version %v", value)
+	}
+	return int(number), nil
+}
+
+// version 0 is every file written before the version field existed:
+//   - the "zen" mode was removed, it is stored as "time" now
+//   - best score keys had no formula, those scores were measured as
+//     correct chars / 5 per minute which is the "plain" formula
+func migrateV0(state map[string]any) error {
+	if prefs, ok := state["preferences"].(map[string]any); ok {
+		if prefs["mode"] == "zen" {
+			prefs["mode"] = "time"
+		}
+	}
+	scores, ok := state["best_scores"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	upgraded := make(map[string]any, len(scores))
+	for key, value := range scores {
+		if strings.Contains(key, "|formula=") {
+			upgraded[key] = value
+		}
+	}
+	// a file can have a score under both the old and the new key, the old
+	// one only replaces the new one when it is better
+	for key, value := range scores {
+		if strings.Contains(key, "|formula=") {
+			continue
+		}
+		if score, ok := value.(map[string]any); ok {
+			score["formula"] = "plain"
+		}
+		key += "|formula=plain"
+		if current, ok := upgraded[key]; ok && !betterScore(rawScore(value), rawScore(current)) {
+			continue
+		}
+		upgraded[key] = value
+	}
+	state["best_scores"] = upgraded
+	return nil
+}
+
+// the speed and accuracy of a best score that is not decoded yet, zero
+// for a value that isn't one
+func rawScore(value any) BestScore {
+	score, _ := value.(map[string]any)
+	wpm, _ := score["wpm"].(float64)
+	accuracy, _ := score["accuracy"].(float64)
+	return BestScore{WPM: int(wpm), Accuracy: int(accuracy)}
+}
+
+// version 2 added the presets, there is nothing to change but an older
+// gotype must not save the file and drop them
+func migrateV1(state map[string]any) error {
+	return nil
+}
@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// copy a fixture into a temp dir so saving can't touch testdata
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+	raw, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadVersion0(t *testing.T) {
+	data, err := Load(fixture(t, "state_v0.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Version != CurrentVersion {
+		t.Fatalf("version = %d, want %d", data.Version, CurrentVersion)
+	}
+	if data.Preferences.Mode != "time" {
+		t.Fatalf("mode = %q, want zen migrated to time", data.Preferences.Mode)
+	}
+	if len(data.BestScores) != 2 {
+		t.Fatalf("got %d best scores, want 2", len(data.BestScores))
+	}
+	score, ok := data.BestScores["time:60s|punct=true|numbers=false|formula=plain"]
+	if !ok || score.WPM != 72 || score.Formula != "plain" {
+		t.Fatalf("legacy score not migrated: %+v %v", score, data.BestScores)
+	}
+}
+
+func TestLoadVersion0WithFormula(t *testing.T) {
+	data, err := Load(fixture(t, "state_v0_formula.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Preferences.Formula != "monkeytype" || data.Preferences.Unit != "cpm" {
+		t.Fatalf("preferences lost: %+v", data.Preferences)
+	}
+	if _, ok := data.BestScores["words:50|punct=false|numbers=true|formula=plain"]; !ok {
+		t.Fatalf("legacy key not renamed: %v", data.BestScores)
+	}
+	score := data.BestScores["words:50|punct=false|numbers=true|formula=monkeytype"]
+	if score.WPM != 61 || score.CPM != 305 {
+		t.Fatalf("current key changed: %+v", score)
+	}
+}
+
+func TestLoadVersion1(t *testing.T) {
+	data, err := Load(fixture(t, "state_v1.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	score := data.BestScores["time:15s|punct=false|numbers=false|formula=standard"]
+	if data.Preferences.ThemeID != "gruvbox" || score.WPM != 90 {
+		t.Fatalf("unexpected data: %+v", data)
+	}
+}
+
+func TestLoadCurrentVersion(t *testing.T) {
+	data, err := Load(fixture(t, "state_v2.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Presets) != 1 || data.Presets[0].Options.DurationSeconds != 600 {
+		t.Fatalf("presets = %+v", data.Presets)
+	}
+	if data.BestScores["preset=exam|time:600s|punct=false|numbers=true|formula=standard"].WPM != 72 {
+		t.Fatalf("preset best score missing: %+v", data.BestScores)
+	}
+}
+
+func TestNewerVersionRefused(t *testing.T) {
+	path := fixture(t, "state_future.json")
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(path); !errors.Is(err, ErrNewerVersion) {
+		t.Fatalf("load err = %v, want ErrNewerVersion", err)
+	}
+	if err := Save(path, Data{}); !errors.Is(err, ErrNewerVersion) {
+		t.Fatalf("save err = %v, want ErrNewerVersion", err)
+	}
+	after, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(before) != string(after) {
+		t.Fatal("newer state file was overwritten")
+	}
+}
+
+func TestSaveWritesVersion(t *testing.T) {
+	path := fixture(t, "state_v0.json")
+	data, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data.Version = 0
+	if err := Save(path, data); err != nil {
+		t.Fatal(err)
+	}
+	header, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Version != CurrentVersion || len(reloaded.BestScores) != 2 {
+		t.Fatalf("round trip lost data: %s", header)
+	}
+}
+
+func TestLoadVersion0KeepsHigherScore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	for _, tc := range []struct {
+		legacy, current, want int
+	}{
+		{legacy: 80, current: 70, want: 80},
+		{legacy: 60, current: 70, want: 70},
+	} {
+		raw := fmt.Sprintf(`{"best_scores": {
+			"time:30s|punct=false|numbers=false": {"wpm": %d, "accuracy": 95},
+			"time:30s|punct=false|numbers=false|formula=plain": {"wpm": %d, "accuracy": 95, "formula": "plain"}
+		}}`, tc.legacy, tc.current)
+		if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		data, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		score := data.BestScores["time:30s|punct=false|numbers=false|formula=plain"]
+		if len(data.BestScores) != 1 || score.WPM != tc.want || score.Formula != "plain" {
+			t.Fatalf("legacy %d, current %d: %+v", tc.legacy, tc.current, data.BestScores)
+		}
+	}
+}
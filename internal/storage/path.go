@@ -1,10 +1,17 @@
 package storage
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 )
 
+// DefaultProfile is the profile stored directly in the config directory,
+// where gotype kept everything before profiles existed
+const DefaultProfile = "default"
+
 // using os for getting the user config path on operation system
 func DefaultPath() (string, error) {
 	configDir, err := os.UserConfigDir()
@@ -13,3 +20,72 @@ func DefaultPath() (string, error) {
 	}
 	return filepath.Join(configDir, "gotype", "state.json"), nil
 }
+
+// the history file lives next to the state file
+func HistoryPath(statePath string) string {
+	return filepath.Join(filepath.Dir(statePath), "history.jsonl")
+}
+
+// StatePath is the state file in a profile directory
+func StatePath(dir string) string {
+	return filepath.Join(dir, "state.json")
+}
+
+// profile names are used as directory names
+var profileName = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// ValidProfileName checks a profile name can be used as a directory
+func ValidProfileName(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q (use up to 32 letters, digits, - and _)", name)
+	}
+	return nil
+}
+
+// ValidPresetName checks a preset name, presets follow the profile rules so
+// they are easy to type after --preset
+func ValidPresetName(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("invalid preset name %q (use up to 32 letters, digits, - and _)", name)
+	}
+	return nil
+}
+
+// ProfileDir is the directory holding everything of one profile, the
+// default profile keeps the files directly in the config directory and the
+// others are in profiles/<name> next to them
+func ProfileDir(name string) (string, error) {
+	if err := ValidProfileName(name); err != nil {
+		return "", err
+	}
+	path, err := DefaultPath()
+	if err != nil {
+		return "", err
+	}
+	base := filepath.Dir(path)
+	if name == DefaultProfile {
+		return base, nil
+	}
+	return filepath.Join(base, "profiles", name), nil
+}
+
+// Profiles lists the default profile and every profile directory, sorted
+// with the default first
+func Profiles() ([]string, error) {
+	path, err := DefaultPath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(filepath.Dir(path), "profiles"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() != DefaultProfile && ValidProfileName(entry.Name()) == nil {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultProfile}, names...), nil
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Setenv("HOME", config)
+	base, err := os.UserConfigDir()
+	if err != nil {
+		t.Skip(err)
+	}
+
+	// the default profile is where gotype always kept its files
+	dir, err := ProfileDir(DefaultProfile)
+	if err != nil || dir != filepath.Join(base, "gotype") {
+		t.Fatalf("default dir = %q %v", dir, err)
+	}
+	dir, err = ProfileDir("sam")
+	if err != nil || dir != filepath.Join(base, "gotype", "profiles", "sam") {
+		t.Fatalf("sam dir = %q %v", dir, err)
+	}
+	for _, name := range []string{"", "..", "a/b", "white space"} {
+		if _, err := ProfileDir(name); err == nil {
+			t.Fatalf("profile name %q accepted", name)
+		}
+	}
+
+	for _, name := range []string{"sam", "alex"} {
+		backend, err := Open("json", filepath.Join(base, "gotype", "profiles", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		backend.Close()
+	}
+	profiles, err := Profiles()
+	if err != nil || !slices.Equal(profiles, []string{"default", "alex", "sam"}) {
+		t.Fatalf("profiles = %v %v", profiles, err)
+	}
+}
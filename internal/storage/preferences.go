@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// limits of a test length, custom ones included
+const (
+	MaxDurationSeconds = 24 * 60 * 60
+	MaxWordCount       = 10000
+)
+
+// ValidDuration checks the length of a time test in seconds
+func ValidDuration(seconds int) error {
+	if seconds < 1 || seconds > MaxDurationSeconds {
+		return fmt.Errorf("duration must be between 1s and 24h, got %ds", seconds)
+	}
+	return nil
+}
+
+// ValidWordCount checks the length of a words test
+func ValidWordCount(count int) error {
+	if count < 1 || count > MaxWordCount {
+		return fmt.Errorf("word count must be between 1 and %d, got %d", MaxWordCount, count)
+	}
+	return nil
+}
+
+// ValidColor checks a colour written as #rrggbb, empty is valid and means
+// the colour of the theme
+func ValidColor(color string) error {
+	if color == "" {
+		return nil
+	}
+	if len(color) != 7 || color[0] != '#' {
+		return fmt.Errorf("colour must look like #e06c75, got %q", color)
+	}
+	for _, c := range color[1:] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return fmt.Errorf("colour must look like #e06c75, got %q", color)
+		}
+	}
+	return nil
+}
+
+// Validate checks the test length and the colours of the preferences, zero
+// means not set and keeps the default
+func (p Preferences) Validate() error {
+	var errs []error
+	if p.DurationSeconds != 0 {
+		errs = append(errs, ValidDuration(p.DurationSeconds))
+	}
+	if p.WordCount != 0 {
+		errs = append(errs, ValidWordCount(p.WordCount))
+	}
+	errs = append(errs, ValidColor(p.AccentColor), ValidColor(p.ErrorColor))
+	return errors.Join(errs...)
+}
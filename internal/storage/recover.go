@@ -0,0 +1,280 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeepBackups is how many good states are kept in the backups directory
+const KeepBackups = 5
+
+// ErrCorrupt is returned by Load for a state file that exists but can't be
+// decoded, LoadOrRecover repairs it
+var ErrCorrupt = errors.New("state file is corrupted")
+
+// Recovery describes what LoadOrRecover did with a corrupted state file
+type Recovery struct {
+	// where the broken file was moved to
+	Quarantined string
+	// backup the lost sections were taken from, empty if none was used
+	Backup string
+	// sections of the broken file that still parsed
+	Recovered []string
+	// sections or entries that had to be dropped
+	Lost []string
+	// number of best scores in the repaired state
+	Scores int
+}
+
+// Summary is a one line description for the footer
+func (r Recovery) Summary() string {
+	var source string
+	switch {
+	case len(r.Recovered) > 0 && r.Backup != "":
+		source = "recovered " + strings.Join(r.Recovered, ", ") + ", the rest from a backup"
+	case len(r.Recovered) > 0:
+		source = "recovered " + strings.Join(r.Recovered, ", ")
+	case r.Backup != "":
+		source = "restored the last backup"
+	default:
+		source = "nothing could be recovered"
+	}
+	return fmt.Sprintf("state file was corrupted: %s (%d best scores), old file kept as %s",
+		source, r.Scores, filepath.Base(r.Quarantined))
+}
+
+// LoadOrRecover loads the state file and repairs it when it is corrupted:
+// the broken file is renamed with a timestamp, whatever still parses is
+// kept, lost sections are filled from the newest good backup and the
+// result is saved in its place. Recovery is nil when nothing was wrong.
+func LoadOrRecover(path string, now time.Time) (Data, *Recovery, error) {
+	// a save of another window must not land between the rename and the
+	// write of the repaired file
+	unlock, err := readLock(path)
+	if err != nil {
+		return Data{}, nil, err
+	}
+	defer unlock()
+	return loadOrRecover(path, now)
+}
+
+// loadOrRecover is LoadOrRecover for a caller that holds the lock
+func loadOrRecover(path string, now time.Time) (Data, *Recovery, error) {
+	data, err := Load(path)
+	if !errors.Is(err, ErrCorrupt) {
+		return data, nil, err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return Data{}, nil, err
+	}
+	data, recovery, err := salvage(raw)
+	if err != nil {
+		return Data{}, nil, err
+	}
+	if len(recovery.Lost) > 0 {
+		fillFromBackup(path, &data, &recovery)
+	}
+
+	recovery.Quarantined = fmt.Sprintf("%s.corrupt-%s", path, now.Format("20060102-150405"))
+	if err := os.Rename(path, recovery.Quarantined); err != nil {
+		return Data{}, nil, err
+	}
+	data.Version = CurrentVersion
+	if err := writeState(path, data); err != nil {
+		return Data{}, nil, err
+	}
+	recovery.Scores = len(data.BestScores)
+	return data, &recovery, nil
+}
+
+// salvage keeps every section of a broken file that still decodes, best
+// scores and presets are checked one by one so a single bad entry doesn't
+// lose the rest
+func salvage(raw []byte) (Data, Recovery, error) {
+	var recovery Recovery
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &sections); err != nil {
+		recovery.Lost = []string{"best scores", "preferences", "presets"}
+		return Data{Version: CurrentVersion, BestScores: map[string]BestScore{}}, recovery, nil
+	}
+
+	// only sections that decode are copied, then the normal migrations run
+	clean := map[string]any{}
+	var version int
+	if json.Unmarshal(sections["version"], &version) == nil && version > 0 {
+		if version > CurrentVersion {
+			return Data{}, recovery, fmt.Errorf("%w (file version %d, supported up to %d)",
+				ErrNewerVersion, version, CurrentVersion)
+		}
+		clean["version"] = version
+	}
+	var prefs Preferences
+	if section, ok := sections["preferences"]; ok && json.Unmarshal(section, &prefs) == nil {
+		clean["preferences"] = section
+		recovery.Recovered = append(recovery.Recovered, "preferences")
+		// the time only means something with the preferences it belongs to
+		var at int64
+		if section, ok := sections["preferences_at"]; ok {
+			if json.Unmarshal(section, &at) == nil {
+				clean["preferences_at"] = at
+			} else {
+				recovery.Lost = append(recovery.Lost, "preferences time")
+			}
+		}
+	} else {
+		recovery.Lost = append(recovery.Lost, "preferences")
+	}
+	var entries map[string]json.RawMessage
+	if section, ok := sections["best_scores"]; ok && json.Unmarshal(section, &entries) == nil {
+		scores := map[string]json.RawMessage{}
+		for key, entry := range entries {
+			var score BestScore
+			if json.Unmarshal(entry, &score) != nil {
+				recovery.Lost = append(recovery.Lost, "best score "+key)
+				continue
+			}
+			scores[key] = entry
+		}
+		clean["best_scores"] = scores
+		recovery.Recovered = append(recovery.Recovered, "best scores")
+	} else {
+		recovery.Lost = append(recovery.Lost, "best scores")
+	}
+	// a file without presets has none to lose
+	if section, ok := sections["presets"]; ok {
+		var entries []json.RawMessage
+		if json.Unmarshal(section, &entries) == nil {
+			presets := []json.RawMessage{}
+			for i, entry := range entries {
+				var preset Preset
+				if json.Unmarshal(entry, &preset) != nil || preset.Name == "" {
+					recovery.Lost = append(recovery.Lost, fmt.Sprintf("preset %d", i+1))
+					continue
+				}
+				presets = append(presets, entry)
+			}
+			clean["presets"] = presets
+			recovery.Recovered = append(recovery.Recovered, "presets")
+		} else {
+			recovery.Lost = append(recovery.Lost, "presets")
+		}
+	}
+	sort.Strings(recovery.Lost)
+
+	cleaned, err := json.Marshal(clean)
+	if err != nil {
+		return Data{}, recovery, err
+	}
+	cleaned, _, err = migrate(cleaned)
+	if err != nil {
+		return Data{}, recovery, err
+	}
+	var data Data
+	if err := json.Unmarshal(cleaned, &data); err != nil {
+		return Data{}, recovery, err
+	}
+	if data.BestScores == nil {
+		data.BestScores = map[string]BestScore{}
+	}
+	return data, recovery, nil
+}
+
+// take what salvage couldn't keep from the newest backup that loads, a
+// score or preset only missing from the broken file is added back
+func fillFromBackup(path string, data *Data, recovery *Recovery) {
+	backups, err := Backups(path)
+	if err != nil {
+		return
+	}
+	for _, backup := range backups {
+		saved, err := Load(backup)
+		if err != nil {
+			continue
+		}
+		if !slices.Contains(recovery.Recovered, "preferences") {
+			data.Preferences = saved.Preferences
+			data.PreferencesAt = saved.PreferencesAt
+		}
+		for key, score := range saved.BestScores {
+			if _, ok := data.BestScores[key]; !ok {
+				data.BestScores[key] = score
+			}
+		}
+		for _, preset := range saved.Presets {
+			if !slices.ContainsFunc(data.Presets, func(p Preset) bool { return p.Name == preset.Name }) {
+				data.Presets = append(data.Presets, preset)
+			}
+		}
+		recovery.Backup = backup
+		return
+	}
+}
+
+// BackupDir is the directory the rotating backups of a state file live in
+func BackupDir(path string) string {
+	return filepath.Join(filepath.Dir(path), "backups")
+}
+
+// Backups lists the backups of a state file, newest first
+func Backups(path string) ([]string, error) {
+	names, err := filepath.Glob(filepath.Join(BackupDir(path), "state-*.json"))
+	if err != nil {
+		return nil, err
+	}
+	// the timestamp in the name sorts the same way as the time
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names, nil
+}
+
+// Backup copies the state file into the backups directory when it loads
+// fine and differs from the newest backup, only KeepBackups are kept
+func Backup(path string, now time.Time) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	// a broken state is never a backup
+	if _, err := Load(path); err != nil {
+		return nil
+	}
+	backups, err := Backups(path)
+	if err != nil {
+		return err
+	}
+	if len(backups) > 0 {
+		if newest, err := os.ReadFile(backups[0]); err == nil && bytes.Equal(newest, raw) {
+			return nil
+		}
+	}
+	dir := BackupDir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	name := filepath.Join(dir, "state-"+now.UTC().Format("20060102-150405.000")+".json")
+	if err := os.WriteFile(name, raw, 0o644); err != nil {
+		return err
+	}
+	backups = append([]string{name}, backups...)
+	for _, old := range backups[min(len(backups), KeepBackups):] {
+		_ = os.Remove(old)
+	}
+	return nil
+}
+
+// Quarantined lists the broken files LoadOrRecover moved aside
+func Quarantined(path string) []string {
+	names, _ := filepath.Glob(path + ".corrupt-*")
+	return names
+}
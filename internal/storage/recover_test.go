@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRecoverPartialFile(t *testing.T) {
+	path := fixture(t, "state_v1.json")
+	broken := `{"version":1,"preferences":{"mode":"words","word_count":"x"},` +
+		`"best_scores":{"good|formula=plain":{"wpm":50},"bad|formula=plain":{"wpm":"x"}}}`
+	if err := os.WriteFile(path, []byte(broken), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	data, recovery, err := LoadOrRecover(path, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovery == nil {
+		t.Fatal("expected a recovery")
+	}
+	if _, ok := data.BestScores["good|formula=plain"]; !ok || len(data.BestScores) != 1 {
+		t.Fatalf("scores = %v, want only the good one", data.BestScores)
+	}
+	if len(recovery.Lost) != 2 {
+		t.Fatalf("lost = %v, want preferences and the bad score", recovery.Lost)
+	}
+	quarantined, err := os.ReadFile(recovery.Quarantined)
+	if err != nil || string(quarantined) != broken {
+		t.Fatalf("broken file not kept: %v", err)
+	}
+	// the repaired file loads without another recovery
+	if _, again, err := LoadOrRecover(path, now); err != nil || again != nil {
+		t.Fatalf("repaired file still broken: %v %v", again, err)
+	}
+}
+
+func TestRecoverFromBackup(t *testing.T) {
+	path := fixture(t, "state_v1.json")
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := Backup(path, start); err != nil {
+		t.Fatal(err)
+	}
+	// a truncated write, nothing in it parses
+	if err := os.WriteFile(path, []byte(`{"version":1,"prefer`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, recovery, err := LoadOrRecover(path, start.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovery == nil || recovery.Backup == "" {
+		t.Fatalf("backup not used: %+v", recovery)
+	}
+	if data.Preferences.ThemeID != "gruvbox" || len(data.BestScores) != 1 {
+		t.Fatalf("backup not restored: %+v", data)
+	}
+}
+
+func TestBackupRotation(t *testing.T) {
+	path := fixture(t, "state_v1.json")
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < KeepBackups+3; i++ {
+		data, err := Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data.Preferences.WordCount = 10 + i
+		if err := Save(path, data); err != nil {
+			t.Fatal(err)
+		}
+		if err := Backup(path, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		// an unchanged state is not backed up twice
+		if err := Backup(path, start.Add(time.Duration(i)*time.Second+time.Millisecond)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backups, err := Backups(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != KeepBackups {
+		t.Fatalf("got %d backups, want %d", len(backups), KeepBackups)
+	}
+	newest, err := Load(backups[0])
+	if err != nil || newest.Preferences.WordCount != 10+KeepBackups+2 {
+		t.Fatalf("newest backup = %+v, %v", newest.Preferences, err)
+	}
+}
+
+func TestRecoverPresets(t *testing.T) {
+	path := fixture(t, "state_v2.json")
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	backup := Data{
+		BestScores: map[string]BestScore{},
+		Presets: []Preset{
+			{Name: "sprint", Options: RunOptions{Mode: "time", DurationSeconds: 15}},
+			{Name: "only in the backup", Options: RunOptions{Mode: "words", WordCount: 10}},
+		},
+	}
+	if err := Save(path, backup); err != nil {
+		t.Fatal(err)
+	}
+	if err := Backup(path, start); err != nil {
+		t.Fatal(err)
+	}
+	broken := `{"version":2,"preferences":{"mode":"words"},"preferences_at":1234,` +
+		`"best_scores":{"bad":{"wpm":"x"}},` +
+		`"presets":[{"name":"sprint","options":{"mode":"time","duration_seconds":30}},{"name":7}]}`
+	if err := os.WriteFile(path, []byte(broken), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, recovery, err := LoadOrRecover(path, start.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.PreferencesAt != 1234 || data.Preferences.Mode != "words" {
+		t.Fatalf("preferences = %+v at %d", data.Preferences, data.PreferencesAt)
+	}
+	// the broken file wins over the backup, the bad preset is listed
+	if len(data.Presets) != 2 || data.Presets[0].Options.DurationSeconds != 30 ||
+		data.Presets[1].Name != "only in the backup" {
+		t.Fatalf("presets = %+v", data.Presets)
+	}
+	if !slices.Contains(recovery.Lost, "preset 2") || !slices.Contains(recovery.Recovered, "presets") {
+		t.Fatalf("recovery = %+v", recovery)
+	}
+}
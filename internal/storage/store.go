@@ -2,27 +2,37 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // user for loading and saving the data too file , or creating if not 
-// exists
+// exists. Older files are upgraded with the migrations, files from a newer
+// version are refused with ErrNewerVersion.
 func Load(path string) (Data, error) {
-	file, err := os.Open(path)
+	raw, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return Data{BestScores: map[string]BestScore{}}, nil
+			return Data{Version: CurrentVersion, BestScores: map[string]BestScore{}}, nil
 		}
 		return Data{}, err
 	}
-	defer file.Close()
 
-	decoder := json.NewDecoder(file)
+	// bring the file up to the current shape before decoding it
+	raw, _, err = migrate(raw)
+	if errors.Is(err, ErrNewerVersion) {
+		return Data{}, err
+	}
+	if err != nil {
+		return Data{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
 	var data Data
 	// check for the data if it's valid or not
-	if err := decoder.Decode(&data); err != nil {
-		return Data{}, err
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return Data{}, fmt.Errorf("%w: %v", ErrCorrupt, err)
 	}
 	// if the data is valid but the best scores is nil we need to 
 	// initialize it to an empty map
@@ -33,18 +43,71 @@ func Load(path string) (Data, error) {
 }
 
 // saving data too file -> creating a temp file and then renaming and moving too
-// the target path 
+// the target path. Another gotype may have saved since data was loaded, so
+// the file is re-read under a lock and merged with data first (see Merge).
 func Save(path string, data Data) error {
+	_, err := SaveMerged(path, data)
+	return err
+}
+
+// SaveMerged is Save that also returns the merged state that was written
+func SaveMerged(path string, data Data) (Data, error) {
+	return saveMerged(path, data, &stateTimes{})
+}
+
+// the modification times of the state file before and after a save, both
+// taken under the lock
+type stateTimes struct {
+	before, after time.Time
+}
+
+// saveMerged is SaveMerged that also records the modification times
+func saveMerged(path string, data Data, times *stateTimes) (Data, error) {
 	// ensure the directory exists
 	dir := filepath.Dir(path)
 
 	// create the directory if it doesn't exist, with permissions 0755
 	// 755 -> read and execute permissions for everyone, 
 	// and write permission for the owner
-	if err := os.MkdirAll(dir, 0o755); err != nil { return err }
+	if err := os.MkdirAll(dir, 0o755); err != nil { return Data{}, err }
+
+	// only one process reads, merges and writes at a time
+	unlock, err := lockFile(path)
+	if err != nil {
+		return Data{}, err
+	}
+	defer unlock()
+
+	// never overwrite a file written by a newer version, it may hold
+	// fields this build doesn't know about. A file another window broke
+	// is quarantined and repaired like on start, so its data is merged
+	// instead of replaced.
+	times.before = modTime(path)
+	disk, err := Load(path)
+	if errors.Is(err, ErrCorrupt) {
+		disk, _, err = loadOrRecover(path, time.Now())
+		if err != nil {
+			return Data{}, err
+		}
+	}
+	if errors.Is(err, ErrNewerVersion) {
+		return Data{}, err
+	}
+	if err == nil {
+		data = Merge(disk, data)
+	}
+	data.Version = CurrentVersion
+	if err := writeState(path, data); err != nil {
+		return Data{}, err
+	}
+	times.after = modTime(path)
+	return data, nil
+}
 
+// writeState replaces the file at path with data, the caller holds the lock
+func writeState(path string, data Data) error {
 	// creating a temp file, and remove it after the function returns
-	file, err := os.CreateTemp(dir, "gotype-*.json")
+	file, err := os.CreateTemp(filepath.Dir(path), "gotype-*.json")
 	if err != nil {
 		return err
 	}
@@ -69,3 +132,32 @@ func Save(path string, data Data) error {
 	// rename the temp file to the target path
 	return os.Rename(file.Name(), path)
 }
+
+// Merge combines the state on disk with the one being saved: the higher
+// best score per key wins and the preferences and presets changed last win
+func Merge(disk, data Data) Data {
+	merged := data
+	merged.BestScores = make(map[string]BestScore, len(disk.BestScores)+len(data.BestScores))
+	for key, score := range disk.BestScores {
+		merged.BestScores[key] = score
+	}
+	for key, score := range data.BestScores {
+		if current, ok := merged.BestScores[key]; !ok || betterScore(score, current) {
+			merged.BestScores[key] = score
+		}
+	}
+	if disk.PreferencesAt > data.PreferencesAt {
+		merged.Preferences = disk.Preferences
+		merged.Presets = disk.Presets
+		merged.PreferencesAt = disk.PreferencesAt
+	}
+	return merged
+}
+
+// higher speed wins, on a tie the more accurate one
+func betterScore(score, current BestScore) bool {
+	if score.WPM != current.WPM {
+		return score.WPM > current.WPM
+	}
+	return score.Accuracy > current.Accuracy
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveMergesWithDisk(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	// two windows loaded the same empty state and save their own copy
+	first := Data{
+		Preferences:   Preferences{Mode: "words"},
+		PreferencesAt: 200,
+		Presets:       []Preset{{Name: "exam", Options: RunOptions{Mode: "time", DurationSeconds: 600}}},
+		BestScores: map[string]BestScore{
+			"a": {WPM: 80, Accuracy: 95},
+			"b": {WPM: 40},
+		},
+	}
+	second := Data{
+		Preferences:   Preferences{Mode: "time"},
+		PreferencesAt: 100,
+		BestScores: map[string]BestScore{
+			"a": {WPM: 70},
+			"b": {WPM: 60},
+			"c": {WPM: 50},
+		},
+	}
+	if err := Save(path, first); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := SaveMerged(path, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]int{"a": 80, "b": 60, "c": 50} {
+		if data.BestScores[key].WPM != want || merged.BestScores[key].WPM != want {
+			t.Fatalf("best %s = %d, want %d", key, data.BestScores[key].WPM, want)
+		}
+	}
+	// the first window changed its preferences last
+	if data.Preferences.Mode != "words" || data.PreferencesAt != 200 {
+		t.Fatalf("preferences = %+v at %d", data.Preferences, data.PreferencesAt)
+	}
+	// and the presets go with them
+	if len(data.Presets) != 1 || data.Presets[0].Name != "exam" {
+		t.Fatalf("presets = %+v", data.Presets)
+	}
+}
+
+func TestSaveRepairsBrokenFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	// another window left a file with one bad score
+	broken := `{"version":2,"best_scores":{"a":{"wpm":50},"b":{"wpm":"x"}}}`
+	if err := os.WriteFile(path, []byte(broken), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(path, Data{BestScores: map[string]BestScore{"c": {WPM: 60}}}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := Load(path)
+	if err != nil || len(data.BestScores) != 2 || data.BestScores["a"].WPM != 50 {
+		t.Fatalf("saved %+v %v", data.BestScores, err)
+	}
+	if quarantined := Quarantined(path); len(quarantined) != 1 {
+		t.Fatalf("quarantined = %v", quarantined)
+	}
+}
@@ -9,15 +9,113 @@ type Preferences struct {
 	WordCount       int    `json:"word_count"`
 	Punctuation     bool   `json:"punctuation"`
 	Numbers         bool   `json:"numbers"`
+	Formula         string `json:"formula"`
+	Unit            string `json:"unit"`
+	// colours over the theme as #rrggbb, empty keeps the theme's
+	AccentColor string `json:"accent_color,omitempty"`
+	ErrorColor  string `json:"error_color,omitempty"`
+	// what the typing screen shows
+	HideKeyboard bool `json:"hide_keyboard,omitempty"`
+	LiveStats    bool `json:"live_stats,omitempty"`
+	// how mistakes show besides their colour
+	ErrorMark string `json:"error_mark,omitempty"`
+	ShowTyped bool   `json:"show_typed,omitempty"`
 }
 
+// best score for one score key, formula tells how the speed was measured
+// so numbers from different formulas are never compared
 type BestScore struct {
-	WPM       int   `json:"wpm"`
-	Accuracy  int   `json:"accuracy"`
-	Timestamp int64 `json:"timestamp"`
+	WPM       int    `json:"wpm"`
+	CPM       int    `json:"cpm,omitempty"`
+	Accuracy  int    `json:"accuracy"`
+	Formula   string `json:"formula,omitempty"`
+	Timestamp int64  `json:"timestamp"`
 }
 
+// Data is the whole state file, Version is the schema version it was
+// written with (see CurrentVersion and migrate.go)
 type Data struct {
-	Preferences Preferences          `json:"preferences"`
-	BestScores  map[string]BestScore `json:"best_scores"`
+	Version     int         `json:"version"`
+	Preferences Preferences `json:"preferences"`
+	// unix ms of the last preferences change, the newer ones win when two
+	// windows save
+	PreferencesAt int64                `json:"preferences_at,omitempty"`
+	BestScores    map[string]BestScore `json:"best_scores"`
+	// saved test setups, they change together with the preferences
+	Presets []Preset `json:"presets,omitempty"`
+}
+
+// Preset is a named test setup the user saved, its runs have their own
+// best scores
+type Preset struct {
+	Name    string     `json:"name"`
+	Options RunOptions `json:"options"`
+	ThemeID string     `json:"theme_id,omitempty"`
+}
+
+// options of a finished test, stored with every run in the history
+type RunOptions struct {
+	Mode            string `json:"mode"`
+	DurationSeconds int    `json:"duration_seconds,omitempty"`
+	WordCount       int    `json:"word_count,omitempty"`
+	Punctuation     bool   `json:"punctuation"`
+	Numbers         bool   `json:"numbers"`
+	Language        string `json:"language"`
+	Formula         string `json:"formula"`
+	// the preset the test was started from, if any
+	Preset string `json:"preset,omitempty"`
+}
+
+// all the numbers measured for one run
+type Metrics struct {
+	WPM         int `json:"wpm"`
+	RawWPM      int `json:"raw_wpm"`
+	CPM         int `json:"cpm"`
+	RawCPM      int `json:"raw_cpm"`
+	Accuracy    int `json:"accuracy"`
+	Consistency int `json:"consistency"`
+	Correct     int `json:"correct"`
+	Incorrect   int `json:"incorrect"`
+	Keystrokes  int `json:"keystrokes"`
+}
+
+// hits and misses for one key during a run
+type KeyStat struct {
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+// speed and errors at one second of a run
+type Sample struct {
+	Second int `json:"s"`
+	WPM    int `json:"wpm"`
+	Raw    int `json:"raw"`
+	Errors int `json:"err"`
+}
+
+// Summary is the small part of a run that is kept in memory for listing,
+// sorting and filtering, times are unix milliseconds
+type Summary struct {
+	ID        string     `json:"id"`
+	Key       string     `json:"key"`
+	Options   RunOptions `json:"options"`
+	Seed      int64      `json:"seed"`
+	StartedAt int64      `json:"started_at"`
+	EndedAt   int64      `json:"ended_at"`
+	Metrics   Metrics    `json:"metrics"`
+}
+
+// one key press of a run, at is milliseconds since the start of the test,
+// backspace is stored as '\b' and delete word as ctrl+w (0x17)
+type Keystroke struct {
+	At   int64 `json:"t"`
+	Rune rune  `json:"r"`
+}
+
+// Run is one completed test as written to the history file
+type Run struct {
+	Summary
+	Keys    map[string]KeyStat `json:"keys,omitempty"`
+	Samples []Sample           `json:"samples,omitempty"`
+	Log     []Keystroke        `json:"log,omitempty"`
 }
@@ -0,0 +1,141 @@
+package themes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RGB is a colour with 8 bits per channel
+type RGB struct {
+	R, G, B uint8
+}
+
+// ParseHex reads #rrggbb, #rgb or #rrggbbaa, the # can be left out and
+// the alpha is dropped
+func ParseHex(text string) (RGB, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(text), "#")
+	switch len(hex) {
+	case 3:
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	case 8:
+		hex = hex[:6]
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return RGB{}, fmt.Errorf("invalid colour %q", text)
+	}
+	return RGB{uint8(value >> 16), uint8(value >> 8), uint8(value)}, nil
+}
+
+// Hex is the colour as #rrggbb
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Luminance is the relative luminance of WCAG 2, 0 for black and 1 for
+// white
+func (c RGB) Luminance() float64 {
+	channel := func(v uint8) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*channel(c.R) + 0.7152*channel(c.G) + 0.0722*channel(c.B)
+}
+
+// Contrast is the WCAG contrast ratio of two colours, from 1 for the same
+// colour to 21 for black on white
+func Contrast(a, b RGB) float64 {
+	la, lb := a.Luminance(), b.Luminance()
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// Mix is the colour t of the way from a to b
+func Mix(a, b RGB, t float64) RGB {
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x) + (float64(y)-float64(x))*t))
+	}
+	return RGB{mix(a.R, b.R), mix(a.G, b.G), mix(a.B, b.B)}
+}
+
+// MostContrast is the colour of options that stands out most on bg
+func MostContrast(bg RGB, options ...RGB) RGB {
+	best := options[0]
+	for _, option := range options[1:] {
+		if Contrast(option, bg) > Contrast(best, bg) {
+			best = option
+		}
+	}
+	return best
+}
+
+// EnsureContrast moves fg towards black or white, whichever is further
+// from bg, until the two reach ratio
+func EnsureContrast(fg, bg RGB, ratio float64) RGB {
+	target := RGB{255, 255, 255}
+	if bg.Luminance() > 0.18 {
+		target = RGB{}
+	}
+	for t := 0.0; t <= 1; t += 0.05 {
+		if c := Mix(fg, target, t); Contrast(c, bg) >= ratio {
+			return c
+		}
+	}
+	return target
+}
+
+// HSL is the colour as a hue in degrees and a saturation and a lightness
+// from 0 to 1
+func (c RGB) HSL() (h, s, l float64) {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+	high, low := max(r, g, b), min(r, g, b)
+	l = (high + low) / 2
+	if high == low {
+		return 0, 0, l
+	}
+	d := high - low
+	s = d / (1 - math.Abs(2*l-1))
+	switch high {
+	case r:
+		h = math.Mod((g-b)/d+6, 6)
+	case g:
+		h = (b-r)/d + 2
+	default:
+		h = (r-g)/d + 4
+	}
+	return h * 60, s, l
+}
+
+// FromHSL is the colour of a hue in degrees and a saturation and a
+// lightness from 0 to 1
+func FromHSL(h, s, l float64) RGB {
+	h = math.Mod(math.Mod(h, 360)+360, 360)
+	s, l = math.Max(0, math.Min(1, s)), math.Max(0, math.Min(1, l))
+	chroma := (1 - math.Abs(2*l-1)) * s
+	x := chroma * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g = chroma, x
+	case h < 120:
+		r, g = x, chroma
+	case h < 180:
+		g, b = chroma, x
+	case h < 240:
+		g, b = x, chroma
+	case h < 300:
+		r, b = x, chroma
+	default:
+		r, b = chroma, x
+	}
+	m := l - chroma/2
+	channel := func(v float64) uint8 { return uint8(math.Round((v + m) * 255)) }
+	return RGB{channel(r), channel(g), channel(b)}
+}
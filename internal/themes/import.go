@@ -0,0 +1,298 @@
+package themes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// the colours a scheme has, file derives the empty ones
+type palette struct {
+	name  string
+	bg    string
+	text  string
+	sub   string
+	main  string
+	caret string
+	err   string
+	panel string
+	keyBg string
+}
+
+// monkeytype's error colour, for the theme lists that don't have one
+const monkeytypeError = "#ca4754"
+
+// the order of the colours of a monkeytype custom theme
+var monkeytypeCustomOrder = []string{"bg", "main", "caret", "sub", "subalt", "text", "error"}
+
+// Import converts the colour schemes in data to theme files, name is the
+// file name and picks the format: monkeytype css variables (.css), a
+// monkeytype theme, custom theme or theme list (.json) or a base16 or
+// base24 scheme (.yaml). The id comes from the scheme name or the file.
+func Import(name string, data []byte) ([]File, error) {
+	var palettes []palette
+	var err error
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".css":
+		palettes, err = parseMonkeytypeCSS(data)
+	case ".json":
+		palettes, err = parseMonkeytypeJSON(data)
+	case ".yaml", ".yml":
+		palettes, err = parseBase16(data)
+	default:
+		return nil, fmt.Errorf("unknown scheme format %q (want .css, .json or .yaml)", filepath.Ext(name))
+	}
+	if err != nil {
+		return nil, err
+	}
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	files := make([]File, 0, len(palettes))
+	for _, p := range palettes {
+		if p.name == "" {
+			p.name = base
+		}
+		file, err := p.file()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+var cssVariable = regexp.MustCompile(`--([a-z-]+?)(?:-color)?\s*:\s*(#[0-9a-fA-F]{3,8})\b`)
+
+// the --bg-color, --main-color... variables of a monkeytype theme
+func parseMonkeytypeCSS(data []byte) ([]palette, error) {
+	colors := map[string]string{}
+	for _, match := range cssVariable.FindAllStringSubmatch(string(data), -1) {
+		colors[strings.ReplaceAll(match[1], "-", "")] = match[2]
+	}
+	if len(colors) == 0 {
+		return nil, errors.New("no --bg-color or other monkeytype colour variables")
+	}
+	return []palette{monkeytypePalette(colors)}, nil
+}
+
+// a theme object, a list of them or the colour list of a custom theme
+func parseMonkeytypeJSON(data []byte) ([]palette, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	switch value := value.(type) {
+	case map[string]any:
+		// a custom theme can be wrapped with its name
+		if list, ok := value["colors"].([]any); ok {
+			p := customPalette(list)
+			p.name, _ = value["name"].(string)
+			return []palette{p}, nil
+		}
+		return []palette{monkeytypePalette(jsonColors(value))}, nil
+	case []any:
+		if len(value) > 0 {
+			if _, ok := value[0].(string); ok {
+				return []palette{customPalette(value)}, nil
+			}
+		}
+		var palettes []palette
+		for _, item := range value {
+			object, ok := item.(map[string]any)
+			if !ok {
+				return nil, errors.New("a theme list holds objects")
+			}
+			palettes = append(palettes, monkeytypePalette(jsonColors(object)))
+		}
+		if len(palettes) == 0 {
+			return nil, errors.New("the theme list is empty")
+		}
+		return palettes, nil
+	}
+	return nil, errors.New("want a theme object, a theme list or a colour list")
+}
+
+// the string values of object by their short name: bgColor, bg_color and
+// bg are all bg
+func jsonColors(object map[string]any) map[string]string {
+	colors := map[string]string{}
+	for key, value := range object {
+		if text, ok := value.(string); ok {
+			key = strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+			if key != "name" {
+				key = strings.TrimSuffix(key, "color")
+			}
+			colors[key] = text
+		}
+	}
+	return colors
+}
+
+func customPalette(list []any) palette {
+	colors := map[string]string{}
+	for i, key := range monkeytypeCustomOrder {
+		if i < len(list) {
+			colors[key], _ = list[i].(string)
+		}
+	}
+	return monkeytypePalette(colors)
+}
+
+func monkeytypePalette(colors map[string]string) palette {
+	return palette{
+		name:  colors["name"],
+		bg:    colors["bg"],
+		text:  colors["text"],
+		sub:   colors["sub"],
+		main:  colors["main"],
+		caret: colors["caret"],
+		err:   colors["error"],
+		panel: colors["subalt"],
+	}
+}
+
+// a base16 or base24 scheme, the old flat files with scheme: and the
+// newer ones with a palette: map. Only the keys and the plain values are
+// read, so no yaml library is needed.
+func parseBase16(data []byte) ([]palette, error) {
+	values := map[string]string{}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		values[strings.ToLower(strings.TrimSpace(key))] = yamlScalar(value)
+	}
+	color := func(key string) string {
+		if value := values[key]; value != "" && !strings.HasPrefix(value, "#") {
+			return "#" + value
+		}
+		return values[key]
+	}
+	if color("base00") == "" || color("base05") == "" {
+		return nil, errors.New("no base00 and base05 colours, not a base16 scheme")
+	}
+	name := values["name"]
+	if name == "" {
+		name = values["scheme"]
+	}
+	return []palette{{
+		name:  name,
+		bg:    color("base00"),
+		panel: color("base01"),
+		keyBg: color("base02"),
+		sub:   color("base03"),
+		text:  color("base05"),
+		err:   color("base08"),
+		main:  color("base0d"),
+	}}, nil
+}
+
+// a yaml value without its quotes or its comment
+func yamlScalar(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+		if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+			return value[1 : end+1]
+		}
+	}
+	if before, _, ok := strings.Cut(value, " #"); ok {
+		value = before
+	}
+	return strings.TrimSpace(value)
+}
+
+// the theme file of the palette, the colours the scheme doesn't have are
+// derived from the ones it has and the text on coloured parts takes
+// whichever of the background and the text stands out more
+func (p palette) file() (File, error) {
+	var errs []error
+	get := func(key, text string) (RGB, bool) {
+		if text == "" {
+			return RGB{}, false
+		}
+		c, err := ParseHex(text)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+		return c, err == nil
+	}
+	bg, hasBg := get("background", p.bg)
+	text, hasText := get("text", p.text)
+	sub, hasSub := get("sub", p.sub)
+	main, hasMain := get("main", p.main)
+	caret, hasCaret := get("caret", p.caret)
+	errColor, hasErr := get("error", p.err)
+	panel, hasPanel := get("panel", p.panel)
+	keyBg, hasKeyBg := get("key background", p.keyBg)
+	if err := errors.Join(errs...); err != nil {
+		return File{}, err
+	}
+	if !hasBg || !hasText {
+		return File{}, errors.New("needs at least a background and a text colour")
+	}
+
+	if !hasSub {
+		sub = EnsureContrast(Mix(bg, text, 0.45), bg, 2)
+	}
+	switch {
+	case hasMain:
+	case hasCaret:
+		main = caret
+	default:
+		main = text
+	}
+	if !hasErr {
+		errColor, _ = ParseHex(monkeytypeError)
+	}
+	// a panel a little towards the text stands out on dark and light
+	// backgrounds alike, the keys a bit more
+	if !hasPanel {
+		panel = Mix(bg, text, 0.06)
+	}
+	if !hasKeyBg {
+		keyBg = Mix(bg, text, 0.12)
+	}
+	onMain := MostContrast(main, bg, text)
+
+	id := slug(p.name)
+	return File{
+		ID:                  id,
+		Label:               p.name,
+		Background:          bg.Hex(),
+		Panel:               panel.Hex(),
+		Text:                text.Hex(),
+		Dim:                 sub.Hex(),
+		Accent:              main.Hex(),
+		Error:               errColor.Hex(),
+		CursorText:          onMain.Hex(),
+		KeyBackground:       keyBg.Hex(),
+		KeyText:             EnsureContrast(text, keyBg, 3).Hex(),
+		KeyActiveBackground: main.Hex(),
+		KeyActiveText:       onMain.Hex(),
+	}, ValidID(id)
+}
+
+var slugSeparators = regexp.MustCompile(`[^a-z0-9]+`)
+
+// a theme id from a scheme name: "Serika Dark" is serika-dark
+func slug(name string) string {
+	id := strings.Trim(slugSeparators.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(id) > 32 {
+		id = strings.TrimRight(id[:32], "-")
+	}
+	return id
+}
+
+// Encode writes the theme file as toml, the id is the file name and not
+// written
+func Encode(w io.Writer, f File) error {
+	encoder := toml.NewEncoder(w)
+	encoder.Indent = ""
+	return encoder.Encode(f)
+}
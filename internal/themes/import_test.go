@@ -0,0 +1,137 @@
+package themes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestImportMonkeytype(t *testing.T) {
+	css := `:root {
+  --bg-color: #323437;
+  --main-color: #e2b714;
+  --caret-color: #e2b714;
+  --sub-color: #646669;
+  --sub-alt-color: #2c2e31;
+  --text-color: #d1d0c5;
+  --error-color: #ca4754;
+}`
+	files, err := Import("serika_dark.css", []byte(css))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := files[0]
+	if f.ID != "serika-dark" || f.Background != "#323437" || f.Accent != "#e2b714" || f.Panel != "#2c2e31" ||
+		f.Dim != "#646669" || f.KeyActiveBackground != "#e2b714" {
+		t.Fatalf("file = %+v", f)
+	}
+	// the dark background stands out more on the yellow accent
+	if f.CursorText != "#323437" || f.KeyActiveText != "#323437" {
+		t.Fatalf("text on the accent = %s %s", f.CursorText, f.KeyActiveText)
+	}
+
+	// the theme list has no error or sub alt colours, they are derived
+	list := `[{"name": "paper", "bgColor": "#eeeeee", "mainColor": "#444444", "subColor": "#b2b2b2", "textColor": "#444444"},
+		{"name": "nord", "bgColor": "#242933", "mainColor": "#d8dee9", "subColor": "#617b94", "textColor": "#d8dee9"}]`
+	files, err = Import("_list.json", []byte(list))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("list = %v, %v", files, err)
+	}
+	paper := files[0]
+	if paper.Error != monkeytypeError || paper.Panel == paper.Background || paper.CursorText != "#eeeeee" {
+		t.Fatalf("paper = %+v", paper)
+	}
+	for _, f := range files {
+		if err := f.Validate(); err != nil {
+			t.Errorf("%s: %v", f.ID, err)
+		}
+	}
+
+	custom := `["#111111", "#ff8800", "#ff8800", "#555555", "#222222", "#eeeeee", "#ff0000"]`
+	if files, err := Import("mine.json", []byte(custom)); err != nil || files[0].ID != "mine" || files[0].Error != "#ff0000" {
+		t.Fatalf("custom = %v, %v", files, err)
+	}
+	if _, err := Import("bad.json", []byte(`{"bg": "#12345z", "text": "#ffffff"}`)); err == nil || !strings.Contains(err.Error(), "background") {
+		t.Fatalf("bad colour: %v", err)
+	}
+}
+
+func TestImportBase16(t *testing.T) {
+	old := `scheme: "Ocean"
+author: "Chris Kempson (http://chriskempson.com)"
+base00: "2b303b" # background
+base01: "343d46"
+base02: "4f5b66"
+base03: "65737e"
+base04: "a7adba"
+base05: "c0c5ce"
+base08: "bf616a"
+base0D: "8fa1b3"
+`
+	files, err := Import("ocean.yaml", []byte(old))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := files[0]
+	if f.ID != "ocean" || f.Label != "Ocean" || f.Background != "#2b303b" || f.Panel != "#343d46" ||
+		f.KeyBackground != "#4f5b66" || f.Error != "#bf616a" || f.Accent != "#8fa1b3" {
+		t.Fatalf("file = %+v", f)
+	}
+
+	newer := `system: "base24"
+name: "One Light"
+variant: "light"
+palette:
+  base00: "#fafafa"
+  base03: "#a0a1a7"
+  base05: "#383a42"
+  base08: "#e45649"
+  base0D: "#4078f2"
+  base10: "#ffffff"
+`
+	files, err = Import("one-light.yml", []byte(newer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f = files[0]
+	if f.ID != "one-light" || f.Background != "#fafafa" || f.Text != "#383a42" {
+		t.Fatalf("file = %+v", f)
+	}
+	// derived towards the text, so darker on a light background
+	if panel, _ := ParseHex(f.Panel); panel.Luminance() >= mustHex(t, "#fafafa").Luminance() {
+		t.Fatalf("panel %s isn't darker", f.Panel)
+	}
+
+	if _, err := Import("theme.txt", nil); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
+
+func TestContrast(t *testing.T) {
+	black, white := mustHex(t, "#000"), mustHex(t, "#ffffff")
+	if c := Contrast(black, white); c < 20.9 || c > 21.1 {
+		t.Fatalf("contrast = %f", c)
+	}
+	gray := mustHex(t, "#777777")
+	if c := Contrast(EnsureContrast(gray, black, 7), black); c < 7 {
+		t.Fatalf("ensured contrast = %f", c)
+	}
+	// every colour comes back from hsl
+	for _, text := range []string{"#191724", "#ebbcba", "#ffffff", "#000000", "#1f6feb", "#7fd1b9"} {
+		c := mustHex(t, text)
+		if back := FromHSL(c.HSL()); back != c {
+			t.Errorf("%s through hsl is %s", text, back.Hex())
+		}
+	}
+	if h, s, l := mustHex(t, "#ff0000").HSL(); h != 0 || s != 1 || l != 0.5 {
+		t.Fatalf("red is hsl(%v, %v, %v)", h, s, l)
+	}
+}
+
+func mustHex(t *testing.T, text string) RGB {
+	t.Helper()
+	c, err := ParseHex(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
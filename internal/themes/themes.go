@@ -0,0 +1,157 @@
+// Package themes reads the user themes, one toml file per theme in the
+// themes directory next to config.toml. The file name is the theme id and
+// every colour is optional, the missing ones come from the base theme:
+//
+//	label = "night owl"
+//	base = "rose-pine"
+//
+//	background = "#011627"
+//	panel = "#0b2942"
+//	text = "#d6deeb"
+//	dim = "#637777"
+//	accent = "#82aaff"
+//	error = "#ef5350"
+//	cursor_text = "#011627"
+//	key_background = "#0b2942"
+//	key_text = "#d6deeb"
+//	key_active_background = "#82aaff"
+//	key_active_text = "#011627"
+//
+// The base is a built-in theme or another user theme, without one the
+// first built-in theme is used.
+package themes
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/yossefsabry/gotype/internal/storage"
+)
+
+// Ext is the extension of a theme file
+const Ext = ".toml"
+
+// AutoID is the theme that follows the terminal, the light or the dark
+// theme of the config by its background. It is no file and no base.
+const AutoID = "auto"
+
+// ColorKeys are the colours of a theme file in the order of the docs
+var ColorKeys = []string{
+	"background",
+	"panel",
+	"text",
+	"dim",
+	"accent",
+	"error",
+	"cursor_text",
+	"key_background",
+	"key_text",
+	"key_active_background",
+	"key_active_text",
+}
+
+// File is one theme file, the colours are #rrggbb and empty ones come
+// from the base
+type File struct {
+	// the file name without the extension
+	ID    string `toml:"-"`
+	Label string `toml:"label,omitempty"`
+	Base  string `toml:"base,omitempty"`
+
+	Background          string `toml:"background,omitempty"`
+	Panel               string `toml:"panel,omitempty"`
+	Text                string `toml:"text,omitempty"`
+	Dim                 string `toml:"dim,omitempty"`
+	Accent              string `toml:"accent,omitempty"`
+	Error               string `toml:"error,omitempty"`
+	CursorText          string `toml:"cursor_text,omitempty"`
+	KeyBackground       string `toml:"key_background,omitempty"`
+	KeyText             string `toml:"key_text,omitempty"`
+	KeyActiveBackground string `toml:"key_active_background,omitempty"`
+	KeyActiveText       string `toml:"key_active_text,omitempty"`
+}
+
+// Colors returns the colours of the file in ColorKeys order
+func (f *File) Colors() []*string {
+	return []*string{
+		&f.Background, &f.Panel, &f.Text, &f.Dim, &f.Accent, &f.Error,
+		&f.CursorText, &f.KeyBackground, &f.KeyText, &f.KeyActiveBackground, &f.KeyActiveText,
+	}
+}
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// ValidID checks a theme id, it is the file name so it follows the rules
+// of profile names
+func ValidID(id string) error {
+	if !idPattern.MatchString(id) {
+		return fmt.Errorf("invalid theme id %q (use up to 32 letters, digits, - and _)", id)
+	}
+	return nil
+}
+
+// Validate checks the id and the colours of the file
+func (f *File) Validate() error {
+	errs := []error{ValidID(f.ID)}
+	for i, color := range f.Colors() {
+		if err := storage.ValidColor(*color); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", ColorKeys[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Read reads and checks the theme file at path, unknown keys are errors so
+// a typo doesn't go unnoticed
+func Read(path string) (File, error) {
+	file := File{ID: strings.TrimSuffix(filepath.Base(path), Ext)}
+	meta, err := toml.DecodeFile(path, &file)
+	if err != nil {
+		return File{}, err
+	}
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return File{}, fmt.Errorf("unknown key %s", strings.Join(keys, ", "))
+	}
+	return file, file.Validate()
+}
+
+// Load reads every theme file in dir sorted by name, a missing dir has
+// none. The error names every broken file, the good ones are returned
+// anyway.
+func Load(dir string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if !entry.IsDir() && filepath.Ext(entry.Name()) == Ext {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(paths)
+	var files []File
+	var errs []error
+	for _, path := range paths {
+		file, err := Read(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, errors.Join(errs...)
+}
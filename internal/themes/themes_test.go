@@ -0,0 +1,35 @@
+package themes
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"night.toml":  "label = \"night\"\nbase = \"forest\"\naccent = \"#82aaff\"\n",
+		"broken.toml": "accent = \"blue\"\nshadow = \"#000000\"\n",
+		"bad id.toml": "",
+		"notes.txt":   "not a theme",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := Load(dir)
+	if len(files) != 1 || files[0].ID != "night" || files[0].Base != "forest" || files[0].Accent != "#82aaff" {
+		t.Fatalf("files = %+v", files)
+	}
+	for _, want := range []string{`broken.toml: unknown key shadow`, `bad id.toml: invalid theme id "bad id"`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error %v, want %q", err, want)
+		}
+	}
+
+	if files, err := Load(filepath.Join(dir, "missing")); files != nil || err != nil {
+		t.Fatalf("missing dir: %v, %v", files, err)
+	}
+}
@@ -1,16 +1,12 @@
 package main
 
 import (
-	"fmt"
 	"os"
 
 	"github.com/yossefsabry/gotype/internal/app"
 )
 
+// start the typing test or run a command, see `gotype help`
 func main() {
-	// start application
-	if err := app.Run(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
-	}
+	os.Exit(app.Main(os.Args[1:], os.Stdout, os.Stderr))
 }